@@ -2,8 +2,9 @@ package main
 
 import (
 	"log"
-	"net"
 	"net/rpc"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
 )
 
 type HelloService struct{}
@@ -16,15 +17,6 @@ func (p *HelloService) Hello(request string, reply *string) error {
 func main() {
 	rpc.Register(new(HelloService))
 
-	listener, err := net.Listen("tcp", ":1234")
-	if err != nil {
-		log.Fatal("ListenTCP error:", err)
-	}
-
-	conn, err := listener.Accept()
-	if err != nil {
-		log.Fatal("Accept error:", err)
-	}
-
-	rpc.ServeConn(conn)
+	server := rpcserver.NewServer(rpc.DefaultServer)
+	log.Fatal(server.ListenAndServe("tcp", ":1234"))
 }
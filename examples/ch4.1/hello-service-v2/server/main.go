@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net"
 	"net/rpc"
+	"os"
+	"os/signal"
+	"time"
 
 	"gobook.examples/ch4-01-rpc-intro/hello-service-v2/api"
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
 )
 
 type HelloService struct{}
@@ -18,17 +22,27 @@ func (p *HelloService) Hello(request string, reply *string) error {
 func main() {
 	api.RegisterHelloService(new(HelloService))
 
-	listener, err := net.Listen("tcp", ":1234")
-	if err != nil {
-		log.Fatal("ListenTCP error:", err)
+	server := &rpcserver.Server{
+		Server:      rpc.DefaultServer,
+		MaxConns:    100,
+		IdleTimeout: time.Minute,
+		ReadTimeout: 10 * time.Second,
 	}
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Fatal("Accept error:", err)
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Shutdown error:", err)
 		}
+	}()
 
-		go rpc.ServeConn(conn)
+	err := server.ListenAndServe("tcp", ":1234")
+	if err != rpcserver.ErrServerClosed {
+		log.Fatal(err)
 	}
 }
@@ -1,10 +1,9 @@
 package main
 
 import (
-	"io"
 	"net/http"
-	"net/rpc"
-	"net/rpc/jsonrpc"
+
+	"gobook.examples/ch4-01-rpc-intro/jsonrpc2"
 )
 
 type HelloService struct{}
@@ -15,20 +14,12 @@ func (p *HelloService) Hello(request string, reply *string) error {
 }
 
 func main() {
-	rpc.RegisterName("HelloService", new(HelloService))
-
-	// curl localhost:1234/jsonrpc --data '{"method":"HelloService.Hello","params":["hello"],"id":0}'
-	http.HandleFunc("/jsonrpc", func(w http.ResponseWriter, r *http.Request) {
-		var conn io.ReadWriteCloser = struct {
-			io.Writer
-			io.ReadCloser
-		}{
-			ReadCloser: r.Body,
-			Writer:     w,
-		}
+	srv := jsonrpc2.NewServer()
+	srv.RegisterName("HelloService", new(HelloService))
 
-		rpc.ServeRequest(jsonrpc.NewServerCodec(conn))
-	})
+	// curl localhost:1234/jsonrpc --data '{"jsonrpc":"2.0","method":"HelloService.Hello","params":["hello"],"id":0}'
+	// curl localhost:1234/jsonrpc --data '[{"jsonrpc":"2.0","method":"HelloService.Hello","params":["a"],"id":1},{"jsonrpc":"2.0","method":"HelloService.Hello","params":["b"]}]'
+	http.Handle("/jsonrpc", jsonrpc2.NewHandler(srv))
 
 	http.ListenAndServe(":1234", nil)
 }
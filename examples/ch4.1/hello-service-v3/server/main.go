@@ -4,9 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
 )
 
 type HelloService struct{}
@@ -23,18 +24,11 @@ func main() {
 
 	rpc.RegisterName("HelloService", new(HelloService))
 
-	// nc -l 2399
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *flagPort))
-	if err != nil {
-		log.Fatal("ListenTCP error:", err)
+	server := &rpcserver.Server{
+		Server:   rpc.DefaultServer,
+		NewCodec: jsonrpc.NewServerCodec,
 	}
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Fatal("Accept error:", err)
-		}
-
-		go rpc.ServeCodec(jsonrpc.NewServerCodec(conn))
-	}
+	// nc -l 2399
+	log.Fatal(server.ListenAndServe("tcp", fmt.Sprintf(":%d", *flagPort)))
 }
@@ -0,0 +1,50 @@
+package jsonrpc2
+
+import (
+	"io"
+	"net/http"
+)
+
+// NewHandler returns a handler serving the JSON-RPC 2.0 request, or batch,
+// in the body of each POST with srv. The response is empty with status 204
+// if there is nothing to answer, such as for notifications.
+//
+//	curl localhost:1234/jsonrpc --data '{"jsonrpc":"2.0","method":"HelloService.Hello","params":["hello"],"id":0}'
+func NewHandler(srv *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+		var conn io.ReadWriteCloser = struct {
+			io.Writer
+			io.ReadCloser
+		}{
+			ReadCloser: r.Body,
+			Writer:     rw,
+		}
+
+		// ServeCodec returns at the end of the body, after all the
+		// responses are written
+		srv.ServeCodec(srv.NewServerCodec(conn))
+		if !rw.written {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.written {
+		w.written = true
+		w.Header().Set("Content-Type", "application/json")
+	}
+	return w.ResponseWriter.Write(p)
+}
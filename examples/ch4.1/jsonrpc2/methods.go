@@ -0,0 +1,80 @@
+package jsonrpc2
+
+import (
+	"go/token"
+	"net/rpc"
+	"reflect"
+	"sync"
+)
+
+// Server is an rpc.Server which records the methods of the services
+// registered with it. Register the services through Server, not through
+// the embedded rpc.Server, or their methods are not found.
+type Server struct {
+	*rpc.Server
+
+	mu      sync.RWMutex
+	methods map[string]bool // "Service.Method"
+}
+
+// NewServer returns a Server without services.
+func NewServer() *Server {
+	return &Server{Server: rpc.NewServer(), methods: make(map[string]bool)}
+}
+
+// Register is like rpc.Server.Register.
+func (s *Server) Register(rcvr interface{}) error {
+	return s.register(rcvr, reflect.Indirect(reflect.ValueOf(rcvr)).Type().Name())
+}
+
+// RegisterName is like rpc.Server.RegisterName.
+func (s *Server) RegisterName(name string, rcvr interface{}) error {
+	return s.register(rcvr, name)
+}
+
+func (s *Server) register(rcvr interface{}, name string) error {
+	if err := s.Server.RegisterName(name, rcvr); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	typ := reflect.TypeOf(rcvr)
+	for i := 0; i < typ.NumMethod(); i++ {
+		if m := typ.Method(i); isRPCMethod(m) {
+			s.methods[name+"."+m.Name] = true
+		}
+	}
+	return nil
+}
+
+func (s *Server) hasMethod(serviceMethod string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.methods[serviceMethod]
+}
+
+var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+
+// isRPCMethod applies the rules of net/rpc:
+//
+//	func (t *T) MethodName(argType T1, replyType *T2) error
+//
+// where T1 and T2 are exported or builtin.
+func isRPCMethod(m reflect.Method) bool {
+	mtype := m.Type
+	if m.PkgPath != "" || mtype.NumIn() != 3 || mtype.NumOut() != 1 {
+		return false
+	}
+	argType, replyType := mtype.In(1), mtype.In(2)
+	return isExportedOrBuiltinType(argType) &&
+		replyType.Kind() == reflect.Ptr && isExportedOrBuiltinType(replyType) &&
+		mtype.Out(0) == typeOfError
+}
+
+func isExportedOrBuiltinType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return token.IsExported(t.Name()) || t.PkgPath() == ""
+}
@@ -0,0 +1,342 @@
+// Package jsonrpc2 implements a JSON-RPC 2.0 ServerCodec for net/rpc, with
+// batches, notifications and the standard error objects, so that the
+// services registered on an rpc.Server can be called by any JSON-RPC 2.0
+// client.
+//
+// The services are registered on a Server, which knows their methods and
+// answers the unknown ones with "Method not found" before dispatching.
+//
+// The params of a request are decoded into the argument of the method:
+// an array must hold exactly one value, an object is the argument itself.
+package jsonrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/rpc"
+	"sync"
+)
+
+const Version = "2.0"
+
+// The error codes of the JSON-RPC 2.0 specification. A method returning an
+// error gets CodeServerError.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// Error is the error object of a response.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var null = json.RawMessage("null")
+
+type serverRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type serverResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// batch collects the responses of a batch request, which are written
+// together once all of them are there.
+type batch struct {
+	responses []*serverResponse
+	pending   int
+}
+
+type pendingRequest struct {
+	id            json.RawMessage // nil for notifications
+	params        json.RawMessage
+	batch         *batch
+	index         int
+	invalidParams bool
+}
+
+type serverCodec struct {
+	srv *Server
+	dec *json.Decoder
+	enc *json.Encoder
+	c   io.Closer
+
+	// queue holds the valid requests of a batch not read yet.
+	queue []*serverRequest
+	qb    *batch
+	qi    []int
+
+	mu      sync.Mutex // protects seq, pending and the writes
+	seq     uint64
+	pending map[uint64]*pendingRequest
+	current *pendingRequest
+}
+
+// NewServerCodec returns a JSON-RPC 2.0 codec for the requests on conn to
+// the methods of s.
+func (s *Server) NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &serverCodec{
+		srv:     s,
+		dec:     json.NewDecoder(conn),
+		enc:     json.NewEncoder(conn),
+		c:       conn,
+		pending: make(map[uint64]*pendingRequest),
+	}
+}
+
+// ServeConn serves the JSON-RPC 2.0 requests on conn.
+func (s *Server) ServeConn(conn io.ReadWriteCloser) {
+	s.ServeCodec(s.NewServerCodec(conn))
+}
+
+// ReadRequestHeader answers the requests to unknown methods itself, so
+// that net/rpc only sees the ones it can dispatch.
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		for len(c.queue) == 0 {
+			if err := c.readMessage(); err != nil {
+				return err
+			}
+		}
+
+		req, b, index := c.queue[0], c.qb, c.qi[0]
+		c.queue, c.qi = c.queue[1:], c.qi[1:]
+		p := &pendingRequest{id: req.ID, params: req.Params, batch: b, index: index}
+
+		c.mu.Lock()
+		if !c.srv.hasMethod(req.Method) {
+			err := c.reply(p, newErrorResponse(p.id, CodeMethodNotFound, "Method not found",
+				errors.New("jsonrpc2: method "+req.Method+" not found")))
+			c.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		c.seq++
+		c.pending[c.seq] = p
+		c.current = p
+		r.ServiceMethod = req.Method
+		r.Seq = c.seq
+		c.mu.Unlock()
+		return nil
+	}
+}
+
+// readMessage reads a request or a batch, queueing the valid requests
+// and answering the invalid ones.
+func (c *serverCodec) readMessage() error {
+	var raw json.RawMessage
+	if err := c.dec.Decode(&raw); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		// the stream cannot be resynchronized after a syntax error
+		c.writeError(null, CodeParseError, "Parse error", err)
+		return io.EOF
+	}
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' {
+		req, id, err := parseRequest(raw)
+		if err != nil {
+			c.writeError(id, CodeInvalidRequest, "Invalid Request", err)
+			return nil
+		}
+		c.queue, c.qb, c.qi = []*serverRequest{req}, nil, []int{0}
+		return nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err != nil || len(list) == 0 {
+		c.writeError(null, CodeInvalidRequest, "Invalid Request", errors.New("empty batch"))
+		return nil
+	}
+
+	b := &batch{responses: make([]*serverResponse, len(list))}
+	c.queue, c.qb, c.qi = nil, b, nil
+	for i, data := range list {
+		req, id, err := parseRequest(data)
+		if err != nil {
+			b.responses[i] = newErrorResponse(id, CodeInvalidRequest, "Invalid Request", err)
+			continue
+		}
+		c.queue = append(c.queue, req)
+		c.qi = append(c.qi, i)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b.pending = len(c.queue)
+	if b.pending == 0 {
+		return c.writeBatch(b)
+	}
+	return nil
+}
+
+// parseRequest checks a request object. id is the id of the request, or
+// null if it has none or the request is not an object.
+func parseRequest(data json.RawMessage) (*serverRequest, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, null, errors.New("request is not an object")
+	}
+
+	req := new(serverRequest)
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, idOrNull(fields["id"]), err
+	}
+	if id, ok := fields["id"]; ok {
+		req.ID = id
+		switch id[0] {
+		case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		default:
+			return nil, null, errors.New("id must be a string, a number or null")
+		}
+	}
+	if req.Version != Version {
+		return nil, idOrNull(req.ID), errors.New(`jsonrpc must be "2.0"`)
+	}
+	if req.Method == "" {
+		return nil, idOrNull(req.ID), errors.New("method is missing")
+	}
+	if p := bytes.TrimSpace(req.Params); len(p) != 0 && p[0] != '[' && p[0] != '{' {
+		return nil, idOrNull(req.ID), errors.New("params must be an array or an object")
+	}
+	return req, nil, nil
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return null
+	}
+	return id
+}
+
+func (c *serverCodec) ReadRequestBody(x interface{}) error {
+	c.mu.Lock()
+	p := c.current
+	c.mu.Unlock()
+
+	if x == nil || len(p.params) == 0 {
+		return nil
+	}
+
+	params := bytes.TrimSpace(p.params)
+	var err error
+	if params[0] == '[' {
+		var list []json.RawMessage
+		if err = json.Unmarshal(params, &list); err == nil {
+			if len(list) != 1 {
+				err = errors.New("params must hold exactly one value")
+			} else {
+				err = json.Unmarshal(list[0], x)
+			}
+		}
+	} else {
+		err = json.Unmarshal(params, x)
+	}
+
+	if err != nil {
+		c.mu.Lock()
+		p.invalidParams = true
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[r.Seq]
+	if !ok {
+		return errors.New("jsonrpc2: invalid sequence number in response")
+	}
+	delete(c.pending, r.Seq)
+
+	var resp *serverResponse
+	switch {
+	case r.Error == "":
+		resp = &serverResponse{Version: Version, Result: x, ID: p.id}
+		if x == nil {
+			resp.Result = null
+		}
+	case p.invalidParams:
+		resp = newErrorResponse(p.id, CodeInvalidParams, "Invalid params", errors.New(r.Error))
+	default:
+		resp = newErrorResponse(p.id, CodeServerError, r.Error, nil)
+	}
+	return c.reply(p, resp)
+}
+
+// reply writes the response of p, or adds it to its batch. c.mu must be
+// held.
+func (c *serverCodec) reply(p *pendingRequest, resp *serverResponse) error {
+	if p.batch == nil {
+		if p.id == nil {
+			return nil // notification
+		}
+		return c.enc.Encode(resp)
+	}
+
+	if p.id != nil {
+		p.batch.responses[p.index] = resp
+	}
+	if p.batch.pending--; p.batch.pending == 0 {
+		return c.writeBatch(p.batch)
+	}
+	return nil
+}
+
+// writeBatch writes the responses of b, nothing if all of its requests
+// are notifications. c.mu must be held.
+func (c *serverCodec) writeBatch(b *batch) error {
+	var list []*serverResponse
+	for _, resp := range b.responses {
+		if resp != nil {
+			list = append(list, resp)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return c.enc.Encode(list)
+}
+
+func (c *serverCodec) writeError(id json.RawMessage, code int, message string, data error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Encode(newErrorResponse(id, code, message, data))
+}
+
+func newErrorResponse(id json.RawMessage, code int, message string, data error) *serverResponse {
+	e := &Error{Code: code, Message: message}
+	if data != nil {
+		e.Data, _ = json.Marshal(data.Error())
+	}
+	return &serverResponse{Version: Version, Error: e, ID: idOrNull(id)}
+}
+
+func (c *serverCodec) Close() error {
+	return c.c.Close()
+}
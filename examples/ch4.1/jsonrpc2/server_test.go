@@ -0,0 +1,248 @@
+package jsonrpc2
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type HelloService struct{}
+
+func (p *HelloService) Hello(request string, reply *string) error {
+	*reply = "hello:" + request
+	return nil
+}
+
+type AddArgs struct {
+	A, B int
+}
+
+func (p *HelloService) Add(args AddArgs, reply *int) error {
+	*reply = args.A + args.B
+	return nil
+}
+
+func (p *HelloService) Fail(request string, reply *string) error {
+	return errors.New("failed: " + request)
+}
+
+// Fail2 has the wrong signature for net/rpc.
+func (p *HelloService) Fail2(request string) error {
+	return nil
+}
+
+func (p *HelloService) hidden(request string, reply *string) error {
+	return nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv := NewServer()
+	if err := srv.RegisterName("HelloService", new(HelloService)); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(NewHandler(srv))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func post(t *testing.T, ts *httptest.Server, body string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(data)
+}
+
+// jsonEqual reports whether a and b are the same JSON value.
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := json.Unmarshal([]byte(a), &x); err != nil {
+		t.Fatalf("%v: %s", err, a)
+	}
+	if err := json.Unmarshal([]byte(b), &y); err != nil {
+		t.Fatalf("%v: %s", err, b)
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return string(xs) == string(ys)
+}
+
+func TestHandler(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, tt := range []struct {
+		name, request, response string
+	}{
+		{
+			"positional params",
+			`{"jsonrpc":"2.0","method":"HelloService.Hello","params":["gopher"],"id":1}`,
+			`{"jsonrpc":"2.0","result":"hello:gopher","id":1}`,
+		},
+		{
+			"named params",
+			`{"jsonrpc":"2.0","method":"HelloService.Add","params":{"A":1,"B":2},"id":"a"}`,
+			`{"jsonrpc":"2.0","result":3,"id":"a"}`,
+		},
+		{
+			"null id",
+			`{"jsonrpc":"2.0","method":"HelloService.Hello","params":["x"],"id":null}`,
+			`{"jsonrpc":"2.0","result":"hello:x","id":null}`,
+		},
+		{
+			"method not found",
+			`{"jsonrpc":"2.0","method":"HelloService.Bye","params":["x"],"id":2}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method HelloService.Bye not found"},"id":2}`,
+		},
+		{
+			"service not found",
+			`{"jsonrpc":"2.0","method":"Nothing.Hello","id":3}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method Nothing.Hello not found"},"id":3}`,
+		},
+		{
+			"unexported method",
+			`{"jsonrpc":"2.0","method":"HelloService.hidden","id":8}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method HelloService.hidden not found"},"id":8}`,
+		},
+		{
+			"not a method",
+			`{"jsonrpc":"2.0","method":"HelloService","id":9}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method HelloService not found"},"id":9}`,
+		},
+		{
+			"wrong signature",
+			`{"jsonrpc":"2.0","method":"HelloService.Fail2","params":["x"],"id":10}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method HelloService.Fail2 not found"},"id":10}`,
+		},
+		{
+			"invalid params",
+			`{"jsonrpc":"2.0","method":"HelloService.Hello","params":[1],"id":4}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"json: cannot unmarshal number into Go value of type string"},"id":4}`,
+		},
+		{
+			"too many params",
+			`{"jsonrpc":"2.0","method":"HelloService.Hello","params":["a","b"],"id":5}`,
+			`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params must hold exactly one value"},"id":5}`,
+		},
+		{
+			"server error",
+			`{"jsonrpc":"2.0","method":"HelloService.Fail","params":["x"],"id":6}`,
+			`{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed: x"},"id":6}`,
+		},
+		{
+			"wrong version",
+			`{"jsonrpc":"1.0","method":"HelloService.Hello","params":["x"],"id":7}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\""},"id":7}`,
+		},
+		{
+			"bad id",
+			`{"jsonrpc":"2.0","method":"HelloService.Hello","params":["x"],"id":{}}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"id must be a string, a number or null"},"id":null}`,
+		},
+		{
+			"parse error",
+			`{"jsonrpc":"2.0","method"`,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"unexpected EOF"},"id":null}`,
+		},
+		{
+			"empty batch",
+			`[]`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"empty batch"},"id":null}`,
+		},
+		{
+			"batch",
+			`[
+				{"jsonrpc":"2.0","method":"HelloService.Hello","params":["a"],"id":1},
+				{"jsonrpc":"2.0","method":"HelloService.Hello","params":["notified"]},
+				1,
+				{"jsonrpc":"2.0","method":"HelloService.Bye","id":"b"},
+				{"jsonrpc":"2.0","method":"HelloService.Add","params":[{"A":1,"B":1}],"id":3}
+			]`,
+			`[
+				{"jsonrpc":"2.0","result":"hello:a","id":1},
+				{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request is not an object"},"id":null},
+				{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"jsonrpc2: method HelloService.Bye not found"},"id":"b"},
+				{"jsonrpc":"2.0","result":2,"id":3}
+			]`,
+		},
+	} {
+		code, body := post(t, ts, tt.request)
+		if code != http.StatusOK || !jsonEqual(t, body, tt.response) {
+			t.Fatalf("%s: unexpected %d %s", tt.name, code, body)
+		}
+	}
+}
+
+func TestHandlerNotifications(t *testing.T) {
+	ts := newTestServer(t)
+
+	for _, request := range []string{
+		`{"jsonrpc":"2.0","method":"HelloService.Hello","params":["x"]}`,
+		`{"jsonrpc":"2.0","method":"HelloService.Fail","params":["x"]}`,
+		`[{"jsonrpc":"2.0","method":"HelloService.Hello","params":["x"]},{"jsonrpc":"2.0","method":"HelloService.Bye"}]`,
+	} {
+		code, body := post(t, ts, request)
+		if code != http.StatusNoContent || body != "" {
+			t.Fatalf("%s: unexpected %d %s", request, code, body)
+		}
+	}
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status: %d", resp.StatusCode)
+	}
+}
+
+// TestServeConn checks a stream of requests on a connection, the
+// notifications get no response.
+func TestServeConn(t *testing.T) {
+	srv := NewServer()
+	srv.RegisterName("HelloService", new(HelloService))
+
+	conn, serverConn := net.Pipe()
+	defer conn.Close()
+	go srv.ServeConn(serverConn)
+
+	go io.WriteString(conn, `{"jsonrpc":"2.0","method":"HelloService.Hello","params":["a"]}
+		{"jsonrpc":"2.0","method":"HelloService.Hello","params":["b"],"id":1}
+		[{"jsonrpc":"2.0","method":"HelloService.Hello","params":["c"],"id":2}]
+	`)
+
+	// the calls run concurrently, so the responses come in any order
+	expect := map[string]bool{
+		`{"jsonrpc":"2.0","result":"hello:b","id":1}`:   true,
+		`[{"jsonrpc":"2.0","result":"hello:c","id":2}]`: true,
+	}
+	dec := json.NewDecoder(conn)
+	for i := 0; i < 2; i++ {
+		var resp json.RawMessage
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for s := range expect {
+			if jsonEqual(t, string(resp), s) {
+				delete(expect, s)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("unexpected response: %s", resp)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package rpcserver
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// conn applies the timeouts of the server to the reads of the codec and
+// counts the calls in flight.
+type conn struct {
+	net.Conn
+	srv *Server
+
+	mu        sync.Mutex
+	calls     int       // calls read but not answered yet
+	reading   bool      // part of a request has been read
+	readStart time.Time // when the request started
+	closing   bool
+}
+
+func (c *conn) serve() {
+	defer func() {
+		c.Close()
+		c.srv.trackConn(c, false)
+		c.srv.release()
+	}()
+
+	newCodec := c.srv.NewCodec
+	if newCodec == nil {
+		newCodec = NewGobServerCodec
+	}
+	codec := &serverCodec{ServerCodec: newCodec(c), conn: c}
+
+	if c.srv.ServeCodec != nil {
+		c.srv.ServeCodec(c.Conn, codec)
+		return
+	}
+	srv := c.srv.Server
+	if srv == nil {
+		srv = rpc.DefaultServer
+	}
+	srv.ServeCodec(codec)
+}
+
+// Read waits for a request without a deadline while calls are in flight,
+// so that long calls don't hit the idle timeout, and returns io.EOF
+// between two requests once the server is shutting down.
+func (c *conn) Read(p []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		if c.closing && !c.reading {
+			c.mu.Unlock()
+			return 0, io.EOF
+		}
+		c.Conn.SetReadDeadline(c.readDeadline())
+		c.mu.Unlock()
+
+		n, err := c.Conn.Read(p)
+
+		c.mu.Lock()
+		if n > 0 && !c.reading {
+			c.reading, c.readStart = true, time.Now()
+		}
+		retry := n == 0 && isTimeout(err) && !c.reading && (c.closing || c.calls > 0)
+		c.mu.Unlock()
+
+		if !retry {
+			return n, err
+		}
+	}
+}
+
+// readDeadline must be called with c.mu held.
+func (c *conn) readDeadline() time.Time {
+	switch {
+	case c.reading && c.srv.ReadTimeout > 0:
+		return c.readStart.Add(c.srv.ReadTimeout)
+	case !c.reading && c.calls == 0 && c.srv.IdleTimeout > 0:
+		return time.Now().Add(c.srv.IdleTimeout)
+	}
+	return time.Time{}
+}
+
+func (c *conn) startRequest() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reading = false
+}
+
+func (c *conn) startCall() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+}
+
+func (c *conn) endCall() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls--
+	if c.calls == 0 && !c.reading {
+		c.Conn.SetReadDeadline(c.readDeadline())
+	}
+}
+
+// shutdown wakes up the reader if it waits for a request.
+func (c *conn) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closing = true
+	if !c.reading {
+		c.Conn.SetReadDeadline(time.Now())
+	}
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
+
+type serverCodec struct {
+	rpc.ServerCodec
+	conn *conn
+}
+
+// ReadRequestHeader starts a call for every header read: net/rpc always
+// answers it, even when the method or the body is invalid.
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	c.conn.startRequest()
+	if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+		return err
+	}
+	c.conn.startCall()
+	return nil
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	defer c.conn.endCall()
+	return c.ServerCodec.WriteResponse(r, body)
+}
+
+// gobServerCodec is the unexported codec of rpc.ServeConn.
+type gobServerCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+}
+
+// NewGobServerCodec returns the gob codec of rpc.ServeConn, which net/rpc
+// does not export.
+func NewGobServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	buf := bufio.NewWriter(conn)
+	return &gobServerCodec{
+		rwc:    conn,
+		dec:    gob.NewDecoder(conn),
+		enc:    gob.NewEncoder(buf),
+		encBuf: buf,
+	}
+}
+
+func (c *gobServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	return c.dec.Decode(r)
+}
+
+func (c *gobServerCodec) ReadRequestBody(body interface{}) error {
+	return c.dec.Decode(body)
+}
+
+func (c *gobServerCodec) WriteResponse(r *rpc.Response, body interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *gobServerCodec) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
@@ -0,0 +1,254 @@
+// Package rpcserver runs the accept loop of a net/rpc server, with a limit
+// on the connections, idle and read timeouts, retries of the temporary
+// accept errors and a graceful Shutdown.
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// ErrServerClosed is returned by Serve after Shutdown or Close.
+var ErrServerClosed = errors.New("rpcserver: Server closed")
+
+// Server serves the connections accepted on its listeners.
+type Server struct {
+	// Server serves the calls, rpc.DefaultServer if nil.
+	Server *rpc.Server
+
+	// NewCodec returns the codec of a new connection. The default is the
+	// gob codec of rpc.ServeConn.
+	NewCodec func(conn io.ReadWriteCloser) rpc.ServerCodec
+
+	// ServeCodec serves the calls read by codec and returns when codec is
+	// closed. The default is Server.ServeCodec; set it to serve each
+	// connection with its own rpc.Server. conn is the accepted connection,
+	// for its address and TLS state, but the calls must be read through
+	// codec for the timeouts and Shutdown to work.
+	ServeCodec func(conn net.Conn, codec rpc.ServerCodec)
+
+	// MaxConns limits the open connections; Serve stops accepting while
+	// the limit is reached. Zero means no limit.
+	MaxConns int
+
+	// IdleTimeout closes a connection without a call in flight after that
+	// long without a new request. Zero means no timeout.
+	IdleTimeout time.Duration
+
+	// ReadTimeout limits the time to read a request, from its first byte
+	// to the end of its body. Zero means no timeout.
+	ReadTimeout time.Duration
+
+	// ErrorLog logs the accept errors, the log package if nil.
+	ErrorLog *log.Logger
+
+	initOnce  sync.Once
+	sem       chan struct{}
+	done      chan struct{}
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	closed    bool
+}
+
+// NewServer returns a Server for the services registered on srv.
+func NewServer(srv *rpc.Server) *Server {
+	return &Server{Server: srv}
+}
+
+// ListenAndServe listens on the network address and calls Serve.
+func (s *Server) ListenAndServe(network, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts the connections on l and serves each of them in a new
+// goroutine. It always returns a non-nil error and closes l; after
+// Shutdown or Close the error is ErrServerClosed.
+func (s *Server) Serve(l net.Listener) error {
+	s.init()
+	if !s.trackListener(l, true) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.trackListener(l, false)
+
+	var delay time.Duration
+	for {
+		if !s.acquire() {
+			return ErrServerClosed
+		}
+
+		rw, err := l.Accept()
+		if err != nil {
+			s.release()
+			if s.shuttingDown() {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay *= 2; delay > time.Second {
+					delay = time.Second
+				}
+				s.logf("rpcserver: accept error: %v; retrying in %v", err, delay)
+				time.Sleep(delay)
+				continue
+			}
+			return err
+		}
+		delay = 0
+
+		c := &conn{Conn: rw, srv: s}
+		if !s.trackConn(c, true) {
+			s.release()
+			rw.Close()
+			return ErrServerClosed
+		}
+		go c.serve()
+	}
+}
+
+// Shutdown closes the listeners, then lets each connection finish the
+// calls in flight and closes it. It returns when all the connections are
+// closed, or with the error of ctx when ctx is done first.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.init()
+	s.mu.Lock()
+	s.close()
+	for c := range s.conns {
+		c.shutdown()
+	}
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		s.mu.Lock()
+		n := len(s.conns)
+		s.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the listeners and the connections at once.
+func (s *Server) Close() error {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.close()
+	for c := range s.conns {
+		c.Close()
+	}
+	return err
+}
+
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		if s.MaxConns > 0 {
+			s.sem = make(chan struct{}, s.MaxConns)
+		}
+		s.done = make(chan struct{})
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[*conn]struct{})
+	})
+}
+
+// close must be called with s.mu held.
+func (s *Server) close() error {
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Server) shuttingDown() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) acquire() bool {
+	if s.sem == nil {
+		return !s.shuttingDown()
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *Server) release() {
+	if s.sem != nil {
+		<-s.sem
+	}
+}
+
+func (s *Server) trackListener(l net.Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.listeners, l)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) trackConn(c *conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !add {
+		delete(s.conns, c)
+		return true
+	}
+	if s.closed {
+		return false
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
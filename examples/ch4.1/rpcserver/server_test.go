@@ -0,0 +1,234 @@
+package rpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/rpc"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type SleepService struct{}
+
+func (p *SleepService) Sleep(d time.Duration, reply *string) error {
+	time.Sleep(d)
+	*reply = "done"
+	return nil
+}
+
+func newTestServer(t *testing.T, s *Server) (string, chan error) {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(SleepService)); err != nil {
+		t.Fatal(err)
+	}
+	s.Server = srv
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.Serve(l) }()
+	return l.Addr().String(), errc
+}
+
+func dial(t *testing.T, addr string) *rpc.Client {
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func sleep(client *rpc.Client, d time.Duration) error {
+	var reply string
+	if err := client.Call("SleepService.Sleep", d, &reply); err != nil {
+		return err
+	}
+	if reply != "done" {
+		return errors.New("unexpected reply: " + reply)
+	}
+	return nil
+}
+
+func TestServe(t *testing.T) {
+	s := new(Server)
+	addr, _ := newTestServer(t, s)
+	defer s.Close()
+
+	client := dial(t, addr)
+	defer client.Close()
+	if err := sleep(client, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxConns(t *testing.T) {
+	s := &Server{MaxConns: 1}
+	addr, _ := newTestServer(t, s)
+	defer s.Close()
+
+	first := dial(t, addr)
+	if err := sleep(first, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	second := dial(t, addr)
+	defer second.Close()
+	call := second.Go("SleepService.Sleep", time.Duration(0), new(string), nil)
+	select {
+	case <-call.Done:
+		t.Fatal("second connection served over the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			t.Fatal(call.Error)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second connection not served after the first one closed")
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	s := &Server{IdleTimeout: 100 * time.Millisecond}
+	addr, _ := newTestServer(t, s)
+	defer s.Close()
+
+	client := dial(t, addr)
+	defer client.Close()
+
+	// a call longer than the idle timeout keeps the connection open
+	if err := sleep(client, 300*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := sleep(client, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if err := sleep(client, 0); err != rpc.ErrShutdown && err != io.ErrUnexpectedEOF {
+		t.Fatalf("expect closed connection, got = %v", err)
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	s := &Server{ReadTimeout: 100 * time.Millisecond}
+	addr, _ := newTestServer(t, s)
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// the start of a request which never ends
+	if _, err := conn.Write([]byte{0x20}); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expect = %v, got = %v", io.EOF, err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := new(Server)
+	addr, errc := newTestServer(t, s)
+
+	client := dial(t, addr)
+	defer client.Close()
+	idle := dial(t, addr)
+	defer idle.Close()
+	if err := sleep(idle, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	call := client.Go("SleepService.Sleep", 200*time.Millisecond, new(string), nil)
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Fatalf("Shutdown returned before the call in flight: %v", d)
+	}
+
+	<-call.Done
+	if call.Error != nil {
+		t.Fatalf("call in flight failed: %v", call.Error)
+	}
+	if err := <-errc; err != ErrServerClosed {
+		t.Fatalf("expect = %v, got = %v", ErrServerClosed, err)
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatal("expect dial error after Shutdown")
+	}
+	if err := sleep(idle, 0); err == nil {
+		t.Fatal("expect idle connection closed")
+	}
+}
+
+func TestShutdownContext(t *testing.T) {
+	s := new(Server)
+	addr, _ := newTestServer(t, s)
+	defer s.Close()
+
+	client := dial(t, addr)
+	defer client.Close()
+	client.Go("SleepService.Sleep", time.Second, new(string), nil)
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect = %v, got = %v", context.DeadlineExceeded, err)
+	}
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+type flakyListener struct {
+	net.Listener
+	failures int32
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if atomic.AddInt32(&l.failures, -1) >= 0 {
+		return nil, temporaryError{}
+	}
+	return l.Listener.Accept()
+}
+
+func TestAcceptBackoff(t *testing.T) {
+	srv := rpc.NewServer()
+	srv.Register(new(SleepService))
+	s := &Server{Server: srv, ErrorLog: log.New(ioutil.Discard, "", 0)}
+	defer s.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(&flakyListener{Listener: l, failures: 3})
+
+	client := dial(t, l.Addr().String())
+	defer client.Close()
+	if err := sleep(client, 0); err != nil {
+		t.Fatal(err)
+	}
+}
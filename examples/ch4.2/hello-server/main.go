@@ -2,33 +2,24 @@ package main
 
 import (
 	"log"
-	"net"
 	"net/rpc"
 
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
 	pb "gobook.examples/ch4-02-proto/hello.pb"
 )
 
 type HelloService struct{}
 
-func (p *HelloService) Hello(request pb.String, reply *pb.String) error {
+func (p *HelloService) Hello(request *pb.String, reply *pb.String) error {
 	reply.Value = "hello:" + request.GetValue()
 	return nil
 }
 
 func main() {
-	rpc.Register(new(HelloService))
-
-	listener, err := net.Listen("tcp", ":1234")
-	if err != nil {
-		log.Fatal("ListenTCP error:", err)
+	srv := rpc.NewServer()
+	if err := pb.RegisterHelloService(srv, new(HelloService)); err != nil {
+		log.Fatal(err)
 	}
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Fatal("Accept error:", err)
-		}
-
-		go rpc.ServeConn(conn)
-	}
+	log.Fatal(rpcserver.NewServer(srv).ListenAndServe("tcp", ":1234"))
 }
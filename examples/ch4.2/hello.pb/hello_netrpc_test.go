@@ -0,0 +1,107 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hello
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type helloService struct{}
+
+func (p *helloService) Hello(in *String, out *String) error {
+	out.Value = "hello:" + in.GetValue()
+	return nil
+}
+
+type jsonResponse struct {
+	ID     interface{}     `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  interface{}     `json:"error"`
+}
+
+func postJSON(t *testing.T, url, body string) (int, jsonResponse) {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expect JSON, got = %q", ct)
+	}
+	var reply jsonResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("decode reply: %v", err)
+	}
+	return resp.StatusCode, reply
+}
+
+func TestJSONHandler(t *testing.T) {
+	h, err := NewHelloServiceJSONHandler(new(helloService))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(h)
+	defer ts.Close()
+
+	code, reply := postJSON(t, ts.URL,
+		`{"method":"hello.HelloService.Hello","params":[{"value":"gopher"}],"id":1}`)
+	if code != http.StatusOK || reply.Error != nil {
+		t.Fatalf("unexpected reply: %d %+v", code, reply)
+	}
+	if got := strings.Join(strings.Fields(string(reply.Result)), ""); got != `{"value":"hello:gopher"}` {
+		t.Fatalf("unexpected result: %s", reply.Result)
+	}
+
+	// errors after a valid request are JSON-RPC errors
+	code, reply = postJSON(t, ts.URL, `{"method":"hello.HelloService.Nope","params":[{}],"id":2}`)
+	if code != http.StatusOK || reply.Error == nil {
+		t.Fatalf("expect an error reply, got = %d %+v", code, reply)
+	}
+
+	for _, body := range []string{"", "not json", `{"method":`} {
+		code, reply = postJSON(t, ts.URL, body)
+		if code != http.StatusBadRequest || reply.Error == nil {
+			t.Fatalf("%q: expect 400 with an error, got = %d %+v", body, code, reply)
+		}
+	}
+}
+
+func TestDialJSON(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go ServeHelloServiceJSON(conn, new(helloService))
+		}
+	}()
+
+	client, err := DialHelloServiceJSON("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var out String
+	if err := client.Hello(&String{Value: "gopher"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.GetValue() != "hello:gopher" {
+		t.Fatalf("expect = hello:gopher, got = %q", out.GetValue())
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc_test
+
+import (
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+	"testing"
+
+	pb "gobook.examples/ch4-02-proto/hello.pb"
+	"gobook.examples/ch4-02-proto/netrpc"
+)
+
+type helloService struct{}
+
+func (p *helloService) Hello(request *pb.String, reply *pb.String) error {
+	reply.Value = "hello:" + request.GetValue()
+	return nil
+}
+
+type benchCodec struct {
+	serve     func(srv *rpc.Server, conn io.ReadWriteCloser)
+	newClient func(conn io.ReadWriteCloser) *rpc.Client
+}
+
+var (
+	benchGob = benchCodec{
+		serve:     (*rpc.Server).ServeConn,
+		newClient: rpc.NewClient,
+	}
+	benchJSONRPC = benchCodec{
+		serve: func(srv *rpc.Server, conn io.ReadWriteCloser) {
+			srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+		},
+		newClient: jsonrpc.NewClient,
+	}
+	benchProtoJSON = benchCodec{
+		serve: func(srv *rpc.Server, conn io.ReadWriteCloser) {
+			srv.ServeCodec(netrpc.NewJSONServerCodec(conn))
+		},
+		newClient: func(conn io.ReadWriteCloser) *rpc.Client {
+			return rpc.NewClientWithCodec(netrpc.NewJSONClientCodec(conn))
+		},
+	}
+	benchProto = benchCodec{
+		serve: func(srv *rpc.Server, conn io.ReadWriteCloser) {
+			srv.ServeCodec(netrpc.NewProtoServerCodec(conn))
+		},
+		newClient: func(conn io.ReadWriteCloser) *rpc.Client {
+			return rpc.NewClientWithCodec(netrpc.NewProtoClientCodec(conn))
+		},
+	}
+)
+
+// benchmarkHello calls HelloService.Hello with a value of size bytes over
+// a loopback TCP connection.
+func benchmarkHello(b *testing.B, codec benchCodec, size int) {
+	srv := rpc.NewServer()
+	if err := pb.RegisterHelloService(srv, new(helloService)); err != nil {
+		b.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go codec.serve(srv, conn)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	client := &pb.HelloServiceClient{Client: codec.newClient(conn)}
+	defer client.Close()
+
+	in := &pb.String{Value: strings.Repeat("x", size)}
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			var out pb.String
+			if err := client.Hello(in, &out); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkHelloGob(b *testing.B)         { benchmarkHello(b, benchGob, 16) }
+func BenchmarkHelloJSONRPC(b *testing.B)     { benchmarkHello(b, benchJSONRPC, 16) }
+func BenchmarkHelloProtoJSON(b *testing.B)   { benchmarkHello(b, benchProtoJSON, 16) }
+func BenchmarkHelloProto(b *testing.B)       { benchmarkHello(b, benchProto, 16) }
+func BenchmarkHelloGob4K(b *testing.B)       { benchmarkHello(b, benchGob, 4<<10) }
+func BenchmarkHelloJSONRPC4K(b *testing.B)   { benchmarkHello(b, benchJSONRPC, 4<<10) }
+func BenchmarkHelloProtoJSON4K(b *testing.B) { benchmarkHello(b, benchProtoJSON, 4<<10) }
+func BenchmarkHelloProto4K(b *testing.B)     { benchmarkHello(b, benchProto, 4<<10) }
+
+func TestDialHelloServiceProto(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		pb.ServeHelloServiceProto(conn, new(helloService))
+	}()
+
+	client, err := pb.DialHelloServiceProto("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply pb.String
+	if err := client.Hello(&pb.String{Value: "gopher"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Value != "hello:gopher" {
+		t.Fatalf("expect = %q, got = %q", "hello:gopher", reply.Value)
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+// SetDefaults calls the SetDefaults method of x, such as the ones
+// generated by protoc-gen-godefaults.
+func SetDefaults(x interface{}) {
+	if v, ok := x.(interface{ SetDefaults() }); ok {
+		v.SetDefaults()
+	}
+}
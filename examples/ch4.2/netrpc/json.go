@@ -0,0 +1,194 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package netrpc is the runtime support of the code generated by
+// protoc-gen-go-netrpc.
+package netrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"reflect"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewJSONClientCodec returns a net/rpc/jsonrpc client codec which encodes
+// proto messages as canonical protobuf JSON.
+func NewJSONClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return &jsonClientCodec{jsonrpc.NewClientCodec(conn)}
+}
+
+// NewJSONServerCodec returns a net/rpc/jsonrpc server codec which encodes
+// proto messages as canonical protobuf JSON.
+func NewJSONServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &jsonServerCodec{jsonrpc.NewServerCodec(conn)}
+}
+
+// ServeJSONHTTP serves the JSON-RPC request in the body of r with srv. A
+// body which is not a JSON-RPC request is answered with 400 Bad Request
+// and a JSON-RPC error.
+func ServeJSONHTTP(srv *rpc.Server, w http.ResponseWriter, r *http.Request) {
+	rw := &jsonResponseWriter{w: w}
+	conn := struct {
+		io.Writer
+		io.ReadCloser
+	}{
+		ReadCloser: r.Body,
+		Writer:     rw,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := srv.ServeRequest(NewJSONServerCodec(conn))
+	if err != nil && !rw.written {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     nil,
+			"result": nil,
+			"error":  "bad request: " + err.Error(),
+		})
+	}
+}
+
+// jsonResponseWriter records whether net/rpc wrote a response, as it does
+// for the errors after a valid request header.
+type jsonResponseWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (w *jsonResponseWriter) Write(p []byte) (int, error) {
+	w.written = true
+	return w.w.Write(p)
+}
+
+type jsonClientCodec struct{ rpc.ClientCodec }
+
+func (c *jsonClientCodec) WriteRequest(r *rpc.Request, x interface{}) error {
+	return c.ClientCodec.WriteRequest(r, jsonValue{x})
+}
+
+func (c *jsonClientCodec) ReadResponseBody(x interface{}) error {
+	if x == nil {
+		return c.ClientCodec.ReadResponseBody(nil)
+	}
+	return c.ClientCodec.ReadResponseBody(&jsonValue{x})
+}
+
+type jsonServerCodec struct{ rpc.ServerCodec }
+
+func (c *jsonServerCodec) ReadRequestBody(x interface{}) error {
+	if x == nil {
+		return c.ServerCodec.ReadRequestBody(nil)
+	}
+	return c.ServerCodec.ReadRequestBody(&jsonValue{x})
+}
+
+func (c *jsonServerCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	return c.ServerCodec.WriteResponse(r, jsonValue{x})
+}
+
+// jsonValue encodes proto messages with protojson and everything else with
+// encoding/json. Plain structs, such as the stream chunks, are walked field
+// by field so that the messages they carry use protojson too.
+type jsonValue struct{ v interface{} }
+
+func (x jsonValue) MarshalJSON() ([]byte, error) {
+	return marshalJSON(reflect.ValueOf(x.v))
+}
+
+func (x *jsonValue) UnmarshalJSON(data []byte) error {
+	return unmarshalJSON(data, reflect.ValueOf(x.v))
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+func marshalJSON(v reflect.Value) ([]byte, error) {
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return []byte("null"), nil
+	}
+	if v.Type().Implements(protoMessageType) {
+		return protojson.Marshal(v.Interface().(proto.Message))
+	}
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return json.Marshal(v.Interface())
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		data, err := marshalJSON(v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalJSON decodes data into the value pointed to by v.
+func unmarshalJSON(data []byte, v reflect.Value) error {
+	if isJSONNull(data) {
+		return nil
+	}
+	if v.Type().Implements(protoMessageType) {
+		return protojson.Unmarshal(data, v.Interface().(proto.Message))
+	}
+
+	elem := v.Elem()
+	if elem.Kind() != reflect.Struct {
+		return json.Unmarshal(data, v.Interface())
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for i := 0; i < elem.NumField(); i++ {
+		f := elem.Type().Field(i)
+		raw, ok := fields[f.Name]
+		if f.PkgPath != "" || !ok || isJSONNull(raw) {
+			continue
+		}
+
+		fv := elem.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			if err := unmarshalJSON(raw, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := unmarshalJSON(raw, fv.Addr()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
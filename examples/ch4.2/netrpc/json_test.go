@@ -0,0 +1,98 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/rpc"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type EchoService struct{}
+
+func (p *EchoService) Add(in *wrapperspb.Int64Value, out *wrapperspb.Int64Value) error {
+	out.Value = in.Value + 1
+	return nil
+}
+
+type Chunk struct {
+	Seq uint64                 `netrpc:"1"`
+	Msg *wrapperspb.Int64Value `netrpc:"2"`
+	EOF bool                   `netrpc:"3"`
+}
+
+func (p *EchoService) Next(in *Chunk, out *Chunk) error {
+	out.Seq = in.Seq + 1
+	out.EOF = in.Msg == nil
+	if in.Msg != nil {
+		out.Msg = &wrapperspb.Int64Value{Value: in.Msg.Value + 1}
+	}
+	return nil
+}
+
+func newEchoServer(t *testing.T) net.Conn {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	c, s := net.Pipe()
+	go srv.ServeCodec(NewJSONServerCodec(s))
+	return c
+}
+
+func TestJSONCodec(t *testing.T) {
+	client := rpc.NewClientWithCodec(NewJSONClientCodec(newEchoServer(t)))
+	defer client.Close()
+
+	var out wrapperspb.Int64Value
+	if err := client.Call("EchoService.Add", &wrapperspb.Int64Value{Value: 41}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != 42 {
+		t.Fatalf("expect = %d, got = %d", 42, out.Value)
+	}
+
+	var chunk Chunk
+	err := client.Call("EchoService.Next", &Chunk{Seq: 1, Msg: &wrapperspb.Int64Value{Value: 1}}, &chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Seq != 2 || chunk.Msg.GetValue() != 2 || chunk.EOF {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+
+	chunk = Chunk{}
+	if err := client.Call("EchoService.Next", &Chunk{Seq: 2}, &chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Msg != nil || !chunk.EOF {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+}
+
+// TestJSONCodecWire checks that int64 values use the canonical protobuf
+// JSON string form on the wire.
+func TestJSONCodecWire(t *testing.T) {
+	conn := newEchoServer(t)
+	defer conn.Close()
+
+	go conn.Write([]byte(`{"method":"EchoService.Add","params":["41"],"id":7}`))
+
+	var resp struct {
+		ID     int         `json:"id"`
+		Result string      `json:"result"`
+		Error  interface{} `json:"error"`
+	}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 7 || resp.Result != "42" || resp.Error != nil {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,434 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// The protobuf codec sends every request and response as two frames, a
+// header and a body. A frame is a varint length followed by that many
+// bytes of protobuf, the same delimited format as protodelim. The header
+// is the message
+//
+//	message Header {
+//		string service_method = 1;
+//		uint64 seq = 2;
+//		string error = 3; // responses only
+//	}
+//
+// and the body is the proto message of the call. Plain structs, such as
+// the stream chunks, are encoded as a message too. Each exported field
+// needs its field number in a tag, and must be a bool, an integer, a
+// string or a proto message pointer:
+//
+//	type Chunk struct {
+//		Seq uint64         `netrpc:"1"`
+//		Msg *hello.String  `netrpc:"2"`
+//	}
+//
+// A struct breaking these rules fails on its first call, whatever its
+// field values.
+
+// MaxProtoFrameSize is the largest frame accepted by the protobuf codec.
+const MaxProtoFrameSize = 64 << 20
+
+var errProtoFrameTooLarge = errors.New("netrpc: protobuf frame too large")
+
+// NewProtoClientCodec returns a client codec which frames the requests
+// and responses as length-prefixed protobuf.
+func NewProtoClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return &protoClientCodec{newProtoCodec(conn)}
+}
+
+// NewProtoServerCodec returns a server codec which frames the requests
+// and responses as length-prefixed protobuf.
+func NewProtoServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &protoServerCodec{newProtoCodec(conn)}
+}
+
+// DialProto connects to a server using the protobuf codec.
+func DialProto(network, address string) (*rpc.Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClientWithCodec(NewProtoClientCodec(conn)), nil
+}
+
+type protoCodec struct {
+	rwc io.ReadWriteCloser
+	r   *bufio.Reader
+	w   *bufio.Writer
+	buf []byte
+}
+
+func newProtoCodec(conn io.ReadWriteCloser) protoCodec {
+	return protoCodec{
+		rwc: conn,
+		r:   bufio.NewReader(conn),
+		w:   bufio.NewWriter(conn),
+	}
+}
+
+func (c *protoCodec) readFrame() ([]byte, error) {
+	n, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > MaxProtoFrameSize {
+		return nil, errProtoFrameTooLarge
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (c *protoCodec) readBody(x interface{}) error {
+	data, err := c.readFrame()
+	if err != nil || x == nil {
+		return err
+	}
+	return unmarshalProto(data, x)
+}
+
+func (c *protoCodec) write(method string, seq uint64, errMsg string, body []byte) error {
+	header := marshalProtoHeader(method, seq, errMsg)
+
+	c.buf = protowire.AppendVarint(c.buf[:0], uint64(len(header)))
+	c.buf = append(c.buf, header...)
+	c.buf = protowire.AppendVarint(c.buf, uint64(len(body)))
+	c.buf = append(c.buf, body...)
+	if _, err := c.w.Write(c.buf); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *protoCodec) Close() error {
+	return c.rwc.Close()
+}
+
+type protoClientCodec struct{ protoCodec }
+
+func (c *protoClientCodec) WriteRequest(r *rpc.Request, x interface{}) error {
+	body, err := marshalProto(x)
+	if err != nil {
+		return err
+	}
+	return c.write(r.ServiceMethod, r.Seq, "", body)
+}
+
+func (c *protoClientCodec) ReadResponseHeader(r *rpc.Response) error {
+	data, err := c.readFrame()
+	if err != nil {
+		return err
+	}
+	r.ServiceMethod, r.Seq, r.Error, err = unmarshalProtoHeader(data)
+	return err
+}
+
+func (c *protoClientCodec) ReadResponseBody(x interface{}) error {
+	return c.readBody(x)
+}
+
+type protoServerCodec struct{ protoCodec }
+
+func (c *protoServerCodec) ReadRequestHeader(r *rpc.Request) error {
+	data, err := c.readFrame()
+	if err != nil {
+		return err
+	}
+	r.ServiceMethod, r.Seq, _, err = unmarshalProtoHeader(data)
+	return err
+}
+
+func (c *protoServerCodec) ReadRequestBody(x interface{}) error {
+	return c.readBody(x)
+}
+
+// WriteResponse reports a reply which cannot be encoded as an error of
+// the call, instead of dropping the connection.
+func (c *protoServerCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	errMsg := r.Error
+	body, err := marshalProto(x)
+	if err != nil {
+		body = nil
+		if errMsg == "" {
+			errMsg = err.Error()
+		}
+	}
+	if errMsg != "" {
+		body = nil
+	}
+	return c.write(r.ServiceMethod, r.Seq, errMsg, body)
+}
+
+func marshalProtoHeader(method string, seq uint64, errMsg string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, method)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, seq)
+	if errMsg != "" {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, errMsg)
+	}
+	return b
+}
+
+func unmarshalProtoHeader(b []byte) (method string, seq uint64, errMsg string, err error) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return "", 0, "", protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			method, n = protowire.ConsumeString(b)
+		case num == 2 && typ == protowire.VarintType:
+			seq, n = protowire.ConsumeVarint(b)
+		case num == 3 && typ == protowire.BytesType:
+			errMsg, n = protowire.ConsumeString(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return "", 0, "", protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return method, seq, errMsg, nil
+}
+
+// protoField is an exported field of a plain struct.
+type protoField struct {
+	index int
+	num   protowire.Number
+	name  string
+	kind  reflect.Kind // reflect.Ptr for the proto messages
+}
+
+// protoStructs caches the []protoField of the plain structs, or the
+// error which makes them unusable, by reflect.Type.
+var protoStructs sync.Map
+
+type protoStruct struct {
+	fields []protoField
+	err    error
+}
+
+// protoFields returns the fields of the struct type t, checking all of
+// them, so that a wrong field is found even when it is zero.
+func protoFields(t reflect.Type) ([]protoField, error) {
+	if x, ok := protoStructs.Load(t); ok {
+		p := x.(*protoStruct)
+		return p.fields, p.err
+	}
+	fields, err := parseProtoFields(t)
+	protoStructs.Store(t, &protoStruct{fields, err})
+	return fields, err
+}
+
+func parseProtoFields(t reflect.Type) ([]protoField, error) {
+	var fields []protoField
+	seen := make(map[protowire.Number]string)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tag, ok := f.Tag.Lookup("netrpc")
+		if !ok {
+			return nil, fmt.Errorf("netrpc: field %s of %v has no netrpc tag", f.Name, t)
+		}
+		n, err := strconv.ParseUint(tag, 10, 32)
+		num := protowire.Number(n)
+		if err != nil || !num.IsValid() {
+			return nil, fmt.Errorf("netrpc: field %s of %v: invalid field number %q", f.Name, t, tag)
+		}
+		if other, ok := seen[num]; ok {
+			return nil, fmt.Errorf("netrpc: fields %s and %s of %v have the number %d", other, f.Name, t, num)
+		}
+		seen[num] = f.Name
+
+		kind := f.Type.Kind()
+		switch kind {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		case reflect.Ptr:
+			if !f.Type.Implements(protoMessageType) {
+				return nil, fmt.Errorf("netrpc: field %s of %v is not a proto message", f.Name, t)
+			}
+		default:
+			return nil, fmt.Errorf("netrpc: field %s of %v: %v is not supported", f.Name, t, f.Type)
+		}
+		fields = append(fields, protoField{index: i, num: num, name: f.Name, kind: kind})
+	}
+	return fields, nil
+}
+
+// marshalProto encodes a proto message, or a plain struct of scalars and
+// proto messages.
+func marshalProto(x interface{}) ([]byte, error) {
+	if m, ok := x.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+
+	v := reflect.ValueOf(x)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("netrpc: cannot encode %T as protobuf", x)
+	}
+	fields, err := protoFields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		if fv.IsZero() {
+			continue
+		}
+
+		switch f.kind {
+		case reflect.Bool:
+			b = protowire.AppendTag(b, f.num, protowire.VarintType)
+			b = protowire.AppendVarint(b, protowire.EncodeBool(fv.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b = protowire.AppendTag(b, f.num, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(fv.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			b = protowire.AppendTag(b, f.num, protowire.VarintType)
+			b = protowire.AppendVarint(b, fv.Uint())
+		case reflect.String:
+			b = protowire.AppendTag(b, f.num, protowire.BytesType)
+			b = protowire.AppendString(b, fv.String())
+		default:
+			data, err := proto.Marshal(fv.Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			b = protowire.AppendTag(b, f.num, protowire.BytesType)
+			b = protowire.AppendBytes(b, data)
+		}
+	}
+	return b, nil
+}
+
+// unmarshalProto decodes data into the proto message or plain struct
+// pointed to by x.
+func unmarshalProto(data []byte, x interface{}) error {
+	if m, ok := x.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+
+	v := reflect.ValueOf(x)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("netrpc: cannot decode protobuf into %T", x)
+	}
+	v = v.Elem()
+	fields, err := protoFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		f := findProtoField(fields, num)
+		if f == nil {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+
+		fv := v.Field(f.index)
+		switch typ {
+		case protowire.VarintType:
+			var u uint64
+			u, n = protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			switch f.kind {
+			case reflect.Bool:
+				fv.SetBool(protowire.DecodeBool(u))
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				fv.SetInt(int64(u))
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				fv.SetUint(u)
+			default:
+				return fmt.Errorf("netrpc: wrong wire type for field %s of %T", f.name, x)
+			}
+		case protowire.BytesType:
+			var b []byte
+			b, n = protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			switch f.kind {
+			case reflect.String:
+				fv.SetString(string(b))
+			case reflect.Ptr:
+				if fv.IsNil() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				if err := proto.Unmarshal(b, fv.Interface().(proto.Message)); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("netrpc: wrong wire type for field %s of %T", f.name, x)
+			}
+		default:
+			return fmt.Errorf("netrpc: wrong wire type for field %s of %T", f.name, x)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func findProtoField(fields []protoField, num protowire.Number) *protoField {
+	for i := range fields {
+		if fields[i].num == num {
+			return &fields[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func (p *EchoService) Fail(in *wrapperspb.Int64Value, out *wrapperspb.Int64Value) error {
+	return errors.New("fail")
+}
+
+func newProtoEchoClient(t *testing.T) *rpc.Client {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	c, s := net.Pipe()
+	go srv.ServeCodec(NewProtoServerCodec(s))
+	return rpc.NewClientWithCodec(NewProtoClientCodec(c))
+}
+
+func TestProtoCodec(t *testing.T) {
+	client := newProtoEchoClient(t)
+	defer client.Close()
+
+	var out wrapperspb.Int64Value
+	if err := client.Call("EchoService.Add", &wrapperspb.Int64Value{Value: 41}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != 42 {
+		t.Fatalf("expect = %d, got = %d", 42, out.Value)
+	}
+
+	var chunk Chunk
+	err := client.Call("EchoService.Next", &Chunk{Seq: 1, Msg: &wrapperspb.Int64Value{Value: 1}}, &chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Seq != 2 || chunk.Msg.GetValue() != 2 || chunk.EOF {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+
+	chunk = Chunk{}
+	if err := client.Call("EchoService.Next", &Chunk{Seq: 2}, &chunk); err != nil {
+		t.Fatal(err)
+	}
+	if chunk.Msg != nil || !chunk.EOF {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+
+	err = client.Call("EchoService.Fail", &wrapperspb.Int64Value{}, &out)
+	if err == nil || err.Error() != "fail" {
+		t.Fatalf("expect = fail, got = %v", err)
+	}
+	err = client.Call("EchoService.Missing", &wrapperspb.Int64Value{}, &out)
+	if err == nil {
+		t.Fatal("expect error for unknown method")
+	}
+
+	// the connection is still usable after errors
+	if err := client.Call("EchoService.Add", &wrapperspb.Int64Value{Value: 1}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != 2 {
+		t.Fatalf("expect = %d, got = %d", 2, out.Value)
+	}
+
+	if err := client.Call("EchoService.Add", make(chan int), &out); err == nil {
+		t.Fatal("expect error for a body which is not protobuf")
+	}
+}
+
+// TestProtoCodecWire checks the frames written by the server codec.
+func TestProtoCodecWire(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	c, s := net.Pipe()
+	defer c.Close()
+	go srv.ServeCodec(NewProtoServerCodec(s))
+
+	body, _ := proto.Marshal(&wrapperspb.Int64Value{Value: 41})
+	header := marshalProtoHeader("EchoService.Add", 7, "")
+
+	var req []byte
+	req = protowire.AppendBytes(req, header)
+	req = protowire.AppendBytes(req, body)
+	go c.Write(req)
+
+	codec := newProtoCodec(c)
+	codec.r = bufio.NewReader(c)
+
+	data, err := codec.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, seq, errMsg, err := unmarshalProtoHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != "EchoService.Add" || seq != 7 || errMsg != "" {
+		t.Fatalf("unexpected header: %q %d %q", method, seq, errMsg)
+	}
+
+	var out wrapperspb.Int64Value
+	if err := codec.readBody(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Value != 42 {
+		t.Fatalf("expect = %d, got = %d", 42, out.Value)
+	}
+}
+
+func TestProtoStructFields(t *testing.T) {
+	type renumbered struct {
+		EOF bool                   `netrpc:"3"`
+		Msg *wrapperspb.Int64Value `netrpc:"2"`
+		Seq uint64                 `netrpc:"1"`
+		// fields added later keep the numbers of the others
+		Note string `netrpc:"4"`
+	}
+	data, err := marshalProto(&Chunk{Seq: 1, Msg: &wrapperspb.Int64Value{Value: 2}, EOF: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r renumbered
+	if err := unmarshalProto(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq != 1 || r.Msg.GetValue() != 2 || !r.EOF {
+		t.Fatalf("unexpected struct: %+v", r)
+	}
+
+	// the wrong fields fail even when they are zero
+	for _, x := range []interface{}{
+		&struct{ A uint64 }{},
+		&struct {
+			A uint64 `netrpc:"0"`
+		}{},
+		&struct {
+			A uint64 `netrpc:"1"`
+			B string `netrpc:"1"`
+		}{},
+		&struct {
+			A float64 `netrpc:"1"`
+		}{},
+		&struct {
+			A []string `netrpc:"1"`
+		}{},
+		&struct {
+			A map[string]string `netrpc:"1"`
+		}{},
+	} {
+		if _, err := marshalProto(x); err == nil {
+			t.Fatalf("%T: expect marshal error", x)
+		}
+		if err := unmarshalProto(nil, x); err == nil {
+			t.Fatalf("%T: expect unmarshal error", x)
+		}
+	}
+}
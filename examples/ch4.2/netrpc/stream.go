@@ -0,0 +1,14 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+import "time"
+
+// StreamIdleTimeout is how long the server keeps a stream of a generated
+// service without a call from its client. The stream is then closed and
+// the Send or Recv of its handler fails, so that a client going away in
+// the middle of a stream doesn't leak it. The calls of the client wait for
+// the handler at most half of it, then the client calls again.
+var StreamIdleTimeout = time.Minute
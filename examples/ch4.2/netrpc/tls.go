@@ -0,0 +1,168 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNoPeerCertificate is returned by PeerIdentity if the connection is
+// not TLS or the client sent no certificate.
+var ErrNoPeerCertificate = errors.New("netrpc: no peer certificate")
+
+// HandshakeTimeout limits the TLS handshake of PeerIdentity, so that a
+// client which connects and sends nothing does not hold the connection.
+var HandshakeTimeout = 10 * time.Second
+
+// NewServerTLSConfig returns the TLS config of a server with the key pair
+// certFile and keyFile. If clientCAFile is not empty, the clients must
+// present a certificate signed by one of its CAs (mutual TLS).
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		if config.ClientCAs, err = loadCertPool(clientCAFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// NewClientTLSConfig returns the TLS config of a client verifying that the
+// server is serverName with the CAs of caFile, or of the system if caFile
+// is empty. The key pair certFile and keyFile is sent to servers which
+// want a client certificate, if they are not empty.
+func NewClientTLSConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	var err error
+	if caFile != "" {
+		if config.RootCAs, err = loadCertPool(caFile); err != nil {
+			return nil, err
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("netrpc: no certificate in %s", filename)
+	}
+	return pool, nil
+}
+
+// ListenTLS returns a listener whose connections are TLS servers, to be
+// served with rpc.ServeConn like plain ones.
+func ListenTLS(network, address string, config *tls.Config) (net.Listener, error) {
+	return tls.Listen(network, address, config)
+}
+
+// DialTLS connects to a net/rpc server listening with ListenTLS.
+func DialTLS(network, address string, config *tls.Config) (*rpc.Client, error) {
+	conn, err := tls.Dial(network, address, config)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClient(conn), nil
+}
+
+// Identity is the subject of the verified certificate of a TLS peer.
+type Identity struct {
+	CommonName     string
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+	URIs           []string
+}
+
+func (id *Identity) String() string {
+	names := []string{"CN=" + id.CommonName}
+	for _, s := range id.DNSNames {
+		names = append(names, "DNS:"+s)
+	}
+	for _, s := range id.EmailAddresses {
+		names = append(names, "email:"+s)
+	}
+	for _, ip := range id.IPAddresses {
+		names = append(names, "IP:"+ip.String())
+	}
+	for _, s := range id.URIs {
+		names = append(names, "URI:"+s)
+	}
+	return strings.Join(names, ",")
+}
+
+// PeerIdentity completes the TLS handshake of conn, within
+// HandshakeTimeout, and returns the identity of the certificate the peer
+// sent. It is called before serving a connection, to give the identity
+// to the per-connection services:
+//
+//	id, err := netrpc.PeerIdentity(conn)
+//	if err != nil {
+//		conn.Close()
+//		return
+//	}
+//	p := rpc.NewServer()
+//	p.Register(&HelloService{conn: conn, identity: id})
+//	p.ServeConn(conn)
+func PeerIdentity(conn net.Conn) (*Identity, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, ErrNoPeerCertificate
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HandshakeTimeout)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, ErrNoPeerCertificate
+	}
+	cert := certs[0]
+
+	id := &Identity{
+		CommonName:     cert.Subject.CommonName,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		IPAddresses:    cert.IPAddresses,
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id, nil
+}
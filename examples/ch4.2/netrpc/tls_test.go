@@ -0,0 +1,304 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/rpc"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "gobook.examples/ch4-02-proto/hello.pb"
+	"gobook.examples/ch4-02-proto/netrpc"
+)
+
+// testCA is a throwaway CA issuing the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, tmpl *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// HelloService is served per connection and knows its client, the way
+// rpc-context knows conn.RemoteAddr().
+type HelloService struct {
+	identity *netrpc.Identity
+}
+
+func (p *HelloService) Hello(in *pb.String, out *pb.String) error {
+	out.Value = "hello:" + in.GetValue() + ", " + p.identity.CommonName
+	return nil
+}
+
+// serveTLS serves HelloService on a ListenTLS listener of config and
+// returns its address.
+func serveTLS(t *testing.T, config *tls.Config) string {
+	l, err := netrpc.ListenTLS("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				id, err := netrpc.PeerIdentity(conn)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				srv := rpc.NewServer()
+				pb.RegisterHelloService(srv, &HelloService{identity: id})
+				srv.ServeConn(conn)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+	otherCA := newTestCA(t, "other ca")
+
+	serverCert := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server.io"},
+		DNSNames:    []string{"server.io"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	})
+	spiffe, _ := url.Parse("spiffe://gobook/client")
+	clientCert := ca.issue(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "client.io"},
+		DNSNames:       []string{"client.io"},
+		EmailAddresses: []string{"client@gobook.io"},
+		URIs:           []*url.URL{spiffe},
+	})
+	strangerCert := otherCA.issue(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "stranger.io"},
+	})
+
+	addr := serveTLS(t, &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	})
+
+	client, err := pb.DialHelloServiceTLS("tcp", addr, &tls.Config{
+		ServerName:   "server.io",
+		RootCAs:      ca.pool,
+		Certificates: []tls.Certificate{clientCert},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply pb.String
+	if err := client.Hello(&pb.String{Value: "gopher"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Value != "hello:gopher, client.io" {
+		t.Fatalf("unexpected reply: %q", reply.Value)
+	}
+
+	for name, config := range map[string]*tls.Config{
+		"no client cert":    {ServerName: "server.io", RootCAs: ca.pool},
+		"unknown client CA": {ServerName: "server.io", RootCAs: ca.pool, Certificates: []tls.Certificate{strangerCert}},
+		"unknown server CA": {ServerName: "server.io", RootCAs: otherCA.pool, Certificates: []tls.Certificate{clientCert}},
+		"wrong server name": {ServerName: "other.io", RootCAs: ca.pool, Certificates: []tls.Certificate{clientCert}},
+	} {
+		client, err := pb.DialHelloServiceTLS("tcp", addr, config)
+		if err == nil {
+			// TLS 1.3 clients only see the rejection of their
+			// certificate when they read
+			err = client.Hello(&pb.String{Value: "gopher"}, &reply)
+			client.Close()
+		}
+		if err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
+
+func TestPeerIdentity(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+	spiffe, _ := url.Parse("spiffe://gobook/client")
+	cert := ca.issue(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "client.io"},
+		DNSNames:       []string{"client.io"},
+		EmailAddresses: []string{"client@gobook.io"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{spiffe},
+	})
+	serverCert := ca.issue(t, &x509.Certificate{DNSNames: []string{"server.io"}})
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	go tls.Client(c1, &tls.Config{
+		ServerName:   "server.io",
+		RootCAs:      ca.pool,
+		Certificates: []tls.Certificate{cert},
+	}).Handshake()
+
+	id, err := netrpc.PeerIdentity(tls.Server(c2, &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "CN=client.io,DNS:client.io,email:client@gobook.io,IP:10.0.0.1,URI:spiffe://gobook/client"
+	if id.String() != expect {
+		t.Fatalf("expect = %q, got = %q", expect, id.String())
+	}
+
+	if _, err := netrpc.PeerIdentity(c1); err != netrpc.ErrNoPeerCertificate {
+		t.Fatalf("expect = %v, got = %v", netrpc.ErrNoPeerCertificate, err)
+	}
+}
+
+func TestPeerIdentityTimeout(t *testing.T) {
+	defer func(d time.Duration) { netrpc.HandshakeTimeout = d }(netrpc.HandshakeTimeout)
+	netrpc.HandshakeTimeout = 50 * time.Millisecond
+
+	ca := newTestCA(t, "test ca")
+	serverCert := ca.issue(t, &x509.Certificate{DNSNames: []string{"server.io"}})
+
+	// the client connects and sends nothing
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := netrpc.PeerIdentity(tls.Server(c2, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+		}))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expect error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("PeerIdentity still waiting for the handshake")
+	}
+}
+
+func TestTLSConfigFiles(t *testing.T) {
+	ca := newTestCA(t, "test ca")
+	cert := ca.issue(t, &x509.Certificate{DNSNames: []string{"server.io"}})
+
+	dir := t.TempDir()
+	writePEM := func(name, typ string, der []byte) string {
+		filename := filepath.Join(dir, name)
+		data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+		if err := os.WriteFile(filename, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := writePEM("ca.crt", "CERTIFICATE", ca.cert.Raw)
+	certFile := writePEM("cert.crt", "CERTIFICATE", cert.Certificate[0])
+	keyFile := writePEM("cert.key", "PRIVATE KEY", keyDER)
+
+	serverConfig, err := netrpc.NewServerTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverConfig.ClientAuth != tls.RequireAndVerifyClientCert || serverConfig.ClientCAs == nil {
+		t.Fatal("expect mutual TLS server config")
+	}
+	clientConfig, err := netrpc.NewClientTLSConfig(caFile, certFile, keyFile, "server.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := serveTLS(t, serverConfig)
+	client, err := pb.DialHelloServiceTLS("tcp", addr, clientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply pb.String
+	if err := client.Hello(&pb.String{Value: "gopher"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := netrpc.NewServerTLSConfig(certFile, keyFile, keyFile); err == nil {
+		t.Fatal("expect error of CA file without certificate")
+	}
+}
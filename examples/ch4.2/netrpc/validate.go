@@ -0,0 +1,14 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netrpc
+
+// Validate calls the Validate method of x, such as the ones generated by
+// protoc-gen-govalidators. Values without one are valid.
+func Validate(x interface{}) error {
+	if v, ok := x.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
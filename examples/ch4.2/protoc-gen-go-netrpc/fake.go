@@ -0,0 +1,202 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func (p *netrpcPlugin) genFakeImportCode(g *protogen.GeneratedFile, specs []*ServiceSpec) {
+	g.QualifiedGoIdent(netPackage.Ident("Pipe"))
+	g.QualifiedGoIdent(rpcPackage.Ident("Server"))
+	g.QualifiedGoIdent(syncPackage.Ident("Mutex"))
+
+	for _, spec := range specs {
+		for _, m := range spec.MethodList {
+			if !m.ServerStreaming {
+				g.QualifiedGoIdent(protoPackage.Ident("Merge"))
+			}
+			if m.ClientStreaming {
+				g.QualifiedGoIdent(ioPackage.Ident("EOF"))
+			}
+		}
+	}
+}
+
+const tmplFake = `
+{{$root := .}}
+
+// New{{.ServiceName}}Pipe serves x on a new rpc.Server and returns a client
+// connected to it through net.Pipe, so tests go through the real net/rpc
+// path without opening ports. Closing the client stops the server.
+func New{{.ServiceName}}Pipe(x {{.ServiceName}}Interface) (*rpc.Server, *{{.ServiceName}}Client, error) {
+	srv := rpc.NewServer()
+	if err := Register{{.ServiceName}}(srv, x); err != nil {
+		return nil, nil, err
+	}
+
+	c, s := net.Pipe()
+	go srv.ServeConn(s)
+	return srv, &{{.ServiceName}}Client{Client: rpc.NewClient(c)}, nil
+}
+
+// Fake{{.ServiceName}} is an in-memory {{.ServiceName}}Interface for tests.
+//
+// Every call is recorded. The replies are taken from the queue filled by
+// the Return methods, then from the Func hooks; a method with neither
+// returns an empty reply. The hooks must be set before the fake is used.
+type Fake{{.ServiceName}} struct {
+	{{- range $_, $m := .MethodList}}
+	{{- if $m.ServerStreaming}}
+	{{$m.MethodName}}Func func(in *{{$m.InputTypeName}}, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream) error
+	{{- else if $m.ClientStreaming}}
+	{{$m.MethodName}}Func func(stream {{$root.ServiceName}}_{{$m.MethodName}}Stream, out *{{$m.OutputTypeName}}) error
+	{{- else}}
+	{{$m.MethodName}}Func func(in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error
+	{{- end}}
+	{{- end}}
+
+	mu sync.Mutex
+	{{- range $_, $m := .MethodList}}
+	{{- if $m.ClientStreaming}}
+	calls{{$m.MethodName}}   [][]*{{$m.InputTypeName}}
+	{{- else}}
+	calls{{$m.MethodName}}   []*{{$m.InputTypeName}}
+	{{- end}}
+	replies{{$m.MethodName}} []_Fake{{$root.ServiceName}}_{{$m.MethodName}}_Reply
+	{{- end}}
+}
+
+var _ {{.ServiceName}}Interface = (*Fake{{.ServiceName}})(nil)
+
+{{range $_, $m := .MethodList}}
+type _Fake{{$root.ServiceName}}_{{$m.MethodName}}_Reply struct {
+	{{- if $m.ServerStreaming}}
+	out []*{{$m.OutputTypeName}}
+	{{- else}}
+	out *{{$m.OutputTypeName}}
+	{{- end}}
+	err error
+}
+
+{{if $m.ServerStreaming -}}
+// Return{{$m.MethodName}} queues the reply of the next {{$m.MethodName}} call:
+// the messages of out are sent in order, then err is returned.
+func (f *Fake{{$root.ServiceName}}) Return{{$m.MethodName}}(out []*{{$m.OutputTypeName}}, err error) {
+{{- else -}}
+// Return{{$m.MethodName}} queues the reply of the next {{$m.MethodName}} call.
+func (f *Fake{{$root.ServiceName}}) Return{{$m.MethodName}}(out *{{$m.OutputTypeName}}, err error) {
+{{- end}}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies{{$m.MethodName}} = append(f.replies{{$m.MethodName}}, _Fake{{$root.ServiceName}}_{{$m.MethodName}}_Reply{out: out, err: err})
+}
+
+{{if $m.ClientStreaming -}}
+// {{$m.MethodName}}Calls returns the messages received by each {{$m.MethodName}} call.
+func (f *Fake{{$root.ServiceName}}) {{$m.MethodName}}Calls() [][]*{{$m.InputTypeName}} {
+{{- else -}}
+// {{$m.MethodName}}Calls returns the requests of the {{$m.MethodName}} calls so far.
+func (f *Fake{{$root.ServiceName}}) {{$m.MethodName}}Calls() []*{{$m.InputTypeName}} {
+{{- end}}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append({{if $m.ClientStreaming}}[][]{{else}}[]{{end}}*{{$m.InputTypeName}}(nil), f.calls{{$m.MethodName}}...)
+}
+
+func (f *Fake{{$root.ServiceName}}) next{{$m.MethodName}}Reply() (*_Fake{{$root.ServiceName}}_{{$m.MethodName}}_Reply, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.replies{{$m.MethodName}}) == 0 {
+		return nil, false
+	}
+	r := f.replies{{$m.MethodName}}[0]
+	f.replies{{$m.MethodName}} = f.replies{{$m.MethodName}}[1:]
+	return &r, true
+}
+
+{{if $m.ServerStreaming -}}
+func (f *Fake{{$root.ServiceName}}) {{$m.MethodName}}(in *{{$m.InputTypeName}}, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream) error {
+	f.mu.Lock()
+	f.calls{{$m.MethodName}} = append(f.calls{{$m.MethodName}}, in)
+	f.mu.Unlock()
+
+	if r, ok := f.next{{$m.MethodName}}Reply(); ok {
+		for _, m := range r.out {
+			if err := stream.Send(m); err != nil {
+				return err
+			}
+		}
+		return r.err
+	}
+	if f.{{$m.MethodName}}Func != nil {
+		return f.{{$m.MethodName}}Func(in, stream)
+	}
+	return nil
+}
+{{- else if $m.ClientStreaming -}}
+// {{$m.MethodName}} records the whole stream before the reply is chosen;
+// {{$m.MethodName}}Func gets the recorded messages replayed.
+func (f *Fake{{$root.ServiceName}}) {{$m.MethodName}}(stream {{$root.ServiceName}}_{{$m.MethodName}}Stream, out *{{$m.OutputTypeName}}) error {
+	var in []*{{$m.InputTypeName}}
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		in = append(in, m)
+	}
+
+	f.mu.Lock()
+	f.calls{{$m.MethodName}} = append(f.calls{{$m.MethodName}}, in)
+	f.mu.Unlock()
+
+	if r, ok := f.next{{$m.MethodName}}Reply(); ok {
+		if r.out != nil {
+			proto.Merge(out, r.out)
+		}
+		return r.err
+	}
+	if f.{{$m.MethodName}}Func != nil {
+		return f.{{$m.MethodName}}Func(&_Fake{{$root.ServiceName}}_{{$m.MethodName}}_Replay{in: in}, out)
+	}
+	return nil
+}
+
+type _Fake{{$root.ServiceName}}_{{$m.MethodName}}_Replay struct {
+	in []*{{$m.InputTypeName}}
+}
+
+func (s *_Fake{{$root.ServiceName}}_{{$m.MethodName}}_Replay) Recv() (*{{$m.InputTypeName}}, error) {
+	if len(s.in) == 0 {
+		return nil, io.EOF
+	}
+	m := s.in[0]
+	s.in = s.in[1:]
+	return m, nil
+}
+{{- else -}}
+func (f *Fake{{$root.ServiceName}}) {{$m.MethodName}}(in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error {
+	f.mu.Lock()
+	f.calls{{$m.MethodName}} = append(f.calls{{$m.MethodName}}, in)
+	f.mu.Unlock()
+
+	if r, ok := f.next{{$m.MethodName}}Reply(); ok {
+		if r.out != nil {
+			proto.Merge(out, r.out)
+		}
+		return r.err
+	}
+	if f.{{$m.MethodName}}Func != nil {
+		return f.{{$m.MethodName}}Func(in, out)
+	}
+	return nil
+}
+{{- end}}
+{{end}}
+`
@@ -6,94 +6,252 @@ package main
 
 import (
 	"bytes"
-	"log"
+	"fmt"
 	"text/template"
 
-	"github.com/golang/protobuf/protoc-gen-go/descriptor"
-	"github.com/golang/protobuf/protoc-gen-go/generator"
+	"google.golang.org/protobuf/compiler/protogen"
 )
 
-func init() {
-	generator.RegisterPlugin(new(netrpcPlugin))
-}
+const (
+	contextPackage = protogen.GoImportPath("context")
+	tlsPackage     = protogen.GoImportPath("crypto/tls")
+	errorsPackage  = protogen.GoImportPath("errors")
+	ioPackage      = protogen.GoImportPath("io")
+	netPackage     = protogen.GoImportPath("net")
+	httpPackage    = protogen.GoImportPath("net/http")
+	rpcPackage     = protogen.GoImportPath("net/rpc")
+	syncPackage    = protogen.GoImportPath("sync")
+	timePackage    = protogen.GoImportPath("time")
+	protoPackage   = protogen.GoImportPath("google.golang.org/protobuf/proto")
+
+	// netrpcPackage is the runtime support of the generated code.
+	netrpcPackage = protogen.GoImportPath("gobook.examples/ch4-02-proto/netrpc")
+)
 
-type netrpcPlugin struct{ *generator.Generator }
+type netrpcPlugin struct{ *protogen.Plugin }
 
-func (p *netrpcPlugin) Name() string                { return "netrpc" }
-func (p *netrpcPlugin) Init(g *generator.Generator) { p.Generator = g }
+func (p *netrpcPlugin) Generate(file *protogen.File) error {
+	if len(file.Services) == 0 {
+		return nil
+	}
 
-func (p *netrpcPlugin) GenerateImports(file *generator.FileDescriptor) {
-	if len(file.Service) > 0 {
-		p.genImportCode(file)
+	err := p.genFile(file, "_netrpc.pb.go", tmplService, p.genImportCode)
+	if err != nil {
+		return err
 	}
+	return p.genFile(file, "_netrpc_fake.pb.go", tmplFake, p.genFakeImportCode)
 }
 
-func (p *netrpcPlugin) Generate(file *generator.FileDescriptor) {
-	for _, svc := range file.Service {
-		p.genServiceCode(svc)
+func (p *netrpcPlugin) genFile(
+	file *protogen.File, suffix, tmpl string,
+	genImportCode func(g *protogen.GeneratedFile, specs []*ServiceSpec),
+) error {
+	g := p.NewGeneratedFile(file.GeneratedFilenamePrefix+suffix, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-netrpc. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	var specs []*ServiceSpec
+	for _, svc := range file.Services {
+		spec, err := p.buildServiceSpec(g, svc)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, spec)
+	}
+
+	genImportCode(g, specs)
+	for _, spec := range specs {
+		if err := p.genServiceCode(g, tmpl, spec); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 type ServiceSpec struct {
-	ServiceName string
-	MethodList  []ServiceMethodSpec
+	ServiceName  string
+	RegisterName string
+	MethodList   []ServiceMethodSpec
 }
 
 type ServiceMethodSpec struct {
 	MethodName     string
 	InputTypeName  string
 	OutputTypeName string
+
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// StreamTypeName returns the message type carried by the stream.
+func (m ServiceMethodSpec) StreamTypeName() string {
+	if m.ServerStreaming {
+		return m.OutputTypeName
+	}
+	return m.InputTypeName
+}
+
+func (p *ServiceSpec) HasUnaryMethod() bool {
+	for _, m := range p.MethodList {
+		if !m.ClientStreaming && !m.ServerStreaming {
+			return true
+		}
+	}
+	return false
 }
 
-func (p *netrpcPlugin) genImportCode(file *generator.FileDescriptor) {
-	p.P(`import "net/rpc"`)
+func (p *ServiceSpec) HasStreamMethod() bool {
+	for _, m := range p.MethodList {
+		if m.ClientStreaming || m.ServerStreaming {
+			return true
+		}
+	}
+	return false
 }
 
-func (p *netrpcPlugin) genServiceCode(svc *descriptor.ServiceDescriptorProto) {
-	spec := p.buildServiceSpec(svc)
+// genImportCode makes the packages used by tmplService importable under
+// their usual names; protogen only emits the ones that are referenced.
+func (p *netrpcPlugin) genImportCode(g *protogen.GeneratedFile, specs []*ServiceSpec) {
+	g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	g.QualifiedGoIdent(tlsPackage.Ident("Config"))
+	g.QualifiedGoIdent(ioPackage.Ident("ReadWriteCloser"))
+	g.QualifiedGoIdent(netPackage.Ident("Dial"))
+	g.QualifiedGoIdent(httpPackage.Ident("Handler"))
+	g.QualifiedGoIdent(rpcPackage.Ident("Client"))
+	g.QualifiedGoIdent(netrpcPackage.Ident("NewJSONClientCodec"))
 
+	for _, spec := range specs {
+		for _, m := range spec.MethodList {
+			if m.ClientStreaming || m.ServerStreaming {
+				g.QualifiedGoIdent(errorsPackage.Ident("New"))
+				g.QualifiedGoIdent(syncPackage.Ident("Mutex"))
+				g.QualifiedGoIdent(timePackage.Ident("Timer"))
+			}
+			if m.ClientStreaming {
+				g.QualifiedGoIdent(protoPackage.Ident("Merge"))
+			}
+		}
+	}
+}
+
+func (p *netrpcPlugin) genServiceCode(g *protogen.GeneratedFile, tmpl string, spec *ServiceSpec) error {
 	var buf bytes.Buffer
-	t := template.Must(template.New("").Parse(tmplService))
-	err := t.Execute(&buf, spec)
-	if err != nil {
-		log.Fatal(err)
+	t := template.Must(template.New("").Parse(tmpl))
+	if err := t.Execute(&buf, spec); err != nil {
+		return err
 	}
 
-	p.P(buf.String())
+	g.P(buf.String())
+	return nil
 }
 
-func (p *netrpcPlugin) buildServiceSpec(svc *descriptor.ServiceDescriptorProto) *ServiceSpec {
+func (p *netrpcPlugin) buildServiceSpec(
+	g *protogen.GeneratedFile, svc *protogen.Service,
+) (*ServiceSpec, error) {
 	spec := &ServiceSpec{
-		ServiceName: generator.CamelCase(svc.GetName()),
+		ServiceName:  svc.GoName,
+		RegisterName: string(svc.Desc.FullName()),
 	}
+	if *flagShortNames {
+		spec.RegisterName = string(svc.Desc.Name())
+	}
+
+	for _, m := range svc.Methods {
+		if m.Desc.IsStreamingClient() && m.Desc.IsStreamingServer() {
+			return nil, fmt.Errorf(
+				"netrpc: %s: bidirectional streaming is not supported",
+				m.Desc.FullName(),
+			)
+		}
 
-	for _, m := range svc.Method {
 		spec.MethodList = append(spec.MethodList, ServiceMethodSpec{
-			MethodName:     generator.CamelCase(m.GetName()),
-			InputTypeName:  p.TypeName(p.ObjectNamed(m.GetInputType())),
-			OutputTypeName: p.TypeName(p.ObjectNamed(m.GetOutputType())),
+			MethodName:     m.GoName,
+			InputTypeName:  g.QualifiedGoIdent(m.Input.GoIdent),
+			OutputTypeName: g.QualifiedGoIdent(m.Output.GoIdent),
+
+			ClientStreaming: m.Desc.IsStreamingClient(),
+			ServerStreaming: m.Desc.IsStreamingServer(),
 		})
 	}
 
-	return spec
+	return spec, nil
 }
 
 const tmplService = `
 {{$root := .}}
 
+// {{.ServiceName}}Name is the name {{.ServiceName}} is registered under.
+const {{.ServiceName}}Name = "{{.RegisterName}}"
+
 type {{.ServiceName}}Interface interface {
 	{{- range $_, $m := .MethodList}}
+	{{- if $m.ServerStreaming}}
+		{{$m.MethodName}}(in *{{$m.InputTypeName}}, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream) error
+	{{- else if $m.ClientStreaming}}
+		{{$m.MethodName}}(stream {{$root.ServiceName}}_{{$m.MethodName}}Stream, out *{{$m.OutputTypeName}}) error
+	{{- else}}
 		{{$m.MethodName}}(in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error
 	{{- end}}
+	{{- end}}
+}
+
+{{range $_, $m := .MethodList}}
+{{- if $m.ServerStreaming}}
+// {{$root.ServiceName}}_{{$m.MethodName}}Stream is the sink of the
+// {{$m.MethodName}} server stream. The server sends into it, and a
+// client passes one in to receive the messages.
+type {{$root.ServiceName}}_{{$m.MethodName}}Stream interface {
+	Send(*{{$m.OutputTypeName}}) error
+}
+{{else if $m.ClientStreaming}}
+// {{$root.ServiceName}}_{{$m.MethodName}}Stream is the source of the
+// {{$m.MethodName}} client stream. Recv returns io.EOF at the end.
+type {{$root.ServiceName}}_{{$m.MethodName}}Stream interface {
+	Recv() (*{{$m.InputTypeName}}, error)
 }
+{{end}}
+{{- end}}
 
+// Register{{.ServiceName}} serves x on srv. Before x handles a request,
+// its SetDefaults method is called and then its Validate method, if the
+// message has them.
 func Register{{.ServiceName}}(srv *rpc.Server, x {{.ServiceName}}Interface) error {
-	if err := srv.RegisterName("{{.ServiceName}}", x); err != nil {
+	{{- if .HasUnaryMethod}}
+	if err := srv.RegisterName({{.ServiceName}}Name, &_{{.ServiceName}}_Server{x}); err != nil {
+		return err
+	}
+	{{- end}}
+	{{- if .HasStreamMethod}}
+	if err := srv.RegisterName({{.ServiceName}}Name+".Stream", &_{{.ServiceName}}_Stream{
+		x: x, m: make(map[uint64]_{{.ServiceName}}_StreamEntry),
+	}); err != nil {
 		return err
 	}
+	{{- end}}
 	return nil
 }
 
+{{- if .HasUnaryMethod}}
+
+type _{{.ServiceName}}_Server struct {
+	x {{.ServiceName}}Interface
+}
+{{range $_, $m := .MethodList}}
+{{- if not (or $m.ServerStreaming $m.ClientStreaming)}}
+func (p *_{{$root.ServiceName}}_Server) {{$m.MethodName}}(in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error {
+	netrpc.SetDefaults(in)
+	if err := netrpc.Validate(in); err != nil {
+		return err
+	}
+	return p.x.{{$m.MethodName}}(in, out)
+}
+{{end}}
+{{- end}}
+{{- end}}
+
 type {{.ServiceName}}Client struct {
 	*rpc.Client
 }
@@ -108,9 +266,480 @@ func Dial{{.ServiceName}}(network, address string) (*{{.ServiceName}}Client, err
 	return &{{.ServiceName}}Client{Client: c}, nil
 }
 
+// Dial{{.ServiceName}}TLS connects to a {{.ServiceName}} served on a
+// netrpc.ListenTLS listener. For mutual TLS, config holds the client
+// certificate, see netrpc.NewClientTLSConfig.
+func Dial{{.ServiceName}}TLS(network, address string, config *tls.Config) (*{{.ServiceName}}Client, error) {
+	c, err := netrpc.DialTLS(network, address, config)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.ServiceName}}Client{Client: c}, nil
+}
+
+// Dial{{.ServiceName}}JSON connects to a {{.ServiceName}} served with
+// JSON-RPC, see Serve{{.ServiceName}}JSON. The messages are encoded as
+// canonical protobuf JSON.
+func Dial{{.ServiceName}}JSON(network, address string) (*{{.ServiceName}}Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.ServiceName}}Client{
+		Client: rpc.NewClientWithCodec(netrpc.NewJSONClientCodec(conn)),
+	}, nil
+}
+
+// Serve{{.ServiceName}}JSON serves x with JSON-RPC on conn, so that non-Go
+// callers can use the service too. It blocks until the client hangs up.
+//
+//	echo '{"method":"{{.RegisterName}}.<Method>","params":[{...}],"id":0}' | nc localhost 1234
+func Serve{{.ServiceName}}JSON(conn io.ReadWriteCloser, x {{.ServiceName}}Interface) error {
+	srv := rpc.NewServer()
+	if err := Register{{.ServiceName}}(srv, x); err != nil {
+		conn.Close()
+		return err
+	}
+	srv.ServeCodec(netrpc.NewJSONServerCodec(conn))
+	return nil
+}
+
+// New{{.ServiceName}}JSONHandler returns a handler serving one JSON-RPC
+// request per POST body. A malformed body gets 400 Bad Request.
+//
+//	curl localhost:1234/jsonrpc --data '{"method":"{{.RegisterName}}.<Method>","params":[{...}],"id":0}'
+func New{{.ServiceName}}JSONHandler(x {{.ServiceName}}Interface) (http.Handler, error) {
+	srv := rpc.NewServer()
+	if err := Register{{.ServiceName}}(srv, x); err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		netrpc.ServeJSONHTTP(srv, w, r)
+	}), nil
+}
+
+// Dial{{.ServiceName}}Proto connects to a {{.ServiceName}} served with the
+// protobuf codec, see Serve{{.ServiceName}}Proto.
+func Dial{{.ServiceName}}Proto(network, address string) (*{{.ServiceName}}Client, error) {
+	c, err := netrpc.DialProto(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.ServiceName}}Client{Client: c}, nil
+}
+
+// Serve{{.ServiceName}}Proto serves x on conn with requests and responses
+// framed as length-prefixed protobuf. It blocks until the client hangs up.
+func Serve{{.ServiceName}}Proto(conn io.ReadWriteCloser, x {{.ServiceName}}Interface) error {
+	srv := rpc.NewServer()
+	if err := Register{{.ServiceName}}(srv, x); err != nil {
+		conn.Close()
+		return err
+	}
+	srv.ServeCodec(netrpc.NewProtoServerCodec(conn))
+	return nil
+}
+
 {{range $_, $m := .MethodList}}
+{{- if $m.ServerStreaming}}
+func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}(in *{{$m.InputTypeName}}, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream) error {
+	return p.{{$m.MethodName}}Context(context.Background(), in, stream)
+}
+{{else if $m.ClientStreaming}}
+func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}(stream {{$root.ServiceName}}_{{$m.MethodName}}Stream, out *{{$m.OutputTypeName}}) error {
+	return p.{{$m.MethodName}}Context(context.Background(), stream, out)
+}
+{{else}}
 func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}(in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error {
-	return p.Client.Call("{{$root.ServiceName}}.{{$m.MethodName}}", in, out)
+	return p.Client.Call({{$root.ServiceName}}Name+".{{$m.MethodName}}", in, out)
+}
+{{end}}
+{{- end}}
+
+// {{.ServiceName}}DeadlineError is returned by the Context methods of
+// {{.ServiceName}}Client when ctx is done before the reply arrives.
+type {{.ServiceName}}DeadlineError struct {
+	ServiceMethod string
+	Err           error
+}
+
+func (e *{{.ServiceName}}DeadlineError) Error() string {
+	return e.ServiceMethod + ": " + e.Err.Error()
+}
+
+func (e *{{.ServiceName}}DeadlineError) Unwrap() error { return e.Err }
+func (e *{{.ServiceName}}DeadlineError) Timeout() bool { return e.Err == context.DeadlineExceeded }
+
+func (p *{{.ServiceName}}Client) callContext(ctx context.Context, serviceMethod string, in, out interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return &{{.ServiceName}}DeadlineError{ServiceMethod: serviceMethod, Err: err}
+	}
+
+	call := p.Client.Go(serviceMethod, in, out, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return &{{.ServiceName}}DeadlineError{ServiceMethod: serviceMethod, Err: ctx.Err()}
+	case call = <-call.Done:
+		return call.Error
+	}
+}
+
+{{range $_, $m := .MethodList}}
+{{- if $m.ServerStreaming}}
+// {{$m.MethodName}}Context is like {{$m.MethodName}} but gives up when ctx is done.
+// The server side of the stream is closed when ctx is done or stream.Send fails.
+func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}Context(ctx context.Context, in *{{$m.InputTypeName}}, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream) error {
+	var args {{$root.ServiceName}}StreamArgs
+	if err := p.callContext(ctx, {{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Open", in, &args); err != nil {
+		return err
+	}
+
+	for {
+		var chunk {{$root.ServiceName}}_{{$m.MethodName}}Chunk
+		err := p.callContext(ctx, {{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Recv", &args, &chunk)
+		if err == nil && chunk.EOF {
+			return nil
+		}
+		if err == nil && chunk.Msg == nil {
+			continue // nothing sent yet, ask again
+		}
+		if err == nil {
+			err = stream.Send(chunk.Msg)
+		}
+		if err != nil {
+			p.Client.Go({{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Close", &args, new({{$root.ServiceName}}StreamArgs), nil)
+			return err
+		}
+		args.Seq++
+	}
+}
+{{else if $m.ClientStreaming}}
+// {{$m.MethodName}}Context is like {{$m.MethodName}} but gives up when ctx is done.
+// The server side of the stream is closed when ctx is done or stream.Recv fails.
+func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}Context(ctx context.Context, stream {{$root.ServiceName}}_{{$m.MethodName}}Stream, out *{{$m.OutputTypeName}}) error {
+	var args {{$root.ServiceName}}StreamArgs
+	if err := p.callContext(ctx, {{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Open", &args, &args); err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			// the reply has the seq of the next chunk, the same one
+			// while the handler doesn't take it
+			chunk := &{{$root.ServiceName}}_{{$m.MethodName}}Chunk{ID: args.ID, Seq: args.Seq, Msg: msg}
+			reply := {{$root.ServiceName}}StreamArgs{Seq: args.Seq}
+			for err == nil && reply.Seq == args.Seq {
+				err = p.callContext(ctx, {{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Send", chunk, &reply)
+			}
+		}
+		if err != nil {
+			p.Client.Go({{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}Close", &args, new({{$root.ServiceName}}StreamArgs), nil)
+			return err
+		}
+		args.Seq++
+	}
+
+	return p.callContext(ctx, {{$root.ServiceName}}Name+".Stream.{{$m.MethodName}}CloseSend", &args, out)
+}
+{{else}}
+// {{$m.MethodName}}Context is like {{$m.MethodName}} but gives up when ctx is done.
+// The call is not cancelled on the server, so out must not be reused after
+// a *{{$root.ServiceName}}DeadlineError is returned.
+func (p *{{$root.ServiceName}}Client) {{$m.MethodName}}Context(ctx context.Context, in *{{$m.InputTypeName}}, out *{{$m.OutputTypeName}}) error {
+	return p.callContext(ctx, {{$root.ServiceName}}Name+".{{$m.MethodName}}", in, out)
+}
+{{end}}
+{{- end}}
+
+{{- if .HasStreamMethod}}
+// {{.ServiceName}}StreamArgs identifies a stream and the sequence number
+// of the next chunk in the {{.ServiceName}}.Stream calls.
+type {{.ServiceName}}StreamArgs struct {
+	ID  uint64 ` + "`netrpc:\"1\"`" + `
+	Seq uint64 ` + "`netrpc:\"2\"`" + `
+}
+
+var err{{.ServiceName}}StreamClosed = errors.New("{{.ServiceName}}: stream closed")
+var err{{.ServiceName}}StreamSeq = errors.New("{{.ServiceName}}: stream chunk out of sequence")
+
+// _{{.ServiceName}}_Stream serves the streaming methods of {{.ServiceName}}
+// as sequence-numbered chunk calls on top of net/rpc.
+type _{{.ServiceName}}_Stream struct {
+	x  {{.ServiceName}}Interface
+	mu sync.Mutex
+	id uint64
+	m  map[uint64]_{{.ServiceName}}_StreamEntry
+}
+
+// _{{.ServiceName}}_StreamSession is embedded in every stream session. The
+// session is closed by the client, or by its timer when the client makes
+// no call for netrpc.StreamIdleTimeout, such as after a disconnection. A
+// Recv or Send call waits for the handler at most half of the timeout, so
+// that a client still there calls again before the timer fires.
+type _{{.ServiceName}}_StreamSession struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+	timer     *time.Timer
+}
+
+type _{{.ServiceName}}_StreamEntry interface {
+	session() *_{{.ServiceName}}_StreamSession
+}
+
+func (s *_{{.ServiceName}}_StreamSession) session() *_{{.ServiceName}}_StreamSession { return s }
+
+// close unblocks the handler, whose Send or Recv returns an error.
+func (s *_{{.ServiceName}}_StreamSession) close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+func (p *_{{.ServiceName}}_Stream) add(s _{{.ServiceName}}_StreamEntry) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.id++
+	id := p.id
+	p.m[id] = s
+	s.session().closed = make(chan struct{})
+	s.session().timer = time.AfterFunc(netrpc.StreamIdleTimeout, func() {
+		if s := p.remove(id); s != nil {
+			s.session().close()
+		}
+	})
+	return id
+}
+
+// get returns the session of id and restarts its idle timer.
+func (p *_{{.ServiceName}}_Stream) get(id uint64) _{{.ServiceName}}_StreamEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.m[id]
+	if s != nil {
+		s.session().timer.Reset(netrpc.StreamIdleTimeout)
+	}
+	return s
+}
+
+// release restarts the idle timer of the session of id at the end of a
+// call, unless the session was removed meanwhile.
+func (p *_{{.ServiceName}}_Stream) release(id uint64, s _{{.ServiceName}}_StreamEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.m[id] == s {
+		s.session().timer.Reset(netrpc.StreamIdleTimeout)
+	}
+}
+
+func (p *_{{.ServiceName}}_Stream) remove(id uint64) _{{.ServiceName}}_StreamEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.m[id]
+	if s != nil {
+		s.session().timer.Stop()
+		delete(p.m, id)
+	}
+	return s
+}
+{{end}}
+
+{{range $_, $m := .MethodList}}
+{{- if or $m.ServerStreaming $m.ClientStreaming}}
+type {{$root.ServiceName}}_{{$m.MethodName}}Chunk struct {
+	ID  uint64 ` + "`netrpc:\"1\"`" + `
+	Seq uint64 ` + "`netrpc:\"2\"`" + `
+	Msg *{{$m.StreamTypeName}} ` + "`netrpc:\"3\"`" + `
+	EOF bool ` + "`netrpc:\"4\"`" + `
+}
+
+type _{{$root.ServiceName}}_{{$m.MethodName}}_Session struct {
+	_{{$root.ServiceName}}_StreamSession
+
+	mu   sync.Mutex
+	seq  uint64
+	ch   chan *{{$m.StreamTypeName}}
+	done chan struct{}
+	{{- if $m.ClientStreaming}}
+	out  *{{$m.OutputTypeName}}
+
+	// sendClosed is set by CloseSend before ch is closed.
+	sendClosed bool
+	{{- end}}
+	err  error
+}
+{{end}}
+
+{{- if $m.ServerStreaming}}
+func (s *_{{$root.ServiceName}}_{{$m.MethodName}}_Session) Send(m *{{$m.OutputTypeName}}) error {
+	select {
+	case s.ch <- m:
+		return nil
+	case <-s.closed:
+		return err{{$root.ServiceName}}StreamClosed
+	}
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Open(in *{{$m.InputTypeName}}, args *{{$root.ServiceName}}StreamArgs) error {
+	netrpc.SetDefaults(in)
+	if err := netrpc.Validate(in); err != nil {
+		return err
+	}
+
+	s := &_{{$root.ServiceName}}_{{$m.MethodName}}_Session{
+		ch:   make(chan *{{$m.OutputTypeName}}),
+		done: make(chan struct{}),
+	}
+	args.ID = p.add(s)
+
+	go func() {
+		s.err = p.x.{{$m.MethodName}}(in, s)
+		close(s.done)
+	}()
+	return nil
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Recv(args *{{$root.ServiceName}}StreamArgs, chunk *{{$root.ServiceName}}_{{$m.MethodName}}Chunk) error {
+	s, ok := p.get(args.ID).(*_{{$root.ServiceName}}_{{$m.MethodName}}_Session)
+	if !ok {
+		return err{{$root.ServiceName}}StreamClosed
+	}
+	defer p.release(args.ID, s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if args.Seq != s.seq {
+		return err{{$root.ServiceName}}StreamSeq
+	}
+
+	// a chunk without Msg nor EOF has the client ask again
+	chunk.ID, chunk.Seq = args.ID, args.Seq
+	wait := time.NewTimer(netrpc.StreamIdleTimeout / 2)
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return nil
+	case chunk.Msg = <-s.ch:
+		s.seq++
+		return nil
+	case <-s.done:
+		p.remove(args.ID)
+		chunk.EOF = true
+		return s.err
+	case <-s.closed:
+		return err{{$root.ServiceName}}StreamClosed
+	}
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Close(args, reply *{{$root.ServiceName}}StreamArgs) error {
+	if s, ok := p.remove(args.ID).(*_{{$root.ServiceName}}_{{$m.MethodName}}_Session); ok {
+		s.close()
+	}
+	return nil
+}
+{{else if $m.ClientStreaming}}
+func (s *_{{$root.ServiceName}}_{{$m.MethodName}}_Session) Recv() (*{{$m.InputTypeName}}, error) {
+	select {
+	case m, ok := <-s.ch:
+		if !ok {
+			return nil, io.EOF
+		}
+		return m, nil
+	case <-s.closed:
+		return nil, err{{$root.ServiceName}}StreamClosed
+	}
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Open(_, args *{{$root.ServiceName}}StreamArgs) error {
+	s := &_{{$root.ServiceName}}_{{$m.MethodName}}_Session{
+		ch:   make(chan *{{$m.InputTypeName}}),
+		done: make(chan struct{}),
+		out:  new({{$m.OutputTypeName}}),
+	}
+	args.ID = p.add(s)
+
+	go func() {
+		s.err = p.x.{{$m.MethodName}}(s, s.out)
+		close(s.done)
+	}()
+	return nil
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Send(chunk *{{$root.ServiceName}}_{{$m.MethodName}}Chunk, reply *{{$root.ServiceName}}StreamArgs) error {
+	netrpc.SetDefaults(chunk.Msg)
+	if err := netrpc.Validate(chunk.Msg); err != nil {
+		return err
+	}
+
+	s, ok := p.get(chunk.ID).(*_{{$root.ServiceName}}_{{$m.MethodName}}_Session)
+	if !ok {
+		return err{{$root.ServiceName}}StreamClosed
+	}
+	defer p.release(chunk.ID, s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendClosed {
+		return err{{$root.ServiceName}}StreamClosed
+	}
+	if chunk.Seq != s.seq {
+		return err{{$root.ServiceName}}StreamSeq
+	}
+
+	// reply.Seq is the seq of the next chunk, still chunk.Seq when the
+	// handler didn't take it in time
+	reply.ID, reply.Seq = chunk.ID, chunk.Seq
+	wait := time.NewTimer(netrpc.StreamIdleTimeout / 2)
+	defer wait.Stop()
+	select {
+	case <-wait.C:
+		return nil
+	case s.ch <- chunk.Msg:
+		s.seq++
+		reply.Seq = s.seq
+		return nil
+	case <-s.done:
+		if s.err != nil {
+			return s.err
+		}
+		return err{{$root.ServiceName}}StreamClosed
+	case <-s.closed:
+		return err{{$root.ServiceName}}StreamClosed
+	}
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}CloseSend(args *{{$root.ServiceName}}StreamArgs, out *{{$m.OutputTypeName}}) error {
+	s, ok := p.remove(args.ID).(*_{{$root.ServiceName}}_{{$m.MethodName}}_Session)
+	if !ok {
+		return err{{$root.ServiceName}}StreamClosed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if args.Seq != s.seq {
+		s.close()
+		return err{{$root.ServiceName}}StreamSeq
+	}
+
+	// a Send waiting for s.mu must not send on the closed s.ch
+	s.sendClosed = true
+	close(s.ch)
+	<-s.done
+	if s.err != nil {
+		return s.err
+	}
+	proto.Merge(out, s.out)
+	return nil
+}
+
+func (p *_{{$root.ServiceName}}_Stream) {{$m.MethodName}}Close(args, reply *{{$root.ServiceName}}StreamArgs) error {
+	if s, ok := p.remove(args.ID).(*_{{$root.ServiceName}}_{{$m.MethodName}}_Session); ok {
+		s.close()
+	}
+	return nil
 }
 {{end}}
+{{- end}}
 `
@@ -0,0 +1,250 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go test -update
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+var flagUpdate = flag.Bool("update", false, "update the golden files")
+
+// TestGolden feeds the CodeGeneratorRequest fixtures in testdata (see
+// testdata/Makefile) through the plugin and compares the output with
+// testdata/<name>.golden. The generated package is also compiled together
+// with the protoc-gen-go message code.
+func TestGolden(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/*.req.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, path := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(path), ".req.pb")
+		req := loadRequest(t, path)
+
+		t.Run(name, func(t *testing.T) {
+			resp := runPlugin(t, req, generate)
+
+			golden := filepath.Join("testdata", name+".golden")
+			got := formatResponse(resp)
+			if *flagUpdate {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("output differs from %s, run 'go test -update' and review the diff", golden)
+			}
+
+			if resp.Error == nil {
+				compileResponse(t, req, resp)
+			}
+		})
+	}
+}
+
+func loadRequest(t *testing.T, path string) *pluginpb.CodeGeneratorRequest {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := new(pluginpb.CodeGeneratorRequest)
+	if err := proto.Unmarshal(data, req); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return req
+}
+
+func runPlugin(
+	t *testing.T, req *pluginpb.CodeGeneratorRequest,
+	fn func(gen *protogen.Plugin) error,
+) *pluginpb.CodeGeneratorResponse {
+	flags.VisitAll(func(f *flag.Flag) { f.Value.Set(f.DefValue) })
+
+	gen, err := protogen.Options{
+		ParamFunc: flags.Set,
+	}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fn(gen); err != nil {
+		gen.Error(err)
+	}
+	return gen.Response()
+}
+
+// formatResponse dumps the generated files, or the plugin error, in a
+// txtar like format so that golden diffs stay readable.
+func formatResponse(resp *pluginpb.CodeGeneratorResponse) []byte {
+	var buf bytes.Buffer
+	if resp.Error != nil {
+		fmt.Fprintf(&buf, "-- error --\n%s\n", resp.GetError())
+		return buf.Bytes()
+	}
+	for _, f := range resp.File {
+		fmt.Fprintf(&buf, "-- %s --\n%s", f.GetName(), f.GetContent())
+	}
+	return buf.Bytes()
+}
+
+// compileResponse builds the generated files and the matching protoc-gen-go
+// message code as a package in a temporary module.
+func compileResponse(
+	t *testing.T, req *pluginpb.CodeGeneratorRequest,
+	resp *pluginpb.CodeGeneratorResponse,
+) {
+	if testing.Short() {
+		t.Skip("skipping compile in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	msgResp := runPlugin(t, req, func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				internal_gengo.GenerateFile(gen, f)
+			}
+		}
+		return nil
+	})
+	if msgResp.Error != nil {
+		t.Fatal(msgResp.GetError())
+	}
+
+	gen, err := protogen.Options{ParamFunc: flags.Set}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var importPath protogen.GoImportPath
+	for _, f := range gen.Files {
+		if f.Generate {
+			importPath = f.GoImportPath
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "netrpc-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the temporary module also holds a copy of the runtime package
+	// imported by the generated code.
+	pkgDir, err := modulePackageDir(dir, string(importPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtimeDir, err := modulePackageDir(dir, string(netrpcPackage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyPackage(t, runtimeDir, "../netrpc")
+
+	for _, f := range append(msgResp.File, resp.File...) {
+		writeFile(t, filepath.Join(pkgDir, filepath.Base(f.GetName())), []byte(f.GetContent()))
+	}
+	writeGoMod(t, dir, testModulePath)
+
+	cmd := exec.Command(goTool, "vet", string(importPath))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go vet %s: %v\n%s", string(importPath), err, out)
+	}
+}
+
+const testModulePath = "gobook.examples"
+
+// modulePackageDir returns the directory of importPath in a module rooted
+// at dir whose path is testModulePath.
+func modulePackageDir(dir, importPath string) (string, error) {
+	if importPath != testModulePath && !strings.HasPrefix(importPath, testModulePath+"/") {
+		return "", fmt.Errorf("%s is not inside module %s", importPath, testModulePath)
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, testModulePath), "/")
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+func copyPackage(t *testing.T, dst, src string) {
+	files, err := filepath.Glob(filepath.Join(src, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dst, filepath.Base(name)), data)
+	}
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeGoMod requires the same module versions as the test binary and
+// reuses the go.sum of the enclosing module.
+func writeGoMod(t *testing.T, dir, modulePath string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n\ngo 1.13\n", modulePath)
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, m := range info.Deps {
+			if m.Replace != nil {
+				m = m.Replace
+			}
+			fmt.Fprintf(&buf, "\nrequire %s %s\n", m.Path, m.Version)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "go.mod"), buf.Bytes())
+
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return
+	}
+	sum, err := ioutil.ReadFile(filepath.Join(filepath.Dir(strings.TrimSpace(string(out))), "go.sum"))
+	if err != nil {
+		return
+	}
+	writeFile(t, filepath.Join(dir, "go.sum"), sum)
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// protoc-gen-dumpreq writes the CodeGeneratorRequest it receives from
+// protoc to <name>.req.pb, where name is the plugin parameter. It is used
+// to record the fixtures of the golden tests:
+//
+//	protoc --dumpreq_out=. --dumpreq_opt=hello hello.proto
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var req pluginpb.CodeGeneratorRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		log.Fatal(err)
+	}
+
+	// the fixture name is the first parameter, the rest is kept for the
+	// plugin under test.
+	name, param := req.GetParameter(), ""
+	if i := strings.Index(name, ","); i >= 0 {
+		name, param = name[:i], name[i+1:]
+	}
+	if param != "" {
+		req.Parameter = proto.String(param)
+	} else {
+		req.Parameter = nil
+	}
+
+	data, err = proto.MarshalOptions{Deterministic: true}.Marshal(&req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err = proto.Marshal(&pluginpb.CodeGeneratorResponse{
+		File: []*pluginpb.CodeGeneratorResponse_File{{
+			Name:    proto.String(name + ".req.pb"),
+			Content: proto.String(string(data)),
+		}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gobook.examples/ch4-02-proto/netrpc"
+)
+
+// streamService reports the end of its stream handlers on done. When
+// hold is set, the stream handlers wait for it to be closed first.
+type streamService struct {
+	done chan error
+	hold chan struct{}
+}
+
+func (p *streamService) wait() {
+	if p.hold != nil {
+		<-p.hold
+	}
+}
+
+func newStreamService() *streamService {
+	return &streamService{done: make(chan error, 1)}
+}
+
+func (p *streamService) Echo(in *String, out *String) error {
+	out.Value = in.GetValue()
+	return nil
+}
+
+func (p *streamService) Upload(stream StreamService_UploadStream, out *String) (err error) {
+	defer func() { p.done <- err }()
+	p.wait()
+
+	var values []string
+	for {
+		m, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		values = append(values, m.GetValue())
+	}
+	out.Value = strings.Join(values, "")
+	return nil
+}
+
+func (p *streamService) Download(in *String, stream StreamService_DownloadStream) (err error) {
+	defer func() { p.done <- err }()
+	p.wait()
+
+	for _, c := range in.GetValue() {
+		if err := stream.Send(&String{Value: string(c)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ended waits for a stream handler to return and returns its error.
+func (p *streamService) ended(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-p.done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream handler still running")
+	}
+	return nil
+}
+
+// source is a client stream of values, failing with err once drained.
+type source struct {
+	values []string
+	err    error
+}
+
+func (s *source) Recv() (*String, error) {
+	if len(s.values) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	m := &String{Value: s.values[0]}
+	s.values = s.values[1:]
+	return m, nil
+}
+
+// sink collects a server stream, failing with err after max messages.
+type sink struct {
+	values []string
+	max    int
+	err    error
+}
+
+func (s *sink) Send(m *String) error {
+	if s.err != nil && len(s.values) == s.max {
+		return s.err
+	}
+	s.values = append(s.values, m.GetValue())
+	return nil
+}
+
+func newClient(t *testing.T, x StreamServiceInterface) *StreamServiceClient {
+	_, client, err := NewStreamServicePipe(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestStream(t *testing.T) {
+	svc := newStreamService()
+	client := newClient(t, svc)
+
+	var out String
+	if err := client.Upload(&source{values: []string{"a", "b", "c"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.GetValue() != "abc" {
+		t.Fatalf("expect = abc, got = %q", out.GetValue())
+	}
+	if err := svc.ended(t); err != nil {
+		t.Fatal(err)
+	}
+
+	var s sink
+	if err := client.Download(&String{Value: "xyz"}, &s); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(s.values, ","); got != "x,y,z" {
+		t.Fatalf("expect = x,y,z, got = %q", got)
+	}
+	if err := svc.ended(t); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStreamEarlyClose(t *testing.T) {
+	svc := newStreamService()
+	client := newClient(t, svc)
+	errBoom := errors.New("boom")
+
+	err := client.Upload(&source{values: []string{"a"}, err: errBoom}, new(String))
+	if err != errBoom {
+		t.Fatalf("expect = %v, got = %v", errBoom, err)
+	}
+	if err := svc.ended(t); err == nil {
+		t.Fatal("expect the Upload handler to fail")
+	}
+
+	err = client.Download(&String{Value: "xyz"}, &sink{max: 1, err: errBoom})
+	if err != errBoom {
+		t.Fatalf("expect = %v, got = %v", errBoom, err)
+	}
+	if err := svc.ended(t); err == nil {
+		t.Fatal("expect the Download handler to fail")
+	}
+
+	// the client is still usable
+	var out String
+	if err := client.Echo(&String{Value: "hi"}, &out); err != nil || out.GetValue() != "hi" {
+		t.Fatalf("Echo: %v, %q", err, out.GetValue())
+	}
+}
+
+// TestStreamIdle opens streams like a client which goes away: the server
+// closes them after netrpc.StreamIdleTimeout.
+func TestStreamIdle(t *testing.T) {
+	defer func(d time.Duration) { netrpc.StreamIdleTimeout = d }(netrpc.StreamIdleTimeout)
+	netrpc.StreamIdleTimeout = 50 * time.Millisecond
+
+	svc := newStreamService()
+	client := newClient(t, svc)
+
+	var args StreamServiceStreamArgs
+	if err := client.Call(StreamServiceName+".Stream.DownloadOpen", &String{Value: "xyz"}, &args); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ended(t); err == nil {
+		t.Fatal("expect the Download handler to fail")
+	}
+	var chunk StreamService_DownloadChunk
+	if err := client.Call(StreamServiceName+".Stream.DownloadRecv", &args, &chunk); err == nil {
+		t.Fatal("expect the stream closed")
+	}
+
+	args = StreamServiceStreamArgs{}
+	if err := client.Call(StreamServiceName+".Stream.UploadOpen", &args, &args); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ended(t); err == nil {
+		t.Fatal("expect the Upload handler to fail")
+	}
+}
+
+// TestStreamSlowHandler runs handlers slower than netrpc.StreamIdleTimeout:
+// the calls of a client still there keep the streams open.
+func TestStreamSlowHandler(t *testing.T) {
+	defer func(d time.Duration) { netrpc.StreamIdleTimeout = d }(netrpc.StreamIdleTimeout)
+	netrpc.StreamIdleTimeout = 50 * time.Millisecond
+
+	svc := newStreamService()
+	client := newClient(t, svc)
+
+	svc.hold = make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(svc.hold) })
+	var s sink
+	if err := client.Download(&String{Value: "xy"}, &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ended(t); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.hold = make(chan struct{})
+	time.AfterFunc(200*time.Millisecond, func() { close(svc.hold) })
+	var out String
+	if err := client.Upload(&source{values: []string{"a", "b"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.GetValue() != "ab" {
+		t.Fatalf("expect = ab, got = %q", out.GetValue())
+	}
+	if err := svc.ended(t); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestStreamIdleInFlight leaves a Recv call in flight, like a client going
+// away while waiting: the stream is still closed after the idle timeout.
+func TestStreamIdleInFlight(t *testing.T) {
+	defer func(d time.Duration) { netrpc.StreamIdleTimeout = d }(netrpc.StreamIdleTimeout)
+	netrpc.StreamIdleTimeout = 50 * time.Millisecond
+
+	svc := newStreamService()
+	svc.hold = make(chan struct{})
+	client := newClient(t, svc)
+
+	var args StreamServiceStreamArgs
+	if err := client.Call(StreamServiceName+".Stream.DownloadOpen", &String{Value: "xyz"}, &args); err != nil {
+		t.Fatal(err)
+	}
+	client.Go(StreamServiceName+".Stream.DownloadRecv", &args, new(StreamService_DownloadChunk), nil)
+
+	time.Sleep(200 * time.Millisecond)
+	close(svc.hold)
+	if err := svc.ended(t); err == nil {
+		t.Fatal("expect the Download handler to fail")
+	}
+}
+
+// TestStreamSendCloseSend has Send calls of the seq of a CloseSend wait
+// for the session while CloseSend ends it, as a client retrying its last
+// chunk: they fail instead of sending on the closed channel.
+func TestStreamSendCloseSend(t *testing.T) {
+	svc := newStreamService()
+	p := &_StreamService_Stream{x: svc, m: make(map[uint64]_StreamService_StreamEntry)}
+
+	var args StreamServiceStreamArgs
+	if err := p.UploadOpen(nil, &args); err != nil {
+		t.Fatal(err)
+	}
+	s := p.m[args.ID].(*_StreamService_Upload_Session)
+
+	// CloseSend removes the session and waits for s.mu, then the Send
+	// calls, which got the session before, wait for it too
+	s.mu.Lock()
+	closed := make(chan error, 1)
+	go func() { closed <- p.UploadCloseSend(&args, new(String)) }()
+	for p.get(args.ID) != nil {
+		time.Sleep(time.Millisecond)
+	}
+	p.mu.Lock()
+	p.m[args.ID] = s
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chunk := &StreamService_UploadChunk{ID: args.ID, Msg: &String{Value: "a"}}
+			errs <- p.UploadSend(chunk, new(StreamServiceStreamArgs))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	p.remove(args.ID)
+	s.mu.Unlock()
+
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != errStreamServiceStreamClosed {
+			t.Fatalf("expect = %v, got = %v", errStreamServiceStreamClosed, err)
+		}
+	}
+	if err := svc.ended(t); err != nil {
+		t.Fatal(err)
+	}
+}
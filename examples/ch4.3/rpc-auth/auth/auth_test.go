@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+type HelloService struct {
+	session *Session
+}
+
+func (p *HelloService) Hello(request string, reply *string) error {
+	user, _ := p.session.User()
+	*reply = request + ":" + user
+	return nil
+}
+
+func (p *HelloService) Ping(request string, reply *string) error {
+	*reply = request
+	return nil
+}
+
+func (p *HelloService) Admin(request string, reply *string) error {
+	*reply = request
+	return nil
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestServer(clock *fakeClock) *Server {
+	s := NewServer(NewStaticAuthenticator(map[string]string{
+		"user":  "password",
+		"admin": "secret",
+	}), Permissions{
+		"HelloService.Ping":  {Anonymous},
+		"HelloService.Hello": {Authenticated},
+		"HelloService.Admin": {"admin"},
+	})
+	s.SessionTTL = time.Minute
+	s.now = clock.Now
+	return s
+}
+
+func newPipeClient(t *testing.T, s *Server, json bool) *rpc.Client {
+	conn, serverConn := net.Pipe()
+
+	session := s.NewSession()
+	srv := rpc.NewServer()
+	srv.Register(&HelloService{session: session})
+	go func() {
+		var err error
+		if json {
+			err = s.ServeCodec(srv, session, jsonrpc.NewServerCodec(serverConn))
+		} else {
+			err = s.ServeConn(srv, session, serverConn)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var client *rpc.Client
+	if json {
+		client = jsonrpc.NewClient(conn)
+	} else {
+		client = rpc.NewClient(conn)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func expectError(t *testing.T, err, expect error) {
+	t.Helper()
+	if expect == nil {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err := rpcerrors.FromRPC(err); !errors.Is(err, expect) {
+		t.Fatalf("expect = %v, got = %v", expect, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	for _, json := range []bool{false, true} {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		client := newPipeClient(t, newTestServer(clock), json)
+
+		var reply string
+		expectError(t, client.Call("HelloService.Ping", "ping", &reply), nil)
+		expectError(t, client.Call("HelloService.Hello", "hi", &reply), ErrNotLoggedIn)
+		expectError(t, client.Call("HelloService.Admin", "hi", &reply), ErrNotLoggedIn)
+		expectError(t, client.Call("HelloService.Unknown", "hi", &reply), ErrPermissionDenied)
+
+		_, err := Login(client, "user:bad")
+		expectError(t, err, ErrAuthFailed)
+
+		login, err := Login(client, "user:password")
+		expectError(t, err, nil)
+		if login.User != "user" || login.ExpiresUnix != 1060 {
+			t.Fatalf("unexpected login reply: %v", login)
+		}
+
+		expectError(t, client.Call("HelloService.Hello", "hi", &reply), nil)
+		if reply != "hi:user" {
+			t.Fatalf("expect = %q, got = %q", "hi:user", reply)
+		}
+		expectError(t, client.Call("HelloService.Admin", "hi", &reply), ErrPermissionDenied)
+
+		_, err = Login(client, "admin:secret")
+		expectError(t, err, nil)
+		expectError(t, client.Call("HelloService.Admin", "hi", &reply), nil)
+
+		clock.Add(time.Minute)
+		expectError(t, client.Call("HelloService.Hello", "hi", &reply), ErrSessionExpired)
+		expectError(t, client.Call("HelloService.Ping", "ping", &reply), nil)
+
+		_, err = Login(client, "user:password")
+		expectError(t, err, nil)
+		expectError(t, client.Call("HelloService.Hello", "hi", &reply), nil)
+
+		expectError(t, Logout(client), nil)
+		expectError(t, client.Call("HelloService.Hello", "hi", &reply), ErrNotLoggedIn)
+	}
+}
+
+func TestServerConcurrentCalls(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	client := newPipeClient(t, newTestServer(clock), false)
+
+	if _, err := Login(client, "user:password"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var reply string
+			if i%2 == 0 {
+				if err := client.Call("HelloService.Hello", "hi", &reply); err != nil {
+					t.Error(err)
+				}
+			} else {
+				err := rpcerrors.FromRPC(client.Call("HelloService.Admin", "hi", &reply))
+				if !errors.Is(err, ErrPermissionDenied) {
+					t.Errorf("expect = %v, got = %v", ErrPermissionDenied, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestPermissionsWildcard(t *testing.T) {
+	p := Permissions{
+		"HelloService.*":     {Authenticated},
+		"HelloService.Admin": {"admin"},
+	}
+	if users, _ := p.users("HelloService.Hello"); len(users) != 1 || users[0] != Authenticated {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if users, _ := p.users("HelloService.Admin"); len(users) != 1 || users[0] != "admin" {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if _, ok := p.users("OtherService.Hello"); ok {
+		t.Fatal("unexpected users of OtherService.Hello")
+	}
+}
+
+func TestStaticFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "users.txt")
+	data := "# users\nuser:password\n\nadmin:a:b\n"
+	if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := LoadStaticFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for credential, expect := range map[string]string{
+		"user:password": "user",
+		"admin:a:b":     "admin",
+		"user:bad":      "",
+		"nobody:":       "",
+		"user":          "",
+	} {
+		user, err := a.Authenticate(credential)
+		if user != expect || (expect == "") != errors.Is(err, ErrAuthFailed) {
+			t.Fatalf("%q: unexpected %q, %v", credential, user, err)
+		}
+	}
+
+	if err := os.WriteFile(filename, []byte("user\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadStaticFile(filename); err == nil {
+		t.Fatal("expect error of bad line")
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "htpasswd")
+	data := "user:" + string(hash) + "\n" +
+		// htpasswd -bs htpasswd sha password
+		"sha:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"
+	if err := os.WriteFile(filename, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := LoadHtpasswdFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for credential, expect := range map[string]string{
+		"user:password": "user",
+		"sha:password":  "sha",
+		"user:bad":      "",
+		"sha:bad":       "",
+		"nobody:x":      "",
+	} {
+		user, err := a.Authenticate(credential)
+		if user != expect || (expect == "") != errors.Is(err, ErrAuthFailed) {
+			t.Fatalf("%q: unexpected %q, %v", credential, user, err)
+		}
+	}
+
+	if _, err := NewHtpasswdAuthenticator(map[string]string{"md5": "$apr1$x$y"}); err == nil {
+		t.Fatal("expect error of unsupported hash")
+	}
+}
+
+func TestHMACToken(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	a := NewHMACAuthenticator([]byte("key"))
+	a.now = clock.Now
+
+	token := a.NewToken("user:x", time.Unix(1060, 0))
+	user, err := a.Authenticate(token)
+	if err != nil || user != "user:x" {
+		t.Fatalf("unexpected %q, %v", user, err)
+	}
+
+	other := NewHMACAuthenticator([]byte("other"))
+	other.now = clock.Now
+	if _, err := other.Authenticate(token); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expect = %v, got = %v", ErrAuthFailed, err)
+	}
+
+	forged := "admin:1060" + token[len("user:x:1060"):]
+	if _, err := a.Authenticate(forged); !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expect = %v, got = %v", ErrAuthFailed, err)
+	}
+
+	clock.Add(time.Minute)
+	if _, err := a.Authenticate(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expect = %v, got = %v", ErrTokenExpired, err)
+	}
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+var (
+	ErrAuthFailed   = rpcerrors.NewWithCode(401, "auth: auth failed")
+	ErrTokenExpired = rpcerrors.NewWithCode(401, "auth: token expired")
+)
+
+// Authenticator checks a credential sent by Auth.Login and returns the
+// user it belongs to.
+type Authenticator interface {
+	Authenticate(credential string) (user string, err error)
+}
+
+// StaticAuthenticator checks "user:password" credentials against a fixed
+// table of passwords.
+type StaticAuthenticator struct {
+	passwords map[string]string
+}
+
+func NewStaticAuthenticator(passwords map[string]string) *StaticAuthenticator {
+	m := make(map[string]string, len(passwords))
+	for user, password := range passwords {
+		m[user] = password
+	}
+	return &StaticAuthenticator{passwords: m}
+}
+
+// LoadStaticFile reads a file with one "user:password" per line. Empty
+// lines and lines starting with # are skipped.
+func LoadStaticFile(filename string) (*StaticAuthenticator, error) {
+	lines, err := readUserLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticAuthenticator(lines), nil
+}
+
+func (p *StaticAuthenticator) Authenticate(credential string) (string, error) {
+	user, password, ok := strings.Cut(credential, ":")
+	if !ok {
+		return "", ErrAuthFailed
+	}
+	expect, ok := p.passwords[user]
+	if !ok || subtle.ConstantTimeCompare([]byte(expect), []byte(password)) != 1 {
+		return "", ErrAuthFailed
+	}
+	return user, nil
+}
+
+// HtpasswdAuthenticator checks "user:password" credentials against the
+// hashes of an Apache htpasswd file. The bcrypt ("htpasswd -B") and SHA1
+// ("htpasswd -s") hashes are supported.
+type HtpasswdAuthenticator struct {
+	hashes map[string]string
+}
+
+func NewHtpasswdAuthenticator(hashes map[string]string) (*HtpasswdAuthenticator, error) {
+	m := make(map[string]string, len(hashes))
+	for user, hash := range hashes {
+		if !isBcrypt(hash) && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("auth: unsupported htpasswd hash of user %q", user)
+		}
+		m[user] = hash
+	}
+	return &HtpasswdAuthenticator{hashes: m}, nil
+}
+
+func LoadHtpasswdFile(filename string) (*HtpasswdAuthenticator, error) {
+	lines, err := readUserLines(filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewHtpasswdAuthenticator(lines)
+}
+
+func (p *HtpasswdAuthenticator) Authenticate(credential string) (string, error) {
+	user, password, ok := strings.Cut(credential, ":")
+	if !ok {
+		return "", ErrAuthFailed
+	}
+	hash, ok := p.hashes[user]
+	if !ok {
+		return "", ErrAuthFailed
+	}
+
+	if isBcrypt(hash) {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+			return "", ErrAuthFailed
+		}
+		return user, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	expect := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expect), []byte(hash)) != 1 {
+		return "", ErrAuthFailed
+	}
+	return user, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+// HMACAuthenticator checks tokens made by NewToken with the same key, so
+// that the server needs no password table. A token has the form
+// "user:expires:signature", expires is a Unix time.
+type HMACAuthenticator struct {
+	key []byte
+	now func() time.Time
+}
+
+func NewHMACAuthenticator(key []byte) *HMACAuthenticator {
+	return &HMACAuthenticator{key: append([]byte(nil), key...), now: time.Now}
+}
+
+// NewToken returns a token of user valid until expires.
+func (p *HMACAuthenticator) NewToken(user string, expires time.Time) string {
+	payload := user + ":" + strconv.FormatInt(expires.Unix(), 10)
+	return payload + ":" + hex.EncodeToString(p.sign(payload))
+}
+
+func (p *HMACAuthenticator) Authenticate(credential string) (string, error) {
+	i := strings.LastIndex(credential, ":")
+	if i < 0 {
+		return "", ErrAuthFailed
+	}
+	payload, signature := credential[:i], credential[i+1:]
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, p.sign(payload)) {
+		return "", ErrAuthFailed
+	}
+
+	j := strings.LastIndex(payload, ":")
+	if j < 0 {
+		return "", ErrAuthFailed
+	}
+	expires, err := strconv.ParseInt(payload[j+1:], 10, 64)
+	if err != nil {
+		return "", ErrAuthFailed
+	}
+	if p.now().Unix() >= expires {
+		return "", ErrTokenExpired
+	}
+	return payload[:j], nil
+}
+
+func (p *HMACAuthenticator) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// readUserLines reads the "user:value" lines of filename.
+func readUserLines(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, value, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("auth: %s:%d: expect user:value", filename, n)
+		}
+		m[user] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"net/rpc"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+// Login calls Auth.Login on client, the later calls of the connection are
+// made as the returned user.
+func Login(client *rpc.Client, credential string) (*LoginReply, error) {
+	reply := new(LoginReply)
+	err := client.Call(AuthServiceName+".Login", credential, reply)
+	if err != nil {
+		return nil, rpcerrors.FromRPC(err)
+	}
+	return reply, nil
+}
+
+func Logout(client *rpc.Client) error {
+	var reply string
+	return rpcerrors.FromRPC(client.Call(AuthServiceName+".Logout", "", &reply))
+}
@@ -0,0 +1,266 @@
+// Package auth adds login sessions and per-method permissions to net/rpc
+// servers.
+//
+// A connection calls Auth.Login with a credential, which is checked by the
+// Authenticator of the Server. The permission table is checked before each
+// request is dispatched, so the registered services need not check the
+// login state themselves. The errors are sent with rpcerrors.ToRPC, use
+// rpcerrors.FromRPC on the client to match them with errors.Is.
+package auth
+
+import (
+	"io"
+	"log"
+	"net/rpc"
+	"strings"
+	"sync"
+	"time"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+// AuthServiceName is the name of the login service registered by ServeConn.
+const AuthServiceName = "Auth"
+
+// DefaultSessionTTL is the lifetime of a session if Server.SessionTTL is 0.
+const DefaultSessionTTL = 30 * time.Minute
+
+// The special users of the permission table.
+const (
+	Anonymous     = "?" // everyone, also without login
+	Authenticated = "*" // every logged in user
+)
+
+var (
+	ErrNotLoggedIn      = rpcerrors.NewWithCode(401, "auth: please login")
+	ErrSessionExpired   = rpcerrors.NewWithCode(401, "auth: session expired")
+	ErrPermissionDenied = rpcerrors.NewWithCode(403, "auth: permission denied")
+)
+
+// Permissions maps "Service.Method" to the users allowed to call it.
+// "Service.*" sets all the methods of a service. Methods not in the table
+// cannot be called, except those of the Auth service.
+type Permissions map[string][]string
+
+func (p Permissions) users(serviceMethod string) ([]string, bool) {
+	if users, ok := p[serviceMethod]; ok {
+		return users, true
+	}
+	if i := strings.LastIndex(serviceMethod, "."); i >= 0 {
+		users, ok := p[serviceMethod[:i]+".*"]
+		return users, ok
+	}
+	return nil, false
+}
+
+type Server struct {
+	Authenticator Authenticator
+	Permissions   Permissions
+
+	// SessionTTL is the lifetime of a session, DefaultSessionTTL if 0.
+	SessionTTL time.Duration
+
+	// LoginLog logs the logins and the failed attempts. Nothing is logged
+	// if nil.
+	LoginLog *log.Logger
+
+	now func() time.Time
+}
+
+func NewServer(authenticator Authenticator, permissions Permissions) *Server {
+	return &Server{Authenticator: authenticator, Permissions: permissions}
+}
+
+func (p *Server) sessionTTL() time.Duration {
+	if p.SessionTTL > 0 {
+		return p.SessionTTL
+	}
+	return DefaultSessionTTL
+}
+
+func (p *Server) logf(format string, args ...interface{}) {
+	if p.LoginLog != nil {
+		p.LoginLog.Printf(format, args...)
+	}
+}
+
+func (p *Server) timeNow() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// NewSession returns the login state of a new connection. Pass it to the
+// per-connection services which need the user, and to ServeConn.
+func (p *Server) NewSession() *Session {
+	return &Session{server: p}
+}
+
+// ServeConn registers the Auth service of session on srv, which must be
+// the rpc.Server of this connection only, and serves conn with the gob
+// codec of net/rpc.
+func (p *Server) ServeConn(srv *rpc.Server, session *Session, conn io.ReadWriteCloser) error {
+	return p.ServeCodec(srv, session, rpcserver.NewGobServerCodec(conn))
+}
+
+// ServeCodec is like ServeConn but uses codec, such as the one of
+// net/rpc/jsonrpc.
+func (p *Server) ServeCodec(srv *rpc.Server, session *Session, codec rpc.ServerCodec) error {
+	if err := srv.RegisterName(AuthServiceName, &authService{session}); err != nil {
+		return err
+	}
+	srv.ServeCodec(&serverCodec{ServerCodec: codec, session: session})
+	return nil
+}
+
+// check returns nil if the user of session may call serviceMethod.
+func (p *Server) check(session *Session, serviceMethod string) error {
+	if strings.HasPrefix(serviceMethod, AuthServiceName+".") {
+		return nil
+	}
+
+	users, ok := p.Permissions.users(serviceMethod)
+	if !ok {
+		return ErrPermissionDenied
+	}
+	for _, u := range users {
+		if u == Anonymous {
+			return nil
+		}
+	}
+
+	user, err := session.user()
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u == Authenticated || u == user {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
+
+// Session is the login state of a connection.
+type Session struct {
+	server *Server
+
+	mu      sync.Mutex
+	name    string
+	expires time.Time
+	expired bool
+}
+
+// User returns the logged in user, ok is false if there is none or the
+// session has expired.
+func (p *Session) User() (user string, ok bool) {
+	user, err := p.user()
+	return user, err == nil
+}
+
+func (p *Session) user() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.name == "" {
+		if p.expired {
+			return "", ErrSessionExpired
+		}
+		return "", ErrNotLoggedIn
+	}
+	if !p.server.timeNow().Before(p.expires) {
+		p.name, p.expired = "", true
+		return "", ErrSessionExpired
+	}
+	return p.name, nil
+}
+
+func (p *Session) login(credential string) (string, time.Time, error) {
+	user, err := p.server.Authenticator.Authenticate(credential)
+	if err != nil {
+		p.server.logf("login failed: %v", err)
+		return "", time.Time{}, err
+	}
+	p.server.logf("login ok: %s", user)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.name, p.expired = user, false
+	p.expires = p.server.timeNow().Add(p.server.sessionTTL())
+	return p.name, p.expires, nil
+}
+
+func (p *Session) logout() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.name, p.expired = "", false
+}
+
+type LoginReply struct {
+	User string
+
+	// ExpiresUnix is the Unix time the session expires, Login must be
+	// called again after it.
+	ExpiresUnix int64
+}
+
+type authService struct {
+	session *Session
+}
+
+func (p *authService) Login(credential string, reply *LoginReply) error {
+	user, expires, err := p.session.login(credential)
+	if err != nil {
+		return rpcerrors.ToRPC(err)
+	}
+	reply.User, reply.ExpiresUnix = user, expires.Unix()
+	return nil
+}
+
+func (p *authService) Logout(request string, reply *string) error {
+	p.session.logout()
+	return nil
+}
+
+// serverCodec answers the requests which fail the permission check
+// itself, so that the server only sees the allowed ones.
+type serverCodec struct {
+	rpc.ServerCodec
+	session *Session
+
+	sending sync.Mutex
+}
+
+func (p *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		if err := p.ServerCodec.ReadRequestHeader(r); err != nil {
+			return err
+		}
+		err := p.session.server.check(p.session, r.ServiceMethod)
+		if err == nil {
+			return nil
+		}
+
+		if err := p.ServerCodec.ReadRequestBody(nil); err != nil {
+			return err
+		}
+		p.sending.Lock()
+		err = p.ServerCodec.WriteResponse(&rpc.Response{
+			ServiceMethod: r.ServiceMethod,
+			Seq:           r.Seq,
+			Error:         rpcerrors.ToRPC(err).Error(),
+		}, struct{}{})
+		p.sending.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	p.sending.Lock()
+	defer p.sending.Unlock()
+	return p.ServerCodec.WriteResponse(r, body)
+}
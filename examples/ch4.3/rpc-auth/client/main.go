@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"net/rpc"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpc-auth/auth"
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
 )
 
 func main() {
@@ -14,14 +17,19 @@ func main() {
 
 	var reply string
 
-	err = client.Call("HelloService.Login", "abc", &reply)
+	err = rpcerrors.FromRPC(client.Call("HelloService.Hello", "hello", &reply))
+	if e, ok := err.(rpcerrors.Error); ok {
+		log.Println("hello before login:", e.Code(), e)
+	}
+
+	_, err = auth.Login(client, "abc")
 	if err != nil {
 		log.Println(err)
 	} else {
 		log.Println("login ok")
 	}
 
-	err = client.Call("HelloService.Login", "user:password", &reply)
+	_, err = auth.Login(client, "user:password")
 	if err != nil {
 		log.Println(err)
 	} else {
@@ -1,6 +1,97 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"time"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+	pb "gobook.examples/ch4-02-proto/hello.pb"
+	"gobook.examples/ch4-02-proto/netrpc"
+)
+
+var (
+	flagAddr = flag.String("addr", "localhost:1234", "listen and dial address")
+
+	tlsDir        = "./tls-config"
+	tlsServerName = "server.io"
+
+	ca         = tlsDir + "/ca.crt"
+	server_crt = tlsDir + "/server.crt"
+	server_key = tlsDir + "/server.key"
+	client_crt = tlsDir + "/client.crt"
+	client_key = tlsDir + "/client.key"
+)
+
+// HelloService is created for each connection, so it knows the client
+// certificate the connection was made with.
+type HelloService struct {
+	conn     net.Conn
+	identity *netrpc.Identity
+}
+
+func ServeHelloService(conn net.Conn, codec rpc.ServerCodec) {
+	id, err := netrpc.PeerIdentity(conn)
+	if err != nil {
+		log.Println("handshake error:", err)
+		codec.Close()
+		return
+	}
+
+	p := rpc.NewServer()
+	pb.RegisterHelloService(p, &HelloService{conn: conn, identity: id})
+	p.ServeCodec(codec)
+}
+
+func (p *HelloService) Hello(request *pb.String, reply *pb.String) error {
+	reply.Value = "hello:" + request.GetValue() + ", " + p.identity.String() +
+		" from" + p.conn.RemoteAddr().String()
+	return nil
+}
+
 func main() {
-	// todo
-	// tls
+	flag.Parse()
+
+	go startServer()
+	time.Sleep(time.Second)
+
+	doClientWork()
+}
+
+func startServer() {
+	config, err := netrpc.NewServerTLSConfig(server_crt, server_key, ca)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	listener, err := netrpc.ListenTLS("tcp", *flagAddr, config)
+	if err != nil {
+		log.Fatal("ListenTLS error:", err)
+	}
+
+	server := &rpcserver.Server{ServeCodec: ServeHelloService}
+	log.Fatal(server.Serve(listener))
+}
+
+func doClientWork() {
+	config, err := netrpc.NewClientTLSConfig(ca, client_crt, client_key, tlsServerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := pb.DialHelloServiceTLS("tcp", *flagAddr, config)
+	if err != nil {
+		log.Fatal("dialing:", err)
+	}
+	defer client.Close()
+
+	var reply pb.String
+	if err := client.Hello(&pb.String{Value: "hello"}, &reply); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(reply.GetValue())
 }
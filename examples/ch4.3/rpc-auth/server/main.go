@@ -1,53 +1,78 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"log"
 	"net"
 	"net/rpc"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+	"gobook.examples/ch4-03-netrpc-hack/rpc-auth/auth"
+)
+
+var (
+	flagStatic   = flag.String("static", "", "user:password file")
+	flagHtpasswd = flag.String("htpasswd", "", "htpasswd file")
+	flagHMACKey  = flag.String("hmac-key", "", "key of the HMAC tokens")
 )
 
 type HelloService struct {
 	conn    net.Conn
-	isLogin bool
+	session *auth.Session
 }
 
-func ServeHelloService(conn net.Conn) {
-	p := rpc.NewServer()
-	p.Register(&HelloService{conn: conn})
-	p.ServeConn(conn)
+func (p *HelloService) Hello(request string, reply *string) error {
+	user, _ := p.session.User()
+	*reply = "hello:" + request + ", " + user + " from" + p.conn.RemoteAddr().String()
+	return nil
 }
 
-func (p *HelloService) Login(request string, reply *string) error {
-	if request != "user:password" {
-		log.Println("login failed")
-		return fmt.Errorf("auth failed")
-	}
-	log.Println("login ok")
-	p.isLogin = true
+func (p *HelloService) Ping(request string, reply *string) error {
+	*reply = "pong:" + request
 	return nil
 }
 
-func (p *HelloService) Hello(request string, reply *string) error {
-	if !p.isLogin {
-		return fmt.Errorf("please login")
+func ServeHelloService(authServer *auth.Server, conn net.Conn, codec rpc.ServerCodec) {
+	session := authServer.NewSession()
+
+	p := rpc.NewServer()
+	p.Register(&HelloService{conn: conn, session: session})
+	if err := authServer.ServeCodec(p, session, codec); err != nil {
+		log.Println(err)
+		codec.Close()
 	}
-	*reply = "hello:" + request + ", from" + p.conn.RemoteAddr().String()
-	return nil
+}
+
+func newAuthenticator() (auth.Authenticator, error) {
+	switch {
+	case *flagStatic != "":
+		return auth.LoadStaticFile(*flagStatic)
+	case *flagHtpasswd != "":
+		return auth.LoadHtpasswdFile(*flagHtpasswd)
+	case *flagHMACKey != "":
+		return auth.NewHMACAuthenticator([]byte(*flagHMACKey)), nil
+	}
+	return auth.NewStaticAuthenticator(map[string]string{"user": "password"}), nil
 }
 
 func main() {
-	listener, err := net.Listen("tcp", ":1234")
+	flag.Parse()
+
+	authenticator, err := newAuthenticator()
 	if err != nil {
-		log.Fatal("ListenTCP error:", err)
+		log.Fatal(err)
 	}
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Fatal("Accept error:", err)
-		}
+	authServer := auth.NewServer(authenticator, auth.Permissions{
+		"HelloService.Ping":  {auth.Anonymous},
+		"HelloService.Hello": {auth.Authenticated},
+	})
+	authServer.LoginLog = log.Default()
 
-		go ServeHelloService(conn)
+	server := &rpcserver.Server{
+		ServeCodec: func(conn net.Conn, codec rpc.ServerCodec) {
+			ServeHelloService(authServer, conn, codec)
+		},
 	}
+	log.Fatal(server.ListenAndServe("tcp", ":1234"))
 }
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"net/rpc"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcmeta"
 )
 
+type HelloRequest struct {
+	Value string
+}
+
 func main() {
-	client, err := rpc.Dial("tcp", "localhost:1234")
+	client, err := rpcmeta.Dial("tcp", "localhost:1234")
 	if err != nil {
 		log.Fatal("dialing:", err)
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = rpcmeta.AppendToOutgoingContext(ctx, "trace-id", "trace-1234")
+
 	var reply string
-	err = client.Call("HelloService.Hello", "hello", &reply)
+	err = client.CallContext(ctx, "HelloService.Hello", HelloRequest{Value: "hello"}, &reply)
 	if err != nil {
 		log.Fatal(err)
 	}
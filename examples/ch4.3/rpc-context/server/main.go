@@ -4,35 +4,38 @@ import (
 	"log"
 	"net"
 	"net/rpc"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+	"gobook.examples/ch4-03-netrpc-hack/rpcmeta"
 )
 
 type HelloService struct {
 	conn net.Conn
 }
 
-func ServeHelloService(conn net.Conn) {
+type HelloRequest struct {
+	rpcmeta.Header
+	Value string
+}
+
+func ServeHelloService(conn net.Conn, codec rpc.ServerCodec) {
 	p := rpc.NewServer()
 	p.Register(&HelloService{conn: conn})
-	p.ServeConn(conn)
+	p.ServeCodec(codec)
 }
 
-func (p *HelloService) Hello(request string, reply *string) error {
-	*reply = "hello:" + request + ", from" + p.conn.RemoteAddr().String()
+func (p *HelloService) Hello(request HelloRequest, reply *string) error {
+	deadline, _ := request.Context().Deadline()
+	log.Printf("trace-id: %s, deadline: %v", request.Metadata()["trace-id"], deadline)
+
+	*reply = "hello:" + request.Value + ", from" + p.conn.RemoteAddr().String()
 	return nil
 }
 
 func main() {
-	listener, err := net.Listen("tcp", ":1234")
-	if err != nil {
-		log.Fatal("ListenTCP error:", err)
-	}
-
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Fatal("Accept error:", err)
-		}
-
-		go ServeHelloService(conn)
+	server := &rpcserver.Server{
+		NewCodec:   rpcmeta.NewServerCodec,
+		ServeCodec: ServeHelloService,
 	}
+	log.Fatal(server.ListenAndServe("tcp", ":1234"))
 }
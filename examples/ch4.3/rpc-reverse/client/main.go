@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"net"
-	"net/rpc"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpc-reverse/hub"
 )
 
 func main() {
@@ -13,27 +15,32 @@ func main() {
 		log.Fatal("ListenTCP error:", err)
 	}
 
-	clientChan := make(chan *rpc.Client)
-
+	h := hub.NewHub()
 	go func() {
-		for {
-			conn, err := listener.Accept()
-			if err != nil {
-				log.Fatal("Accept error:", err)
-			}
+		log.Fatal(h.Serve(listener))
+	}()
 
-			clientChan <- rpc.NewClient(conn)
+	for range time.Tick(time.Second * 5) {
+		nodes := h.Nodes()
+		for _, n := range nodes {
+			fmt.Printf("node %s %v from %s\n", n.ID, n.Capabilities, n.RemoteAddr)
+		}
+		if len(nodes) == 0 {
+			continue
 		}
-	}()
 
-	client := <-clientChan
-	defer client.Close()
+		var reply string
+		err := h.Call(nodes[0].ID, "HelloService.Hello", "node", &reply)
+		fmt.Println(reply, err)
 
-	var reply string
-	err = client.Call("HelloService.Hello", "hello", &reply)
-	if err != nil {
-		log.Fatal(err)
-	}
+		id, err := h.CallCapability("hello", "HelloService.Hello", "capability", &reply)
+		fmt.Println(id, reply, err)
 
-	fmt.Println(reply)
+		results := h.Broadcast("", "HelloService.Hello", "broadcast", func() interface{} {
+			return new(string)
+		})
+		for _, r := range results {
+			fmt.Println(r.NodeID, *r.Reply.(*string), r.Err)
+		}
+	}
 }
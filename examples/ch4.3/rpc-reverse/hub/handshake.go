@@ -0,0 +1,64 @@
+package hub
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// NodeInfo is sent by a node when it connects to the hub.
+type NodeInfo struct {
+	ID           string
+	Capabilities []string
+}
+
+func (p NodeInfo) HasCapability(capability string) bool {
+	for _, s := range p.Capabilities {
+		if s == capability {
+			return true
+		}
+	}
+	return false
+}
+
+type handshakeReply struct {
+	Error string
+
+	// HeartbeatInterval tells the node how often to expect the heartbeat
+	// calls of the hub.
+	HeartbeatInterval time.Duration
+}
+
+const maxHandshakeSize = 64 << 10
+
+// The handshake messages are length-prefixed JSON, so that nothing after
+// them is read ahead of the rpc codec.
+func writeHandshake(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	_, err = w.Write(buf)
+	return err
+}
+
+func readHandshake(r io.Reader, v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxHandshakeSize {
+		return errors.New("hub: handshake too large")
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,292 @@
+// Package hub is a reverse RPC hub. Nodes behind NAT dial out to the hub
+// and serve net/rpc on the connection, the hub keeps a registry of the
+// connected nodes and routes the calls of its users to them: to a node by
+// ID, to any node with a capability, or to all of them.
+package hub
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/rpc"
+	"sort"
+	"sync"
+	"time"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+)
+
+const (
+	DefaultHeartbeatInterval = 10 * time.Second
+	DefaultHeartbeatTimeout  = 5 * time.Second
+)
+
+// HeartbeatServiceName is the service every node registers for the
+// heartbeat calls of the hub.
+const HeartbeatServiceName = "ReverseNode"
+
+var (
+	ErrNoNode        = errors.New("hub: no such node")
+	ErrNoCapableNode = errors.New("hub: no node with the capability")
+	ErrBadNodeID     = errors.New("hub: empty node id")
+)
+
+// NodeStatus is a node of the registry.
+type NodeStatus struct {
+	NodeInfo
+	RemoteAddr    string
+	ConnectedAt   time.Time
+	LastHeartbeat time.Time
+}
+
+type node struct {
+	status NodeStatus
+	client *rpc.Client
+	done   chan struct{}
+}
+
+type Hub struct {
+	// HeartbeatInterval is the time between the heartbeat calls of a
+	// node, DefaultHeartbeatInterval if 0. A node is removed if a call
+	// takes longer than HeartbeatTimeout, DefaultHeartbeatTimeout if 0.
+	HeartbeatInterval time.Duration
+	HeartbeatTimeout  time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]*node
+	next  int // round robin of CallCapability
+}
+
+func NewHub() *Hub {
+	return &Hub{nodes: make(map[string]*node)}
+}
+
+func (h *Hub) heartbeatInterval() time.Duration {
+	if h.HeartbeatInterval > 0 {
+		return h.HeartbeatInterval
+	}
+	return DefaultHeartbeatInterval
+}
+
+func (h *Hub) heartbeatTimeout() time.Duration {
+	if h.HeartbeatTimeout > 0 {
+		return h.HeartbeatTimeout
+	}
+	return DefaultHeartbeatTimeout
+}
+
+// Serve accepts the connections of the nodes on l with the accept loop
+// of rpcserver, which retries the temporary errors, and serves each of
+// them with ServeConn. It always returns a non-nil error and closes l.
+func (h *Hub) Serve(l net.Listener) error {
+	srv := &rpcserver.Server{
+		// the hub is the client of the connections, it does not read
+		// requests from them
+		ServeCodec: func(conn net.Conn, _ rpc.ServerCodec) {
+			h.ServeConn(conn)
+		},
+	}
+	return srv.Serve(l)
+}
+
+// ServeConn registers the node connected on conn and keeps it in the
+// registry until its heartbeat fails or the connection is closed. A node
+// reconnecting with the same ID replaces the old connection.
+func (h *Hub) ServeConn(conn net.Conn) {
+	var info NodeInfo
+	conn.SetDeadline(time.Now().Add(h.heartbeatTimeout()))
+	if err := readHandshake(conn, &info); err != nil {
+		log.Println("hub: handshake:", err)
+		conn.Close()
+		return
+	}
+
+	reply := handshakeReply{HeartbeatInterval: h.heartbeatInterval()}
+	if info.ID == "" {
+		reply.Error = ErrBadNodeID.Error()
+	}
+	if err := writeHandshake(conn, &reply); err != nil || reply.Error != "" {
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+
+	done := make(chan struct{})
+	now := time.Now()
+	n := &node{
+		status: NodeStatus{
+			NodeInfo:      info,
+			RemoteAddr:    conn.RemoteAddr().String(),
+			ConnectedAt:   now,
+			LastHeartbeat: now,
+		},
+		client: rpc.NewClient(&notifyConn{Conn: conn, done: done}),
+		done:   done,
+	}
+	h.add(n)
+	defer h.remove(n)
+
+	h.heartbeat(n)
+}
+
+func (h *Hub) add(n *node) {
+	h.mu.Lock()
+	old := h.nodes[n.status.ID]
+	h.nodes[n.status.ID] = n
+	h.mu.Unlock()
+
+	if old != nil {
+		old.client.Close()
+	}
+	log.Printf("hub: node %s connected from %s", n.status.ID, n.status.RemoteAddr)
+}
+
+func (h *Hub) remove(n *node) {
+	h.mu.Lock()
+	if h.nodes[n.status.ID] == n {
+		delete(h.nodes, n.status.ID)
+	}
+	h.mu.Unlock()
+
+	n.client.Close()
+	log.Printf("hub: node %s disconnected", n.status.ID)
+}
+
+// heartbeat calls the node until a call fails or the connection is
+// closed.
+func (h *Hub) heartbeat(n *node) {
+	ticker := time.NewTicker(h.heartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-n.done:
+			return
+		}
+
+		var reply string
+		call := n.client.Go(HeartbeatServiceName+".Ping", "ping", &reply, make(chan *rpc.Call, 1))
+
+		select {
+		case call = <-call.Done:
+			if call.Error != nil {
+				return
+			}
+		case <-time.After(h.heartbeatTimeout()):
+			log.Printf("hub: node %s heartbeat timeout", n.status.ID)
+			return
+		}
+
+		h.mu.Lock()
+		n.status.LastHeartbeat = time.Now()
+		h.mu.Unlock()
+	}
+}
+
+// Nodes returns the connected nodes sorted by ID.
+func (h *Hub) Nodes() []NodeStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	list := make([]NodeStatus, 0, len(h.nodes))
+	for _, n := range h.nodes {
+		list = append(list, n.status)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// capableNodes returns the nodes with capability sorted by ID, all the
+// nodes if capability is empty.
+func (h *Hub) capableNodes(capability string) []*node {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var list []*node
+	for _, n := range h.nodes {
+		if capability == "" || n.status.HasCapability(capability) {
+			list = append(list, n)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].status.ID < list[j].status.ID })
+	return list
+}
+
+// Call calls serviceMethod on the node nodeID.
+func (h *Hub) Call(nodeID, serviceMethod string, args, reply interface{}) error {
+	h.mu.Lock()
+	n := h.nodes[nodeID]
+	h.mu.Unlock()
+
+	if n == nil {
+		return ErrNoNode
+	}
+	return n.client.Call(serviceMethod, args, reply)
+}
+
+// CallCapability calls serviceMethod on one of the nodes with capability,
+// taking them in turn. The next node is tried if the connection of one
+// fails. It returns the ID of the node which answered.
+func (h *Hub) CallCapability(capability, serviceMethod string, args, reply interface{}) (string, error) {
+	nodes := h.capableNodes(capability)
+	if len(nodes) == 0 {
+		return "", ErrNoCapableNode
+	}
+
+	h.mu.Lock()
+	start := h.next
+	h.next++
+	h.mu.Unlock()
+
+	var err error
+	for i := range nodes {
+		n := nodes[(start+i)%len(nodes)]
+		err = n.client.Call(serviceMethod, args, reply)
+		if err != rpc.ErrShutdown {
+			return n.status.ID, err
+		}
+	}
+	return "", err
+}
+
+// BroadcastResult is the answer of a node to Broadcast.
+type BroadcastResult struct {
+	NodeID string
+	Reply  interface{}
+	Err    error
+}
+
+// Broadcast calls serviceMethod on all the nodes with capability, or all
+// the nodes if capability is empty, at the same time. newReply returns the
+// reply value of a node. The results are sorted by node ID.
+func (h *Hub) Broadcast(capability, serviceMethod string, args interface{}, newReply func() interface{}) []BroadcastResult {
+	nodes := h.capableNodes(capability)
+	results := make([]BroadcastResult, len(nodes))
+	calls := make([]*rpc.Call, len(nodes))
+
+	for i, n := range nodes {
+		results[i] = BroadcastResult{NodeID: n.status.ID, Reply: newReply()}
+		calls[i] = n.client.Go(serviceMethod, args, results[i].Reply, make(chan *rpc.Call, 1))
+	}
+	for i, call := range calls {
+		results[i].Err = (<-call.Done).Error
+	}
+	return results
+}
+
+// notifyConn closes done when reading fails, so that a node is removed as
+// soon as its connection is lost.
+type notifyConn struct {
+	net.Conn
+	done chan struct{}
+	once sync.Once
+}
+
+func (c *notifyConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if err != nil {
+		c.once.Do(func() { close(c.done) })
+	}
+	return n, err
+}
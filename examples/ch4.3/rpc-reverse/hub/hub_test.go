@@ -0,0 +1,280 @@
+package hub
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+)
+
+type HelloService struct {
+	id string
+}
+
+func (p *HelloService) Hello(request string, reply *string) error {
+	*reply = "hello:" + request + ", from " + p.id
+	return nil
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+// connTracker remembers the accepted connections, so that a test can cut
+// them off.
+type connTracker struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *connTracker) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *connTracker) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
+func startHub(t *testing.T, h *Hub) *connTracker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := &connTracker{Listener: l}
+	t.Cleanup(func() { l.Close() })
+	go h.Serve(tracker)
+	return tracker
+}
+
+func startNode(t *testing.T, addr string, info NodeInfo) {
+	srv := rpc.NewServer()
+	srv.Register(&HelloService{id: info.ID})
+	node, err := NewNode(info, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.MinBackoff = 10 * time.Millisecond
+	node.MaxBackoff = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		node.Run(ctx, "tcp", addr)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestRouting(t *testing.T) {
+	h := NewHub()
+	l := startHub(t, h)
+
+	startNode(t, l.Addr().String(), NodeInfo{ID: "a", Capabilities: []string{"hello", "fs"}})
+	startNode(t, l.Addr().String(), NodeInfo{ID: "b", Capabilities: []string{"hello"}})
+	startNode(t, l.Addr().String(), NodeInfo{ID: "c", Capabilities: []string{"db"}})
+	waitFor(t, "nodes", func() bool { return len(h.Nodes()) == 3 })
+
+	nodes := h.Nodes()
+	if nodes[0].ID != "a" || !nodes[0].HasCapability("fs") || nodes[0].RemoteAddr == "" {
+		t.Fatalf("unexpected node: %+v", nodes[0])
+	}
+
+	var reply string
+	if err := h.Call("b", "HelloService.Hello", "hub", &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply != "hello:hub, from b" {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+	if err := h.Call("x", "HelloService.Hello", "hub", &reply); err != ErrNoNode {
+		t.Fatalf("expect = %v, got = %v", ErrNoNode, err)
+	}
+
+	id, err := h.CallCapability("fs", "HelloService.Hello", "hub", &reply)
+	if err != nil || id != "a" {
+		t.Fatalf("unexpected %q, %v", id, err)
+	}
+	if _, err := h.CallCapability("gpu", "HelloService.Hello", "hub", &reply); err != ErrNoCapableNode {
+		t.Fatalf("expect = %v, got = %v", ErrNoCapableNode, err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		id, err := h.CallCapability("hello", "HelloService.Hello", "hub", &reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[id] = true
+	}
+	if len(seen) != 2 || !seen["a"] || !seen["b"] {
+		t.Fatalf("expect calls on a and b, got %v", seen)
+	}
+
+	results := h.Broadcast("", "HelloService.Hello", "all", func() interface{} { return new(string) })
+	if len(results) != 3 {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		r := results[i]
+		if r.NodeID != id || r.Err != nil || *r.Reply.(*string) != "hello:all, from "+id {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+	}
+
+	results = h.Broadcast("hello", "HelloService.Hello", "some", func() interface{} { return new(string) })
+	if len(results) != 2 || results[0].NodeID != "a" || results[1].NodeID != "b" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	h := NewHub()
+	h.HeartbeatInterval = 20 * time.Millisecond
+	l := startHub(t, h)
+
+	startNode(t, l.Addr().String(), NodeInfo{ID: "a"})
+	startNode(t, l.Addr().String(), NodeInfo{ID: "b"})
+	waitFor(t, "nodes", func() bool { return len(h.Nodes()) == 2 })
+
+	connected := h.Nodes()[0].ConnectedAt
+	waitFor(t, "heartbeat", func() bool {
+		return h.Nodes()[0].LastHeartbeat.After(connected)
+	})
+
+	// cut off the nodes, they come back by themselves
+	l.closeConns()
+	waitFor(t, "reconnect", func() bool {
+		nodes := h.Nodes()
+		return len(nodes) == 2 && nodes[0].ConnectedAt.After(connected)
+	})
+
+	var reply string
+	if err := h.Call("a", "HelloService.Hello", "again", &reply); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHeartbeatTimeout(t *testing.T) {
+	h := NewHub()
+	h.HeartbeatInterval = 20 * time.Millisecond
+	h.HeartbeatTimeout = 20 * time.Millisecond
+	l := startHub(t, h)
+
+	// a node which never answers
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var reply handshakeReply
+	if err := writeHandshake(conn, &NodeInfo{ID: "silent"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := readHandshake(conn, &reply); err != nil || reply.Error != "" {
+		t.Fatalf("unexpected handshake: %v, %v", reply, err)
+	}
+	waitFor(t, "register", func() bool { return len(h.Nodes()) == 1 })
+	waitFor(t, "remove", func() bool { return len(h.Nodes()) == 0 })
+}
+
+func TestDuplicateNodeID(t *testing.T) {
+	h := NewHub()
+	l := startHub(t, h)
+
+	startNode(t, l.Addr().String(), NodeInfo{ID: "a", Capabilities: []string{"old"}})
+	waitFor(t, "old node", func() bool { return len(h.Nodes()) == 1 })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var reply handshakeReply
+	writeHandshake(conn, &NodeInfo{ID: "a", Capabilities: []string{"new"}})
+	readHandshake(conn, &reply)
+
+	waitFor(t, "new node", func() bool {
+		nodes := h.Nodes()
+		return len(nodes) == 1 && nodes[0].HasCapability("new")
+	})
+
+	conn2, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn2.Close()
+	writeHandshake(conn2, &NodeInfo{})
+	if err := readHandshake(conn2, &reply); err != nil || reply.Error != ErrBadNodeID.Error() {
+		t.Fatalf("unexpected handshake: %v, %v", reply, err)
+	}
+}
+
+// TestSilentHub checks that a node reconnects when the heartbeat of the
+// hub stops, although the connection is not closed.
+func TestSilentHub(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var info NodeInfo
+			readHandshake(conn, &info)
+			writeHandshake(conn, &handshakeReply{HeartbeatInterval: 20 * time.Millisecond})
+			accepted <- conn
+		}
+	}()
+
+	startNode(t, l.Addr().String(), NodeInfo{ID: "a"})
+	for i := 0; i < 2; i++ {
+		select {
+		case conn := <-accepted:
+			defer conn.Close()
+		case <-time.After(5 * time.Second):
+			t.Fatal("node does not reconnect")
+		}
+	}
+}
+
+func TestNewNode(t *testing.T) {
+	if _, err := NewNode(NodeInfo{}, rpc.NewServer()); err != ErrBadNodeID {
+		t.Fatalf("expect = %v, got = %v", ErrBadNodeID, err)
+	}
+	info := NodeInfo{ID: "a", Capabilities: []string{"x", "y"}}
+	if !info.HasCapability("y") || info.HasCapability("z") {
+		t.Fatal("unexpected HasCapability")
+	}
+}
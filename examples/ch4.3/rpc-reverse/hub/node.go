@@ -0,0 +1,139 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"net/rpc"
+	"time"
+)
+
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Node dials out to a hub and serves its rpc.Server on the connection,
+// reconnecting with exponential backoff when the connection is lost.
+type Node struct {
+	Info NodeInfo
+
+	// MinBackoff and MaxBackoff bound the delay before reconnecting,
+	// DefaultMinBackoff and DefaultMaxBackoff if 0. The delay doubles
+	// after each failure and is reset once the hub accepts the node.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	server *rpc.Server
+}
+
+// NewNode returns a node serving srv, on which the heartbeat service is
+// registered too.
+func NewNode(info NodeInfo, srv *rpc.Server) (*Node, error) {
+	if info.ID == "" {
+		return nil, ErrBadNodeID
+	}
+	if err := srv.RegisterName(HeartbeatServiceName, new(heartbeatService)); err != nil {
+		return nil, err
+	}
+	return &Node{Info: info, server: srv}, nil
+}
+
+type heartbeatService struct{}
+
+func (p *heartbeatService) Ping(request string, reply *string) error {
+	*reply = "pong"
+	return nil
+}
+
+// Run connects to the hub at address until ctx is done.
+func (p *Node) Run(ctx context.Context, network, address string) error {
+	var dialer net.Dialer
+	return p.RunDialer(ctx, func(ctx context.Context) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	})
+}
+
+// RunDialer is like Run but connects to the hub with dial.
+func (p *Node) RunDialer(ctx context.Context, dial func(ctx context.Context) (net.Conn, error)) error {
+	backoff := p.minBackoff()
+	for {
+		conn, err := dial(ctx)
+		if err == nil {
+			var accepted bool
+			accepted, err = p.serveConn(ctx, conn)
+			if accepted {
+				backoff = p.minBackoff()
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("node %s: %v, reconnect in %v", p.Info.ID, err, backoff)
+
+		// wait between half and all of backoff, so that the nodes
+		// cut off together do not come back together
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > p.maxBackoff() {
+			backoff = p.maxBackoff()
+		}
+	}
+}
+
+func (p *Node) minBackoff() time.Duration {
+	if p.MinBackoff > 0 {
+		return p.MinBackoff
+	}
+	return DefaultMinBackoff
+}
+
+func (p *Node) maxBackoff() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return DefaultMaxBackoff
+}
+
+// serveConn announces the node on conn and serves it until the
+// connection is lost, or the hub is silent for two heartbeat intervals.
+func (p *Node) serveConn(ctx context.Context, conn net.Conn) (accepted bool, err error) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+
+	var reply handshakeReply
+	conn.SetDeadline(time.Now().Add(DefaultHeartbeatTimeout))
+	if err := writeHandshake(conn, &p.Info); err != nil {
+		return false, err
+	}
+	if err := readHandshake(conn, &reply); err != nil {
+		return false, err
+	}
+	if reply.Error != "" {
+		return false, errors.New(reply.Error)
+	}
+	conn.SetDeadline(time.Time{})
+
+	p.server.ServeConn(&deadlineConn{Conn: conn, timeout: 2 * reply.HeartbeatInterval})
+	return true, errors.New("connection lost")
+}
+
+// deadlineConn fails reading if nothing arrives within timeout.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(p []byte) (int, error) {
+	if c.timeout > 0 {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	}
+	return c.Conn.Read(p)
+}
@@ -1,29 +1,43 @@
 package main
 
 import (
-	"net"
+	"context"
+	"flag"
+	"log"
 	"net/rpc"
-	"time"
+	"strings"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpc-reverse/hub"
 )
 
-type HelloService struct{}
+var (
+	flagID   = flag.String("id", "node-1", "node id")
+	flagCaps = flag.String("caps", "hello", "comma separated capabilities")
+	flagHub  = flag.String("hub", "localhost:1234", "hub address")
+)
+
+type HelloService struct {
+	id string
+}
 
 func (p *HelloService) Hello(request string, reply *string) error {
-	*reply = "hello:" + request
+	*reply = "hello:" + request + ", from " + p.id
 	return nil
 }
 
 func main() {
-	rpc.Register(new(HelloService))
+	flag.Parse()
 
-	for {
-		conn, _ := net.Dial("tcp", "localhost:1234")
-		if conn == nil {
-			time.Sleep(time.Second)
-			continue
-		}
+	srv := rpc.NewServer()
+	srv.Register(&HelloService{id: *flagID})
 
-		rpc.ServeConn(conn)
-		conn.Close()
+	node, err := hub.NewNode(hub.NodeInfo{
+		ID:           *flagID,
+		Capabilities: strings.Split(*flagCaps, ","),
+	}, srv)
+	if err != nil {
+		log.Fatal(err)
 	}
+
+	log.Fatal(node.Run(context.Background(), "tcp", *flagHub))
 }
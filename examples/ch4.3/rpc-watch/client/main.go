@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpc-watch/kvstore"
+)
+
+func main() {
+	client, err := kvstore.DialKVStoreService("tcp", "localhost:1234")
+	if err != nil {
+		log.Fatal("dialing:", err)
+	}
+
+	var rev int64
+	if err := client.Set([2]string{"abc", "abc-value"}, &rev); err != nil {
+		log.Fatal(err)
+	}
+
+	go watch(client, []string{"abc"}, rev)
+	time.Sleep(time.Second)
+
+	if err := client.Set([2]string{"abc", "abc-value-2"}, &rev); err != nil {
+		log.Fatal(err)
+	}
+	if err := client.Set([2]string{"xyz", "xyz-value"}, &rev); err != nil {
+		log.Fatal(err)
+	}
+
+	var entry kvstore.Entry
+	if err := client.Get("abc", &entry); err != nil {
+		log.Fatal(err)
+	}
+
+	err = client.CompareAndSet(kvstore.CompareAndSetArgs{
+		Key: "abc", Value: "stale", Revision: entry.Revision - 1,
+	}, &rev)
+	fmt.Println("cas:", err)
+
+	err = client.CompareAndSet(kvstore.CompareAndSetArgs{
+		Key: "abc", Value: "abc-value-3", Revision: entry.Revision,
+	}, &rev)
+	fmt.Println("cas:", err)
+
+	if err := client.Delete("abc", &rev); err != nil {
+		log.Fatal(err)
+	}
+
+	time.Sleep(time.Second * 3)
+}
+
+// watch prints the changes of the keys with one of prefixes after rev,
+// resuming from the last revision seen after each call.
+func watch(client *kvstore.KVStoreServiceClient, prefixes []string, rev int64) {
+	for {
+		var reply kvstore.WatchReply
+		err := client.Watch(kvstore.WatchArgs{
+			Prefixes:      prefixes,
+			Revision:      rev,
+			TimeoutSecond: 30,
+		}, &reply)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, e := range reply.Events {
+			if e.Deleted {
+				fmt.Printf("watch: %d delete %s\n", e.Revision, e.Key)
+			} else {
+				fmt.Printf("watch: %d set %s = %s\n", e.Revision, e.Key, e.Value)
+			}
+		}
+		rev = reply.Revision
+	}
+}
@@ -0,0 +1,89 @@
+package kvstore
+
+import (
+	"net/rpc"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+const KVStoreServiceName = "KVStoreService"
+
+type KVStoreServiceInterface = interface {
+	Get(key string, entry *Entry) error
+	Set(kv [2]string, rev *int64) error
+	Delete(key string, rev *int64) error
+	CompareAndSet(args CompareAndSetArgs, rev *int64) error
+	Watch(args WatchArgs, reply *WatchReply) error
+}
+
+var _ KVStoreServiceInterface = (*KVStoreService)(nil)
+
+// RegisterKVStoreService serves svc on srv, its errors are sent with
+// rpcerrors.ToRPC.
+func RegisterKVStoreService(srv *rpc.Server, svc KVStoreServiceInterface) error {
+	return srv.RegisterName(KVStoreServiceName, &kvstoreServer{svc})
+}
+
+type kvstoreServer struct {
+	svc KVStoreServiceInterface
+}
+
+func (p *kvstoreServer) Get(key string, entry *Entry) error {
+	return rpcerrors.ToRPC(p.svc.Get(key, entry))
+}
+
+func (p *kvstoreServer) Set(kv [2]string, rev *int64) error {
+	return rpcerrors.ToRPC(p.svc.Set(kv, rev))
+}
+
+func (p *kvstoreServer) Delete(key string, rev *int64) error {
+	return rpcerrors.ToRPC(p.svc.Delete(key, rev))
+}
+
+func (p *kvstoreServer) CompareAndSet(args CompareAndSetArgs, rev *int64) error {
+	return rpcerrors.ToRPC(p.svc.CompareAndSet(args, rev))
+}
+
+func (p *kvstoreServer) Watch(args WatchArgs, reply *WatchReply) error {
+	return rpcerrors.ToRPC(p.svc.Watch(args, reply))
+}
+
+type KVStoreServiceClient struct {
+	*rpc.Client
+}
+
+var _ KVStoreServiceInterface = (*KVStoreServiceClient)(nil)
+
+func DialKVStoreService(network, address string) (*KVStoreServiceClient, error) {
+	c, err := rpc.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &KVStoreServiceClient{Client: c}, nil
+}
+
+func (p *KVStoreServiceClient) Get(key string, entry *Entry) error {
+	return p.call("Get", key, entry)
+}
+
+func (p *KVStoreServiceClient) Set(kv [2]string, rev *int64) error {
+	return p.call("Set", kv, rev)
+}
+
+func (p *KVStoreServiceClient) Delete(key string, rev *int64) error {
+	return p.call("Delete", key, rev)
+}
+
+func (p *KVStoreServiceClient) CompareAndSet(args CompareAndSetArgs, rev *int64) error {
+	return p.call("CompareAndSet", args, rev)
+}
+
+func (p *KVStoreServiceClient) Watch(args WatchArgs, reply *WatchReply) error {
+	return p.call("Watch", args, reply)
+}
+
+// call returns the errors of the store as rpcerrors.Error values, which
+// match ErrNotFound, ErrConflict and ErrCompacted with errors.Is.
+func (p *KVStoreServiceClient) call(method string, args, reply interface{}) error {
+	return rpcerrors.FromRPC(p.Client.Call(KVStoreServiceName+"."+method, args, reply))
+}
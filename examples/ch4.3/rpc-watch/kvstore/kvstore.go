@@ -0,0 +1,263 @@
+// Package kvstore is an in-memory KV store served with net/rpc. Watch is
+// a long-polling call returning the changes of some key prefixes.
+//
+// Every change gets the next revision number. A watcher passes the last
+// revision it has seen, so it resumes without missing changes as long as
+// they are still in the history of the store.
+package kvstore
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+// DefaultHistorySize is the number of changes kept for the watchers.
+const DefaultHistorySize = 1024
+
+// The errors of the store, the client gets them back as they are.
+var (
+	ErrNotFound  = rpcerrors.NewWithCode(404, "kvstore: not found")
+	ErrConflict  = rpcerrors.NewWithCode(409, "kvstore: revision conflict")
+	ErrCompacted = rpcerrors.NewWithCode(410, "kvstore: revision compacted")
+)
+
+// Entry is a key and its value. Revision is the revision of the last
+// change of the key.
+type Entry struct {
+	Key      string
+	Value    string
+	Revision int64
+}
+
+// Event is a change of a key.
+type Event struct {
+	Key      string
+	Value    string
+	Revision int64
+	Deleted  bool
+}
+
+type CompareAndSetArgs struct {
+	Key   string
+	Value string
+
+	// Revision is the expected revision of the key, 0 if it must not exist.
+	Revision int64
+}
+
+type WatchArgs struct {
+	// Prefixes of the watched keys, all keys if empty.
+	Prefixes []string
+
+	// Revision is the last revision seen, the events after it are
+	// returned. If it is 0, only the changes after the call are.
+	Revision int64
+
+	TimeoutSecond int
+}
+
+type WatchReply struct {
+	Events []Event
+
+	// Revision is the revision to pass to the next Watch call.
+	Revision int64
+}
+
+type KVStoreService struct {
+	// HistorySize is the number of changes kept for the watchers,
+	// DefaultHistorySize if 0.
+	HistorySize int
+
+	mu      sync.Mutex
+	m       map[string]Entry
+	rev     int64
+	history []Event
+	filter  map[uint64]func()
+	nextID  uint64
+}
+
+func NewKVStoreService() *KVStoreService {
+	return &KVStoreService{
+		m:      make(map[string]Entry),
+		filter: make(map[uint64]func()),
+	}
+}
+
+func (p *KVStoreService) Get(key string, entry *Entry) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.m[key]; ok {
+		*entry = e
+		return nil
+	}
+	return ErrNotFound
+}
+
+// Set sets kv[0] to kv[1] and returns the new revision of the store.
+func (p *KVStoreService) Set(kv [2]string, rev *int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, value := kv[0], kv[1]
+	if e, ok := p.m[key]; ok && e.Value == value {
+		*rev = p.rev
+		return nil
+	}
+
+	*rev = p.change(Event{Key: key, Value: value})
+	return nil
+}
+
+// Delete removes key and returns the new revision of the store.
+func (p *KVStoreService) Delete(key string, rev *int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.m[key]; !ok {
+		return ErrNotFound
+	}
+
+	*rev = p.change(Event{Key: key, Deleted: true})
+	return nil
+}
+
+// CompareAndSet sets the key if its revision is still args.Revision.
+func (p *KVStoreService) CompareAndSet(args CompareAndSetArgs, rev *int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.m[args.Key].Revision != args.Revision {
+		return ErrConflict
+	}
+
+	*rev = p.change(Event{Key: args.Key, Value: args.Value})
+	return nil
+}
+
+// change applies e and wakes up the watchers. p.mu must be held.
+func (p *KVStoreService) change(e Event) int64 {
+	p.rev++
+	e.Revision = p.rev
+
+	if e.Deleted {
+		delete(p.m, e.Key)
+	} else {
+		p.m[e.Key] = Entry{Key: e.Key, Value: e.Value, Revision: e.Revision}
+	}
+
+	size := p.HistorySize
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	p.history = append(p.history, e)
+	if len(p.history) > size {
+		p.history = append(p.history[:0:0], p.history[len(p.history)-size:]...)
+	}
+
+	for _, fn := range p.filter {
+		fn()
+	}
+	return p.rev
+}
+
+// Watch waits for the changes of the keys with one of args.Prefixes after
+// args.Revision. It returns no events if none happen before the timeout,
+// reply.Revision is still the one to resume from then.
+func (p *KVStoreService) Watch(args WatchArgs, reply *WatchReply) error {
+	ch := make(chan struct{}, 1)
+
+	p.mu.Lock()
+	rev := args.Revision
+	if rev <= 0 {
+		rev = p.rev
+	}
+	events, err := p.eventsAfter(rev, args.Prefixes)
+	if err != nil || len(events) > 0 {
+		p.mu.Unlock()
+		reply.Events, reply.Revision = events, lastRevision(events, rev)
+		return err
+	}
+	rev = p.rev
+
+	p.nextID++
+	id := p.nextID
+	p.filter[id] = func() {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.filter, id)
+		p.mu.Unlock()
+	}()
+
+	timeout := time.NewTimer(time.Duration(args.TimeoutSecond) * time.Second)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-timeout.C:
+			reply.Revision = rev
+			return nil
+		case <-ch:
+		}
+
+		p.mu.Lock()
+		events, err := p.eventsAfter(rev, args.Prefixes)
+		if err == nil && len(events) == 0 {
+			rev = p.rev
+		}
+		p.mu.Unlock()
+
+		if err != nil || len(events) > 0 {
+			reply.Events, reply.Revision = events, lastRevision(events, rev)
+			return err
+		}
+	}
+}
+
+// eventsAfter returns the events after rev matching prefixes. p.mu must
+// be held.
+func (p *KVStoreService) eventsAfter(rev int64, prefixes []string) ([]Event, error) {
+	if rev >= p.rev {
+		return nil, nil
+	}
+	if len(p.history) == 0 || p.history[0].Revision > rev+1 {
+		return nil, ErrCompacted
+	}
+
+	var events []Event
+	for _, e := range p.history[rev+1-p.history[0].Revision:] {
+		if hasPrefix(e.Key, prefixes) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func hasPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, s := range prefixes {
+		if strings.HasPrefix(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastRevision(events []Event, rev int64) int64 {
+	if len(events) > 0 {
+		return events[len(events)-1].Revision
+	}
+	return rev
+}
@@ -0,0 +1,229 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+func newPipeClient(t *testing.T, svc *KVStoreService) *KVStoreServiceClient {
+	server := rpc.NewServer()
+	if err := RegisterKVStoreService(server, svc); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, serverConn := net.Pipe()
+	go server.ServeConn(serverConn)
+
+	client := &KVStoreServiceClient{Client: rpc.NewClient(conn)}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func waitNoWatchers(t *testing.T, svc *KVStoreService) {
+	for i := 0; i < 100; i++ {
+		svc.mu.Lock()
+		n := len(svc.filter)
+		svc.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("watchers are not removed")
+}
+
+func TestKVStore(t *testing.T) {
+	svc := NewKVStoreService()
+	client := newPipeClient(t, svc)
+
+	var rev int64
+	if err := client.Set([2]string{"abc", "1"}, &rev); err != nil || rev != 1 {
+		t.Fatalf("Set: %d, %v", rev, err)
+	}
+	if err := client.Set([2]string{"abc", "1"}, &rev); err != nil || rev != 1 {
+		t.Fatalf("Set same value: %d, %v", rev, err)
+	}
+
+	var entry Entry
+	if err := client.Get("abc", &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry != (Entry{Key: "abc", Value: "1", Revision: 1}) {
+		t.Fatalf("unexpected entry: %v", entry)
+	}
+	err := client.Get("xyz", &entry)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect = %v, got = %v", ErrNotFound, err)
+	}
+	if e, ok := err.(rpcerrors.Error); !ok || e.Code() != 404 {
+		t.Fatalf("expect rpcerrors.Error with code 404, got %T %v", err, err)
+	}
+
+	err = client.CompareAndSet(CompareAndSetArgs{Key: "abc", Value: "2"}, &rev)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expect = %v, got = %v", ErrConflict, err)
+	}
+	err = client.CompareAndSet(CompareAndSetArgs{Key: "abc", Value: "2", Revision: 1}, &rev)
+	if err != nil || rev != 2 {
+		t.Fatalf("CompareAndSet: %d, %v", rev, err)
+	}
+	err = client.CompareAndSet(CompareAndSetArgs{Key: "xyz", Value: "1"}, &rev)
+	if err != nil || rev != 3 {
+		t.Fatalf("CompareAndSet new key: %d, %v", rev, err)
+	}
+
+	if err := client.Delete("abc", &rev); err != nil || rev != 4 {
+		t.Fatalf("Delete: %d, %v", rev, err)
+	}
+	if err := client.Delete("abc", &rev); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect = %v, got = %v", ErrNotFound, err)
+	}
+
+	var reply WatchReply
+	if err := client.Watch(WatchArgs{Prefixes: []string{"abc"}, Revision: 1}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	expect := []Event{
+		{Key: "abc", Value: "2", Revision: 2},
+		{Key: "abc", Revision: 4, Deleted: true},
+	}
+	if fmt.Sprint(reply.Events) != fmt.Sprint(expect) || reply.Revision != 4 {
+		t.Fatalf("unexpected watch reply: %v", reply)
+	}
+}
+
+func TestWatchTimeout(t *testing.T) {
+	svc := NewKVStoreService()
+	client := newPipeClient(t, svc)
+
+	var rev int64
+	client.Set([2]string{"abc", "1"}, &rev)
+
+	done := make(chan WatchReply)
+	go func() {
+		var reply WatchReply
+		if err := client.Watch(WatchArgs{Prefixes: []string{"abc"}, TimeoutSecond: 1}, &reply); err != nil {
+			t.Error(err)
+		}
+		done <- reply
+	}()
+
+	// changes of other keys do not end the watch, but move its revision
+	time.Sleep(100 * time.Millisecond)
+	client.Set([2]string{"xyz", "1"}, &rev)
+
+	reply := <-done
+	if len(reply.Events) != 0 || reply.Revision != 2 {
+		t.Fatalf("unexpected watch reply: %v", reply)
+	}
+	waitNoWatchers(t, svc)
+}
+
+func TestWatchCompacted(t *testing.T) {
+	svc := NewKVStoreService()
+	svc.HistorySize = 2
+	client := newPipeClient(t, svc)
+
+	var rev int64
+	for i := 0; i < 4; i++ {
+		client.Set([2]string{"abc", fmt.Sprint(i)}, &rev)
+	}
+
+	var reply WatchReply
+	if err := client.Watch(WatchArgs{Revision: 1}, &reply); !errors.Is(err, ErrCompacted) {
+		t.Fatalf("expect = %v, got = %v", ErrCompacted, err)
+	}
+	if err := client.Watch(WatchArgs{Revision: 2}, &reply); err != nil || len(reply.Events) != 2 {
+		t.Fatalf("unexpected watch reply: %v, %v", reply, err)
+	}
+}
+
+// TestConcurrentWatchers runs watchers of different prefixes on their own
+// connections, resuming from the returned revisions, while several
+// writers change the keys. Each watcher must see all the changes of its
+// prefix exactly once and in order.
+func TestConcurrentWatchers(t *testing.T) {
+	const (
+		numWriters = 4
+		numChanges = 50
+	)
+
+	svc := NewKVStoreService()
+	prefixes := []string{"a/", "b/", ""}
+
+	var rev int64
+	newPipeClient(t, svc).Set([2]string{"init", "0"}, &rev)
+
+	results := make([][]Event, len(prefixes))
+	var watchers sync.WaitGroup
+	for i, prefix := range prefixes {
+		watchers.Add(1)
+		go func(i int, prefix string) {
+			defer watchers.Done()
+			client := newPipeClient(t, svc)
+
+			want := numWriters * numChanges
+			if prefix == "" {
+				want *= 2
+			}
+			rev := rev
+			for len(results[i]) < want {
+				var reply WatchReply
+				err := client.Watch(WatchArgs{
+					Prefixes:      []string{prefix},
+					Revision:      rev,
+					TimeoutSecond: 5,
+				}, &reply)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(reply.Events) == 0 {
+					t.Errorf("watch %q timeout after %d events", prefix, len(results[i]))
+					return
+				}
+				results[i] = append(results[i], reply.Events...)
+				rev = reply.Revision
+			}
+		}(i, prefix)
+	}
+
+	var writers sync.WaitGroup
+	for w := 0; w < numWriters; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			client := newPipeClient(t, svc)
+			for i := 0; i < numChanges; i++ {
+				var rev int64
+				for _, prefix := range []string{"a/", "b/"} {
+					key := fmt.Sprintf("%s%d", prefix, w)
+					if err := client.Set([2]string{key, fmt.Sprint(i)}, &rev); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(w)
+	}
+
+	writers.Wait()
+	watchers.Wait()
+
+	for i, prefix := range prefixes {
+		var last int64
+		for _, e := range results[i] {
+			if e.Revision <= last || !hasPrefix(e.Key, []string{prefix}) {
+				t.Fatalf("watch %q: unexpected event %v after revision %d", prefix, e, last)
+			}
+			last = e.Revision
+		}
+	}
+	waitNoWatchers(t, svc)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+	"net/rpc"
+	"time"
+
+	"gobook.examples/ch4-01-rpc-intro/rpcserver"
+	"gobook.examples/ch4-03-netrpc-hack/rpc-watch/kvstore"
+)
+
+func main() {
+	server := rpc.NewServer()
+	if err := kvstore.RegisterKVStoreService(server, kvstore.NewKVStoreService()); err != nil {
+		log.Fatal(err)
+	}
+
+	// the Watch calls in flight keep a connection open past IdleTimeout
+	s := &rpcserver.Server{
+		Server:      server,
+		IdleTimeout: 5 * time.Minute,
+		ReadTimeout: 10 * time.Second,
+	}
+	log.Fatal(s.ListenAndServe("tcp", ":1234"))
+}
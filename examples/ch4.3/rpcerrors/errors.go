@@ -0,0 +1,311 @@
+// Package rpcerrors carries typed errors through net/rpc calls.
+//
+// net/rpc only sends the text of an error, so the client gets a bare
+// rpc.ServerError. An Error has a code, details, a retryable flag, the
+// callers where it was made and the errors it wraps. ToRPC encodes it as
+// JSON in the error text on the server, which survives the gob and JSON
+// codecs, and FromRPC decodes it on the client, so that errors.Is and
+// errors.As work on both sides. The callers are not sent, they only
+// describe the code of the server:
+//
+//	var ErrNotFound = rpcerrors.NewWithCode(404, "not found")
+//
+//	// server
+//	return rpcerrors.ToRPC(rpcerrors.Wrap(ErrNotFound, "get abc"))
+//
+//	// client
+//	err := rpcerrors.FromRPC(client.Call("KV.Get", "abc", &v))
+//	if errors.Is(err, ErrNotFound) { ... }
+package rpcerrors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/rpc"
+	"runtime"
+	"strings"
+)
+
+// Error is an error with a code and the callers where it was made. Two
+// Errors are the same for errors.Is if they have the same code and
+// message, so that sentinel errors still match after a round trip.
+type Error interface {
+	Caller() []CallerInfo
+	Wraped() []error
+	Code() int
+	Details() map[string]string
+	Retryable() bool
+	error
+
+	private()
+}
+
+type CallerInfo struct {
+	FuncName string
+	FileName string
+	FileLine int
+}
+
+type _Error struct {
+	code      int
+	message   string
+	details   map[string]string
+	retryable bool
+	caller    []CallerInfo
+	wraped    error
+
+	// opaque is set for the errors which were not Errors before
+	// encoding, their message already holds the wrapped ones.
+	opaque bool
+}
+
+func (p *_Error) private() {}
+
+func New(msg string) error {
+	return &_Error{message: msg, caller: callers()}
+}
+
+func NewWithCode(code int, msg string) error {
+	return &_Error{code: code, message: msg, caller: callers()}
+}
+
+func Wrap(err error, msg string) error {
+	return &_Error{message: msg, caller: callers(), wraped: err}
+}
+
+func WrapWithCode(code int, err error, msg string) error {
+	return &_Error{code: code, message: msg, caller: callers(), wraped: err}
+}
+
+// WithDetails returns err with details added.
+func WithDetails(err error, details map[string]string) error {
+	p := &_Error{caller: callers(), wraped: err, details: make(map[string]string)}
+	for k, v := range details {
+		p.details[k] = v
+	}
+	return p
+}
+
+// WithRetryable returns err marked as worth retrying, such as a timeout.
+func WithRetryable(err error) error {
+	return &_Error{caller: callers(), wraped: err, retryable: true}
+}
+
+func callers() []CallerInfo {
+	var pc [32]uintptr
+	n := runtime.Callers(3, pc[:])
+	frames := runtime.CallersFrames(pc[:n])
+
+	var list []CallerInfo
+	for {
+		f, more := frames.Next()
+		list = append(list, CallerInfo{
+			FuncName: f.Function,
+			FileName: f.File,
+			FileLine: f.Line,
+		})
+		if !more {
+			return list
+		}
+	}
+}
+
+func (p *_Error) Error() string {
+	switch {
+	case p.wraped == nil || p.opaque:
+		return p.message
+	case p.message == "":
+		return p.wraped.Error()
+	}
+	return p.message + ": " + p.wraped.Error()
+}
+
+// Code returns the code of the error, or of the first wrapped Error which
+// has one.
+func (p *_Error) Code() int {
+	for _, e := range p.chain() {
+		if e.code != 0 {
+			return e.code
+		}
+	}
+	return 0
+}
+
+// Details returns the details of the error and of the wrapped Errors, the
+// outer ones win.
+func (p *_Error) Details() map[string]string {
+	m := make(map[string]string)
+	chain := p.chain()
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].details {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Retryable reports whether the error or one of the wrapped Errors is
+// marked retryable.
+func (p *_Error) Retryable() bool {
+	for _, e := range p.chain() {
+		if e.retryable {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *_Error) Caller() []CallerInfo {
+	return p.caller
+}
+
+// Wraped returns the wrapped errors, from the outer to the original one.
+func (p *_Error) Wraped() []error {
+	var list []error
+	for err := p.wraped; err != nil; err = errors.Unwrap(err) {
+		list = append(list, err)
+	}
+	return list
+}
+
+func (p *_Error) Unwrap() error {
+	return p.wraped
+}
+
+func (p *_Error) Is(target error) bool {
+	t, ok := target.(*_Error)
+	return ok && t.code == p.code && t.message == p.message && (t.message != "" || t.code != 0)
+}
+
+// chain returns p and the Errors it wraps.
+func (p *_Error) chain() []*_Error {
+	var list []*_Error
+	for err := error(p); err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*_Error); ok {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+type jsonError struct {
+	Code      int               `json:"code,omitempty"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details,omitempty"`
+	Retryable bool              `json:"retryable,omitempty"`
+	Caller    []CallerInfo      `json:"caller,omitempty"`
+	Opaque    bool              `json:"opaque,omitempty"`
+	Wraped    *jsonError        `json:"wraped,omitempty"`
+}
+
+// toJSONError encodes err, with the callers if withCaller is set.
+func toJSONError(err error, withCaller bool) *jsonError {
+	if err == nil {
+		return nil
+	}
+	p, ok := err.(*_Error)
+	if !ok {
+		return &jsonError{
+			Message: err.Error(),
+			Opaque:  true,
+			Wraped:  toJSONError(errors.Unwrap(err), withCaller),
+		}
+	}
+	x := &jsonError{
+		Code:      p.code,
+		Message:   p.message,
+		Details:   p.details,
+		Retryable: p.retryable,
+		Opaque:    p.opaque,
+		Wraped:    toJSONError(p.wraped, withCaller),
+	}
+	if withCaller {
+		x.Caller = p.caller
+	}
+	return x
+}
+
+func fromJSONError(x *jsonError) *_Error {
+	p := &_Error{
+		code:      x.Code,
+		message:   x.Message,
+		details:   x.Details,
+		retryable: x.Retryable,
+		caller:    x.Caller,
+		opaque:    x.Opaque,
+	}
+	if x.Wraped != nil {
+		p.wraped = fromJSONError(x.Wraped)
+	}
+	return p
+}
+
+// ToJson encodes err and the errors it wraps as JSON, with their callers,
+// such as for a log.
+func ToJson(err error) string {
+	data, _ := json.Marshal(toJSONError(err, true))
+	return string(data)
+}
+
+// FromJson decodes an error encoded by ToJson. The errors which were not
+// Errors come back as Errors with their text.
+func FromJson(s string) (Error, error) {
+	var x jsonError
+	if err := json.Unmarshal([]byte(s), &x); err != nil {
+		return nil, err
+	}
+	return fromJSONError(&x), nil
+}
+
+// rpcPrefix marks the error texts made by ToRPC.
+const rpcPrefix = "rpcerrors:"
+
+type rpcError struct {
+	err error
+}
+
+func (e *rpcError) Error() string {
+	data, _ := json.Marshal(toJSONError(e.err, false))
+	return rpcPrefix + string(data)
+}
+
+func (e *rpcError) Unwrap() error { return e.err }
+
+// ToRPC returns err in the form returned by a service method, so that
+// FromRPC can decode it. The callers are not sent. Errors which are not,
+// and do not wrap, an Error are returned as they are.
+func ToRPC(err error) error {
+	var e Error
+	if err == nil || !errors.As(err, &e) {
+		return err
+	}
+	return &rpcError{err}
+}
+
+// FromRPC returns the Error encoded by ToRPC in the rpc.ServerError err,
+// or err itself.
+func FromRPC(err error) error {
+	e, ok := err.(rpc.ServerError)
+	if !ok || !strings.HasPrefix(string(e), rpcPrefix) {
+		return err
+	}
+	p, jsonErr := FromJson(strings.TrimPrefix(string(e), rpcPrefix))
+	if jsonErr != nil {
+		return err
+	}
+	return p
+}
+
+// Client is a net/rpc client whose calls return the Errors sent by the
+// server.
+type Client struct {
+	*rpc.Client
+}
+
+func NewClient(c *rpc.Client) *Client {
+	return &Client{Client: c}
+}
+
+func (p *Client) Call(serviceMethod string, args, reply interface{}) error {
+	return FromRPC(p.Client.Call(serviceMethod, args, reply))
+}
@@ -0,0 +1,136 @@
+package rpcerrors
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+	"testing"
+)
+
+var (
+	ErrNotFound = NewWithCode(404, "not found")
+	ErrBusy     = NewWithCode(503, "busy")
+	errPlain    = errors.New("plain error")
+)
+
+type KVService struct{}
+
+func (p *KVService) Get(key string, value *string) error {
+	switch key {
+	case "missing":
+		return ToRPC(WithDetails(Wrap(ErrNotFound, "get "+key), map[string]string{"key": key}))
+	case "busy":
+		return ToRPC(WithRetryable(fmt.Errorf("shard 1: %w", ErrBusy)))
+	case "plain":
+		return ToRPC(errPlain)
+	}
+	*value = key
+	return nil
+}
+
+func TestError(t *testing.T) {
+	err := WrapWithCode(500, Wrap(ErrNotFound, "read"), "load")
+	if err.Error() != "load: read: not found" {
+		t.Fatalf("unexpected text: %q", err)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrBusy) {
+		t.Fatal("unexpected errors.Is")
+	}
+
+	var e Error
+	if !errors.As(err, &e) || e.Code() != 500 {
+		t.Fatalf("unexpected error: %v", e)
+	}
+	if n := len(e.Wraped()); n != 2 {
+		t.Fatalf("expect 2 wrapped errors, got %d", n)
+	}
+	if caller := e.Caller(); len(caller) == 0 || !strings.HasSuffix(caller[0].FuncName, "TestError") {
+		t.Fatalf("unexpected caller: %v", caller)
+	}
+	if Wrap(ErrNotFound, "read").(Error).Code() != 404 {
+		t.Fatal("expect the code of the wrapped error")
+	}
+
+	err = WithRetryable(WithDetails(ErrBusy, map[string]string{"shard": "1"}))
+	if e := err.(Error); !e.Retryable() || e.Details()["shard"] != "1" || e.Code() != 503 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err.Error() != "busy" || !errors.Is(err, ErrBusy) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestJson(t *testing.T) {
+	err := WithDetails(Wrap(fmt.Errorf("open: %w", ErrNotFound), "load"), map[string]string{"a": "b"})
+
+	p, jsonErr := FromJson(ToJson(err))
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	if p.Error() != err.Error() {
+		t.Fatalf("expect = %q, got = %q", err, p)
+	}
+	if !errors.Is(p, ErrNotFound) || p.Code() != 404 || p.Details()["a"] != "b" {
+		t.Fatalf("unexpected error: %v", p)
+	}
+	if len(p.Caller()) == 0 || len(p.Wraped()) != 3 {
+		t.Fatalf("unexpected error: %v", p)
+	}
+
+	if _, err := FromJson("{"); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestRPC(t *testing.T) {
+	for _, codec := range []string{"gob", "json"} {
+		srv := rpc.NewServer()
+		srv.Register(new(KVService))
+
+		conn, serverConn := net.Pipe()
+		var client *Client
+		if codec == "gob" {
+			go srv.ServeConn(serverConn)
+			client = NewClient(rpc.NewClient(conn))
+		} else {
+			go srv.ServeCodec(jsonrpc.NewServerCodec(serverConn))
+			client = NewClient(jsonrpc.NewClient(conn))
+		}
+		defer client.Close()
+
+		var value string
+		if err := client.Call("KVService.Get", "abc", &value); err != nil || value != "abc" {
+			t.Fatalf("%s: unexpected %q, %v", codec, value, err)
+		}
+
+		err := client.Call("KVService.Get", "missing", &value)
+		var e Error
+		if !errors.As(err, &e) {
+			t.Fatalf("%s: expect Error, got %T %v", codec, err, err)
+		}
+		if !errors.Is(err, ErrNotFound) || e.Code() != 404 || e.Details()["key"] != "missing" || e.Retryable() {
+			t.Fatalf("%s: unexpected error: %v", codec, err)
+		}
+		if err.Error() != "get missing: not found" {
+			t.Fatalf("%s: unexpected text: %q", codec, err)
+		}
+		for _, w := range append([]error{err}, e.Wraped()...) {
+			if e, ok := w.(Error); ok && len(e.Caller()) != 0 {
+				t.Fatalf("%s: expect no callers from the server, got = %v", codec, e.Caller())
+			}
+		}
+
+		err = client.Call("KVService.Get", "busy", &value)
+		if !errors.Is(err, ErrBusy) || !err.(Error).Retryable() || err.Error() != "shard 1: busy" {
+			t.Fatalf("%s: unexpected error: %v", codec, err)
+		}
+
+		err = client.Call("KVService.Get", "plain", &value)
+		if _, ok := err.(rpc.ServerError); !ok || err.Error() != "plain error" {
+			t.Fatalf("%s: unexpected error: %T %v", codec, err, err)
+		}
+	}
+}
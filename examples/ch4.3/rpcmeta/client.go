@@ -0,0 +1,115 @@
+package rpcmeta
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+// outgoing is the body of the requests of Client.CallContext, the client
+// codec takes the metadata from ctx.
+type outgoing struct {
+	ctx  context.Context
+	args interface{}
+}
+
+type clientCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+}
+
+// NewClientCodec returns a gob codec sending metadata and deadlines to a
+// server using NewServerCodec.
+func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	buf := bufio.NewWriter(conn)
+	return &clientCodec{
+		rwc:    conn,
+		dec:    gob.NewDecoder(conn),
+		enc:    gob.NewEncoder(buf),
+		encBuf: buf,
+	}
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, body interface{}) (err error) {
+	var meta requestMeta
+	if x, ok := body.(*outgoing); ok {
+		meta.Metadata, _ = FromOutgoingContext(x.ctx)
+		if deadline, ok := x.ctx.Deadline(); ok {
+			if meta.Timeout = time.Until(deadline); meta.Timeout == 0 {
+				meta.Timeout = -1
+			}
+		}
+		body = x.args
+	}
+
+	if err = c.enc.Encode(r); err != nil {
+		return
+	}
+	if err = c.enc.Encode(&meta); err != nil {
+		return
+	}
+	if err = c.enc.Encode(body); err != nil {
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	return c.dec.Decode(r)
+}
+
+func (c *clientCodec) ReadResponseBody(body interface{}) error {
+	return c.dec.Decode(body)
+}
+
+func (c *clientCodec) Close() error {
+	return c.rwc.Close()
+}
+
+type Client struct {
+	*rpc.Client
+}
+
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{Client: rpc.NewClientWithCodec(NewClientCodec(conn))}
+}
+
+func Dial(network, address string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// CallContext calls serviceMethod with the metadata and the deadline of
+// ctx. It gives up when ctx is done, the server gives up at the deadline
+// too; either way the error is ErrDeadlineExceeded, or ErrCanceled if ctx
+// is canceled. The errors sent with rpcerrors.ToRPC are decoded.
+func (p *Client) CallContext(ctx context.Context, serviceMethod string, args, reply interface{}) error {
+	if ctx.Err() != nil {
+		return contextError(ctx.Err())
+	}
+
+	call := p.Client.Go(serviceMethod, &outgoing{ctx: ctx, args: args}, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return contextError(ctx.Err())
+	case call = <-call.Done:
+		err := rpcerrors.FromRPC(call.Error)
+		if errors.Is(err, ErrDeadlineExceeded) {
+			// the server gave up first
+			return contextError(context.DeadlineExceeded)
+		}
+		return err
+	}
+}
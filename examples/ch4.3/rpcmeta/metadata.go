@@ -0,0 +1,122 @@
+// Package rpcmeta carries metadata, such as trace IDs and auth tokens,
+// and deadlines with net/rpc requests.
+//
+// The client codec sends the metadata and the deadline of the context
+// given to Client.CallContext before each request body. The server codec
+// gives them to the service methods whose argument embeds Header, and
+// answers with ErrDeadlineExceeded when the deadline passes before the
+// method returns:
+//
+//	type HelloRequest struct {
+//		rpcmeta.Header
+//		Name string
+//	}
+//
+//	func (p *HelloService) Hello(req HelloRequest, reply *string) error {
+//		md, _ := rpcmeta.FromIncomingContext(req.Context())
+//		log.Println("trace-id:", md["trace-id"])
+//		...
+//	}
+package rpcmeta
+
+import (
+	"context"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+var (
+	// ErrDeadlineExceeded is returned when the deadline of a call passes
+	// before the server answers it, be it found by the client or by the
+	// server.
+	ErrDeadlineExceeded = rpcerrors.NewWithCode(504, "rpcmeta: deadline exceeded")
+
+	// ErrCanceled is returned when the context of a call is canceled
+	// before the server answers it.
+	ErrCanceled = rpcerrors.NewWithCode(499, "rpcmeta: canceled")
+)
+
+// contextError returns the error of a call ended by err, context.Canceled
+// or context.DeadlineExceeded, which it wraps for errors.Is.
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return rpcerrors.WrapWithCode(504, err, "rpcmeta: deadline exceeded")
+	}
+	return rpcerrors.WrapWithCode(499, err, "rpcmeta: canceled")
+}
+
+// MD is the metadata of a request.
+type MD map[string]string
+
+func (md MD) Copy() MD {
+	m := make(MD, len(md))
+	for k, v := range md {
+		m[k] = v
+	}
+	return m
+}
+
+type outgoingKey struct{}
+type incomingKey struct{}
+
+// NewOutgoingContext returns ctx with md to be sent by Client.CallContext.
+func NewOutgoingContext(ctx context.Context, md MD) context.Context {
+	return context.WithValue(ctx, outgoingKey{}, md)
+}
+
+// AppendToOutgoingContext returns ctx with k and v added to its outgoing
+// metadata.
+func AppendToOutgoingContext(ctx context.Context, k, v string) context.Context {
+	md, _ := FromOutgoingContext(ctx)
+	md = md.Copy()
+	md[k] = v
+	return NewOutgoingContext(ctx, md)
+}
+
+func FromOutgoingContext(ctx context.Context) (MD, bool) {
+	md, ok := ctx.Value(outgoingKey{}).(MD)
+	return md, ok
+}
+
+// FromIncomingContext returns the metadata sent with the request whose
+// Header.Context is ctx.
+func FromIncomingContext(ctx context.Context) (MD, bool) {
+	md, ok := ctx.Value(incomingKey{}).(MD)
+	return md, ok
+}
+
+// Header is embedded in the argument of a service method to get the
+// context of the request. It is set by the server codec, and not sent as
+// gob skips the fields of func types.
+type Header func() context.Context
+
+// Context returns the context of the request. It carries the metadata and
+// is done when the deadline of the call passes.
+func (h Header) Context() context.Context {
+	if h == nil {
+		return context.Background()
+	}
+	return h()
+}
+
+// Metadata returns the metadata of the request.
+func (h Header) Metadata() MD {
+	md, _ := FromIncomingContext(h.Context())
+	return md
+}
+
+func (h *Header) setContext(ctx context.Context) {
+	*h = func() context.Context { return ctx }
+}
+
+// requestMeta is sent between the rpc.Request and the body.
+type requestMeta struct {
+	Metadata MD
+
+	// Timeout is the time left before the deadline when the request was
+	// sent, 0 for no deadline and negative if it has passed already. The
+	// server counts it from the reception, so the clocks of the client
+	// and the server need not agree.
+	Timeout time.Duration
+}
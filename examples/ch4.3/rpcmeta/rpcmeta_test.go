@@ -0,0 +1,179 @@
+package rpcmeta
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"net"
+	"net/rpc"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+type HelloRequest struct {
+	Header
+	Name string
+}
+
+type HelloService struct {
+	calls int32
+}
+
+func (p *HelloService) Hello(req HelloRequest, reply *string) error {
+	atomic.AddInt32(&p.calls, 1)
+	*reply = "hello:" + req.Name + ", trace-id:" + req.Metadata()["trace-id"]
+	return nil
+}
+
+// Slow waits until its context is done, or one second without deadline.
+func (p *HelloService) Slow(req *HelloRequest, reply *string) error {
+	atomic.AddInt32(&p.calls, 1)
+	select {
+	case <-req.Context().Done():
+		*reply = "done"
+		return req.Context().Err()
+	case <-time.After(time.Second):
+		*reply = "slow"
+		return nil
+	}
+}
+
+func newPipeClient(t *testing.T, svc *HelloService) *Client {
+	srv := rpc.NewServer()
+	if err := srv.Register(svc); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, serverConn := net.Pipe()
+	go ServeConn(srv, serverConn)
+
+	client := NewClient(conn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestMetadata(t *testing.T) {
+	client := newPipeClient(t, new(HelloService))
+
+	ctx := NewOutgoingContext(context.Background(), MD{"trace-id": "abc"})
+	ctx = AppendToOutgoingContext(ctx, "authorization", "token")
+	if md, _ := FromOutgoingContext(ctx); len(md) != 2 {
+		t.Fatalf("unexpected metadata: %v", md)
+	}
+
+	var reply string
+	if err := client.CallContext(ctx, "HelloService.Hello", HelloRequest{Name: "gopher"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply != "hello:gopher, trace-id:abc" {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+
+	// calls without a context still work
+	if err := client.Call("HelloService.Hello", HelloRequest{Name: "gopher"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply != "hello:gopher, trace-id:" {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	svc := new(HelloService)
+	client := newPipeClient(t, svc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var reply string
+	// the client and the server give up at the same time
+	err := client.CallContext(ctx, "HelloService.Slow", &HelloRequest{}, &reply)
+	if !errors.Is(err, ErrDeadlineExceeded) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect = %v, got = %v", ErrDeadlineExceeded, err)
+	}
+
+	// the server answers at the deadline by itself, even if the client
+	// keeps waiting
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	call := <-client.Go("HelloService.Slow", &outgoing{ctx: ctx, args: &HelloRequest{}}, &reply, nil).Done
+	err = rpcerrors.FromRPC(call.Error)
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("expect = %v, got = %v", ErrDeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("the server answered after %v", d)
+	}
+
+	// the late reply of the method is dropped, the connection still works
+	time.Sleep(50 * time.Millisecond)
+	if err := client.CallContext(context.Background(), "HelloService.Hello", HelloRequest{Name: "x"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	client := newPipeClient(t, new(HelloService))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	var reply string
+	err := client.CallContext(ctx, "HelloService.Slow", &HelloRequest{}, &reply)
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect = %v, got = %v", ErrCanceled, err)
+	}
+
+	err = client.CallContext(ctx, "HelloService.Hello", HelloRequest{}, &reply)
+	if !errors.Is(err, ErrCanceled) {
+		t.Fatalf("expect = %v, got = %v", ErrCanceled, err)
+	}
+}
+
+// TestTimeoutOnWire checks that the time left is sent, not the deadline
+// of the client clock.
+func TestTimeoutOnWire(t *testing.T) {
+	conn, serverConn := net.Pipe()
+	defer conn.Close()
+	defer serverConn.Close()
+	codec := NewClientCodec(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	go codec.WriteRequest(&rpc.Request{ServiceMethod: "HelloService.Hello"}, &outgoing{ctx: ctx, args: HelloRequest{}})
+
+	dec := gob.NewDecoder(serverConn)
+	var meta requestMeta
+	if err := dec.Decode(new(rpc.Request)); err != nil {
+		t.Fatal(err)
+	}
+	if err := dec.Decode(&meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Timeout <= 0 || meta.Timeout > time.Minute {
+		t.Fatalf("expect a timeout of about %v, got = %v", time.Minute, meta.Timeout)
+	}
+	dec.Decode(new(HelloRequest))
+}
+
+func TestExpiredDeadline(t *testing.T) {
+	svc := new(HelloService)
+	client := newPipeClient(t, svc)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	var reply string
+	call := <-client.Go("HelloService.Hello", &outgoing{ctx: ctx, args: HelloRequest{}}, &reply, nil).Done
+	if err := rpcerrors.FromRPC(call.Error); !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("expect = %v, got = %v", ErrDeadlineExceeded, err)
+	}
+	if n := atomic.LoadInt32(&svc.calls); n != 0 {
+		t.Fatalf("expect no call of the method, got %d", n)
+	}
+}
@@ -0,0 +1,188 @@
+package rpcmeta
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"io"
+	"net/rpc"
+	"sync"
+	"time"
+
+	"gobook.examples/ch4-03-netrpc-hack/rpcerrors"
+)
+
+type serverCall struct {
+	serviceMethod string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	timer         *time.Timer
+	answered      bool
+}
+
+type serverCodec struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+
+	current *serverCall // the request whose body is read next
+
+	mu     sync.Mutex // protects calls and the writes
+	calls  map[uint64]*serverCall
+	closed bool
+}
+
+// NewServerCodec returns a gob codec reading the metadata and deadline
+// sent by the client codec with each request.
+func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	buf := bufio.NewWriter(conn)
+	return &serverCodec{
+		rwc:    conn,
+		dec:    gob.NewDecoder(conn),
+		enc:    gob.NewEncoder(buf),
+		encBuf: buf,
+		calls:  make(map[uint64]*serverCall),
+	}
+}
+
+// ServeConn serves conn with srv, see NewServerCodec.
+func ServeConn(srv *rpc.Server, conn io.ReadWriteCloser) {
+	srv.ServeCodec(NewServerCodec(conn))
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		var meta requestMeta
+		if err := c.dec.Decode(r); err != nil {
+			return err
+		}
+		if err := c.dec.Decode(&meta); err != nil {
+			return err
+		}
+
+		ctx := context.WithValue(context.Background(), incomingKey{}, meta.Metadata)
+		call := &serverCall{serviceMethod: r.ServiceMethod}
+		switch {
+		case meta.Timeout == 0:
+			call.ctx, call.cancel = context.WithCancel(ctx)
+			c.start(r.Seq, call)
+			return nil
+		case meta.Timeout > 0:
+			call.ctx, call.cancel = context.WithTimeout(ctx, meta.Timeout)
+			c.start(r.Seq, call)
+			return nil
+		}
+
+		// too late already, the server does not see the request
+		if err := c.ReadRequestBody(nil); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		err := c.writeDeadlineExceeded(r.Seq, r.ServiceMethod)
+		c.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// start registers call and answers it with ErrDeadlineExceeded if its
+// deadline passes first.
+func (c *serverCodec) start(seq uint64, call *serverCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls[seq] = call
+	c.current = call
+	if deadline, ok := call.ctx.Deadline(); ok {
+		call.timer = time.AfterFunc(time.Until(deadline), func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.calls[seq] == call && !call.answered {
+				call.answered = true
+				c.writeDeadlineExceeded(seq, call.serviceMethod)
+			}
+		})
+	}
+}
+
+func (c *serverCodec) ReadRequestBody(x interface{}) error {
+	if err := c.dec.Decode(x); err != nil {
+		return err
+	}
+	if h, ok := x.(interface{ setContext(context.Context) }); ok && c.current != nil {
+		h.setContext(c.current.ctx)
+	}
+	return nil
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.calls[r.Seq]; ok {
+		delete(c.calls, r.Seq)
+		if call.timer != nil {
+			call.timer.Stop()
+		}
+		late := call.ctx.Err() == context.DeadlineExceeded
+		call.cancel()
+
+		switch {
+		case call.answered:
+			return nil
+		case late:
+			return c.writeDeadlineExceeded(r.Seq, r.ServiceMethod)
+		}
+	}
+	return c.write(r, x)
+}
+
+// writeDeadlineExceeded answers a request with ErrDeadlineExceeded. c.mu
+// must be held.
+func (c *serverCodec) writeDeadlineExceeded(seq uint64, serviceMethod string) error {
+	return c.write(&rpc.Response{
+		ServiceMethod: serviceMethod,
+		Seq:           seq,
+		Error:         rpcerrors.ToRPC(ErrDeadlineExceeded).Error(),
+	}, struct{}{})
+}
+
+// write is the WriteResponse of the gob codec of net/rpc. c.mu must be
+// held.
+func (c *serverCodec) write(r *rpc.Response, x interface{}) (err error) {
+	if err = c.enc.Encode(r); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.close()
+		}
+		return
+	}
+	if err = c.enc.Encode(x); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.close()
+		}
+		return
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *serverCodec) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, call := range c.calls {
+		if call.timer != nil {
+			call.timer.Stop()
+		}
+		call.cancel()
+	}
+	return c.close()
+}
+
+func (c *serverCodec) close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
@@ -17,6 +17,11 @@ type PubsubService struct {
 	pub *pubsub.Publisher
 }
 
+// NewPubsubService uses the publisher of docker, which gives up on a
+// subscriber not reading within 100ms and drops the message for it, with
+// nothing sent to the client. The String of this step can carry neither a
+// backpressure policy nor a gap notification: see grpc-pubsub for the
+// per-subscription policies, drop counters and gaps.
 func NewPubsubService() *PubsubService {
 	return &PubsubService{
 		pub: pubsub.NewPublisher(100*time.Millisecond, 10),
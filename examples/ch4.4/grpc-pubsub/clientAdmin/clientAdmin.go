@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc"
+
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+var flagUnsubscribe = flag.String("unsubscribe", "", "id of the subscription to end")
+
+func main() {
+	flag.Parse()
+
+	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := pb.NewPubsubServiceClient(conn)
+
+	if *flagUnsubscribe != "" {
+		_, err := client.Unsubscribe(context.Background(), &pb.UnsubscribeRequest{Id: *flagUnsubscribe})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	reply, err := client.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, sub := range reply.GetSubscriptions() {
+		fmt.Printf("%s topic=%q value=%q next=%d peer=%s policy=%s dropped=%d lag=%d queued=%d group=%q unacked=%d\n",
+			sub.GetId(), sub.GetTopic(), sub.GetValue(), sub.GetNextSeq(), sub.GetPeer(),
+			sub.GetPolicy(), sub.GetDropped(), sub.GetLag(), sub.GetQueued(),
+			sub.GetGroup(), sub.GetUnacked())
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -18,12 +20,20 @@ func main() {
 
 	client := pb.NewPubsubServiceClient(conn)
 
-	_, err = client.Publish(context.Background(), &pb.String{Value: "golang: hello Go"})
+	reply, err := client.Publish(context.Background(), &pb.String{Value: "golang: hello Go"})
 	if err != nil {
 		log.Fatal(err)
 	}
+	fmt.Println(reply.GetSeq(), time.Unix(0, reply.GetTimeUnixNano()))
 	_, err = client.Publish(context.Background(), &pb.String{Value: "docker: hello Docker"})
 	if err != nil {
 		log.Fatal(err)
 	}
+	_, err = client.Publish(context.Background(), &pb.String{
+		Topic:   "golang.release.go1",
+		Payload: []byte("hello Go 1"),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 }
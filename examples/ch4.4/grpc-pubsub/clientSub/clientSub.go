@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
@@ -11,7 +12,16 @@ import (
 	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
 )
 
+var (
+	flagStart  = flag.Uint64("start", 0, "first message to replay, 0 for the new messages only")
+	flagTopic  = flag.String("topic", "", `topic pattern such as "golang.>", instead of the "golang:" prefix`)
+	flagGroup  = flag.String("group", "", "consumer group sharing the messages, acknowledged once printed")
+	flagPolicy = flag.String("policy", "CATCH_UP", "when falling behind: CATCH_UP, BLOCK, DROP_OLDEST, DROP_NEWEST or DISCONNECT")
+)
+
 func main() {
+	flag.Parse()
+
 	conn, err := grpc.Dial("localhost:1234", grpc.WithInsecure())
 	if err != nil {
 		log.Fatal(err)
@@ -19,10 +29,24 @@ func main() {
 	defer conn.Close()
 
 	client := pb.NewPubsubServiceClient(conn)
-	stream, err := client.Subscribe(context.Background(), &pb.String{Value: "golang:"})
+	stream, err := client.Subscribe(context.Background(), &pb.SubscribeRequest{
+		Value:    "golang:",
+		StartSeq: *flagStart,
+		Topic:    *flagTopic,
+		Policy:   pb.Policy(pb.Policy_value[*flagPolicy]),
+		Group:    *flagGroup,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	header, err := stream.Header()
 	if err != nil {
 		log.Fatal(err)
 	}
+	var id string
+	if ids := header.Get("pubsub-subscription-id"); len(ids) > 0 {
+		id = ids[0]
+	}
 
 	for {
 		reply, err := stream.Recv()
@@ -33,6 +57,23 @@ func main() {
 			log.Fatal(err)
 		}
 
-		fmt.Println(reply.GetValue())
+		if gap := reply.GetGap(); gap != nil {
+			fmt.Printf("gap %d-%d: %d messages lost\n", gap.GetFirstSeq(), gap.GetLastSeq(), gap.GetDropped())
+		} else if reply.GetTopic() != "" {
+			fmt.Println(reply.GetSeq(), reply.GetTopic(), string(reply.GetPayload()))
+		} else {
+			fmt.Println(reply.GetSeq(), reply.GetValue())
+		}
+
+		if *flagGroup != "" && reply.GetGap() == nil {
+			_, err := client.Ack(context.Background(), &pb.AckRequest{
+				Group: *flagGroup,
+				Id:    id,
+				Seqs:  []uint64{reply.GetSeq()},
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 }
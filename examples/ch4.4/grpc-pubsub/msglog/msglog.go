@@ -0,0 +1,473 @@
+// Package msglog is an append-only message log on disk. Every record gets
+// the next sequence number, starting at 1, and the oldest records are
+// removed by the retention limits on the size and the age of the log.
+//
+// The log is a directory of segment files named after the sequence number
+// of their first record, and of an id file naming the log. A log created
+// again in an emptied directory gets a new ID, which tells the readers
+// that its sequence numbers started over. A record is written as
+//
+//	uint32 length of data
+//	uint32 CRC-32 of seq, time and data
+//	uint64 seq
+//	int64  time in unix nanoseconds
+//	[]byte data
+//
+// in big endian. A torn record at the end of the last segment, left by a
+// crash, is truncated when the log is opened.
+package msglog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerSize = 24
+	fileSuffix = ".log"
+	idFile     = "id"
+
+	DefaultSegmentBytes = 4 << 20
+)
+
+var (
+	ErrCompacted = errors.New("msglog: record removed by retention")
+	ErrClosed    = errors.New("msglog: log closed")
+	ErrCorrupted = errors.New("msglog: corrupted record")
+)
+
+// Record is a message of the log.
+type Record struct {
+	Seq  uint64
+	Time time.Time
+	Data []byte
+}
+
+// Options configures a Log. The zero value keeps every record.
+type Options struct {
+	// SegmentBytes is the size after which a new segment file is started,
+	// DefaultSegmentBytes if zero. Retention removes whole segments.
+	SegmentBytes int64
+
+	// MaxBytes removes the oldest segments while the log is larger.
+	MaxBytes int64
+
+	// MaxAge removes the segments whose last record is older. A segment
+	// whose first record is older is ended by the next Append, and the last
+	// segment by Retain once all its records are older, so a record is kept
+	// for at most about twice MaxAge.
+	MaxAge time.Duration
+
+	// Sync calls fsync after every Append.
+	Sync bool
+}
+
+// Log is an append-only log safe for concurrent use.
+type Log struct {
+	dir  string
+	id   string
+	opts Options
+	now  func() time.Time
+
+	mu       sync.RWMutex
+	segments []*segment // by sequence number, the last one is appended to
+	nextSeq  uint64
+	closed   bool
+}
+
+type segment struct {
+	f         *os.File
+	base      uint64  // seq of the first record
+	offsets   []int64 // file offset of each record
+	size      int64
+	firstTime time.Time
+	lastTime  time.Time
+}
+
+// Open opens the log in dir, creating the directory if needed.
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	l := &Log{dir: dir, opts: opts, now: time.Now, nextSeq: 1}
+	if err := l.load(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := l.loadID(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// loadID reads the id file, or creates it with a random ID.
+func (l *Log) loadID() error {
+	path := filepath.Join(l.dir, idFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		l.id = strings.TrimSpace(string(data))
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return err
+	}
+	l.id = hex.EncodeToString(b[:])
+	return writeFile(path, []byte(l.id+"\n"))
+}
+
+// writeFile replaces the file at path with data, so that a crash leaves
+// either the old or the new content.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ID returns the random name of the log, kept as long as its directory.
+func (l *Log) ID() string {
+	return l.id
+}
+
+// Dir returns the directory of the log. Files not ending in .log may be
+// kept there.
+func (l *Log) Dir() string {
+	return l.dir
+}
+
+func (l *Log) load() error {
+	names, err := filepath.Glob(filepath.Join(l.dir, "*"+fileSuffix))
+	if err != nil {
+		return err
+	}
+
+	var bases []uint64
+	for _, name := range names {
+		base, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(name), fileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+
+	for i, base := range bases {
+		if i > 0 && base != l.nextSeq {
+			return fmt.Errorf("msglog: segment %d does not follow seq %d", base, l.nextSeq-1)
+		}
+		f, err := os.OpenFile(l.segmentPath(base), os.O_RDWR, 0644)
+		if err != nil {
+			return err
+		}
+		s := &segment{f: f, base: base}
+		l.segments = append(l.segments, s)
+
+		if err := s.scan(i == len(bases)-1); err != nil {
+			return err
+		}
+		l.nextSeq = s.base + uint64(len(s.offsets))
+	}
+	return nil
+}
+
+// scan indexes the records of s. A bad record ends the last segment and
+// is an error in the others.
+func (s *segment) scan(last bool) error {
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var off int64
+	for off < info.Size() {
+		rec, n, err := readRecord(s.f, off, info.Size())
+		if err == nil && rec.Seq != s.base+uint64(len(s.offsets)) {
+			err = ErrCorrupted
+		}
+		if err != nil {
+			if !last {
+				return fmt.Errorf("%s at offset %d: %v", s.f.Name(), off, err)
+			}
+			if err := s.f.Truncate(off); err != nil {
+				return err
+			}
+			break
+		}
+		if len(s.offsets) == 0 {
+			s.firstTime = rec.Time
+		}
+		s.offsets = append(s.offsets, off)
+		s.lastTime = rec.Time
+		off += n
+	}
+	s.size = off
+	return nil
+}
+
+// readRecord reads the record at off of a file of size bytes. The length
+// is checked against size before allocating, as a torn one may be huge.
+func readRecord(r io.ReaderAt, off, size int64) (Record, int64, error) {
+	var hdr [headerSize]byte
+	if _, err := r.ReadAt(hdr[:], off); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, 0, err
+	}
+
+	n := int64(binary.BigEndian.Uint32(hdr[0:]))
+	if n > size-off-headerSize {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, n)
+	if _, err := r.ReadAt(data, off+headerSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Record{}, 0, err
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[8:])
+	crc.Write(data)
+	if crc.Sum32() != binary.BigEndian.Uint32(hdr[4:]) {
+		return Record{}, 0, ErrCorrupted
+	}
+
+	return Record{
+		Seq:  binary.BigEndian.Uint64(hdr[8:]),
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(hdr[16:]))),
+		Data: data,
+	}, headerSize + int64(len(data)), nil
+}
+
+func encodeRecord(rec Record) []byte {
+	buf := make([]byte, headerSize+len(rec.Data))
+	binary.BigEndian.PutUint32(buf[0:], uint32(len(rec.Data)))
+	binary.BigEndian.PutUint64(buf[8:], rec.Seq)
+	binary.BigEndian.PutUint64(buf[16:], uint64(rec.Time.UnixNano()))
+	copy(buf[headerSize:], rec.Data)
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(buf[8:]))
+	return buf
+}
+
+func (l *Log) segmentPath(base uint64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, fileSuffix))
+}
+
+// Append writes data as the next record and returns it.
+func (l *Log) Append(data []byte) (Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return Record{}, ErrClosed
+	}
+
+	s, err := l.activeSegment()
+	if err != nil {
+		return Record{}, err
+	}
+
+	rec := Record{Seq: l.nextSeq, Time: l.now(), Data: data}
+	buf := encodeRecord(rec)
+	if _, err := s.f.WriteAt(buf, s.size); err != nil {
+		s.f.Truncate(s.size)
+		return Record{}, err
+	}
+	if l.opts.Sync {
+		if err := s.f.Sync(); err != nil {
+			return Record{}, err
+		}
+	}
+
+	if len(s.offsets) == 0 {
+		s.firstTime = rec.Time
+	}
+	s.offsets = append(s.offsets, s.size)
+	s.size += int64(len(buf))
+	s.lastTime = rec.Time
+	l.nextSeq++
+
+	return rec, l.retain()
+}
+
+// activeSegment returns the segment to append to, starting a new one when
+// the last is full or its first record too old.
+func (l *Log) activeSegment() (*segment, error) {
+	if n := len(l.segments); n > 0 {
+		s := l.segments[n-1]
+		if s.size < l.opts.SegmentBytes && (len(s.offsets) == 0 || !l.tooOld(s.firstTime)) {
+			return s, nil
+		}
+	}
+	return l.newSegment()
+}
+
+// newSegment starts a segment at nextSeq, after a non-empty one.
+func (l *Log) newSegment() (*segment, error) {
+	f, err := os.OpenFile(l.segmentPath(l.nextSeq), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s := &segment{f: f, base: l.nextSeq}
+	l.segments = append(l.segments, s)
+	return s, nil
+}
+
+// Retain removes the segments beyond the retention limits. Append applies
+// them too, but only MaxAge can expire records while nothing is appended.
+func (l *Log) Retain() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+	return l.retain()
+}
+
+// tooOld reports whether a record of time t is beyond MaxAge.
+func (l *Log) tooOld(t time.Time) bool {
+	return l.opts.MaxAge > 0 && l.now().Sub(t) > l.opts.MaxAge
+}
+
+// retain never removes the last segment, which holds nextSeq. Once all its
+// records are too old, an empty segment is started after it instead.
+func (l *Log) retain() error {
+	if n := len(l.segments); n > 0 {
+		if s := l.segments[n-1]; len(s.offsets) > 0 && l.tooOld(s.lastTime) {
+			if _, err := l.newSegment(); err != nil {
+				return err
+			}
+		}
+	}
+
+	var total int64
+	for _, s := range l.segments {
+		total += s.size
+	}
+
+	for len(l.segments) > 1 {
+		s := l.segments[0]
+		tooBig := l.opts.MaxBytes > 0 && total > l.opts.MaxBytes
+		tooOld := l.tooOld(s.lastTime)
+		if !tooBig && !tooOld {
+			break
+		}
+
+		s.f.Close()
+		if err := os.Remove(s.f.Name()); err != nil {
+			return err
+		}
+		total -= s.size
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+// FirstSeq returns the sequence number of the oldest record, or of the
+// next record if the log is empty.
+func (l *Log) FirstSeq() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.segments) == 0 {
+		return l.nextSeq
+	}
+	return l.segments[0].base
+}
+
+// LastSeq returns the sequence number of the newest record, 0 if nothing
+// has been appended yet.
+func (l *Log) LastSeq() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.nextSeq - 1
+}
+
+// Read returns up to max records starting at seq. It returns no records
+// when seq is after the last record, and ErrCompacted when seq has been
+// removed by retention.
+func (l *Log) Read(seq uint64, max int) ([]Record, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return nil, ErrClosed
+	}
+	if len(l.segments) == 0 || seq >= l.nextSeq {
+		return nil, nil
+	}
+	if seq < l.segments[0].base {
+		return nil, ErrCompacted
+	}
+
+	i := sort.Search(len(l.segments), func(i int) bool {
+		return l.segments[i].base > seq
+	}) - 1
+
+	var recs []Record
+	for ; i < len(l.segments) && len(recs) < max; i++ {
+		s := l.segments[i]
+		for j := int(seq - s.base); j < len(s.offsets) && len(recs) < max; j++ {
+			rec, _, err := readRecord(s.f, s.offsets[j], s.size)
+			if err != nil {
+				return recs, err
+			}
+			recs = append(recs, rec)
+			seq++
+		}
+	}
+	return recs, nil
+}
+
+// Close closes the segment files.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	var err error
+	for _, s := range l.segments {
+		if cerr := s.f.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,261 @@
+package msglog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func appendN(t *testing.T, l *Log, from, n int) {
+	for i := from; i < from+n; i++ {
+		rec, err := l.Append([]byte(fmt.Sprintf("msg-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Seq != uint64(i) {
+			t.Fatalf("expect seq = %d, got = %d", i, rec.Seq)
+		}
+	}
+}
+
+func checkRead(t *testing.T, l *Log, seq uint64, max int, expect ...int) {
+	recs, err := l.Read(seq, max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != len(expect) {
+		t.Fatalf("Read(%d, %d): expect %d records, got = %d", seq, max, len(expect), len(recs))
+	}
+	for i, rec := range recs {
+		if want := fmt.Sprintf("msg-%d", expect[i]); rec.Seq != uint64(expect[i]) || string(rec.Data) != want {
+			t.Fatalf("expect = %d %s, got = %d %s", expect[i], want, rec.Seq, rec.Data)
+		}
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	l, err := Open(t.TempDir(), Options{SegmentBytes: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.FirstSeq() != 1 || l.LastSeq() != 0 {
+		t.Fatalf("empty log: first = %d, last = %d", l.FirstSeq(), l.LastSeq())
+	}
+	checkRead(t, l, 1, 10)
+
+	appendN(t, l, 1, 20)
+	if len(l.segments) < 2 {
+		t.Fatalf("expect several segments, got = %d", len(l.segments))
+	}
+	checkRead(t, l, 1, 3, 1, 2, 3)
+	checkRead(t, l, 5, 100, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+	checkRead(t, l, 20, 10, 20)
+	checkRead(t, l, 21, 10)
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{SegmentBytes: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, l, 1, 10)
+	id := l.ID()
+	l.Close()
+
+	l, err = Open(dir, Options{SegmentBytes: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.FirstSeq() != 1 || l.LastSeq() != 10 {
+		t.Fatalf("expect 1..10, got = %d..%d", l.FirstSeq(), l.LastSeq())
+	}
+	if l.ID() != id || id == "" {
+		t.Fatalf("expect = %q, got = %q", id, l.ID())
+	}
+
+	other, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if other.ID() == id {
+		t.Fatalf("expect a new ID, got = %q", id)
+	}
+	appendN(t, l, 11, 2)
+	checkRead(t, l, 9, 10, 9, 10, 11, 12)
+}
+
+func TestTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, l, 1, 3)
+	l.Close()
+
+	// cut the last record in the middle, as a crash during a write would
+	name := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, fileSuffix))
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(name, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.LastSeq() != 2 {
+		t.Fatalf("expect last = 2, got = %d", l.LastSeq())
+	}
+	appendN(t, l, 3, 1)
+	checkRead(t, l, 1, 10, 1, 2, 3)
+}
+
+func TestTornLength(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendN(t, l, 1, 2)
+	l.Close()
+
+	// a length of 4 GiB in the header of the last record
+	name := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, fileSuffix))
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := int64(headerSize + len("msg-1"))
+	if _, err := f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, off); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	l, err = Open(dir, Options{})
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if l.LastSeq() != 1 {
+		t.Fatalf("expect last = 1, got = %d", l.LastSeq())
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("expect less than 1 MiB allocated, got = %d", n)
+	}
+}
+
+func TestRetainBytes(t *testing.T) {
+	l, err := Open(t.TempDir(), Options{SegmentBytes: 100, MaxBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	appendN(t, l, 1, 30)
+	if l.FirstSeq() == 1 {
+		t.Fatal("expect old segments removed")
+	}
+	if _, err := l.Read(1, 1); err != ErrCompacted {
+		t.Fatalf("expect = %v, got = %v", ErrCompacted, err)
+	}
+	checkRead(t, l, 30, 1, 30)
+
+	var total int64
+	for _, s := range l.segments {
+		total += s.size
+	}
+	if total > 200 {
+		t.Fatalf("expect at most 200 bytes, got = %d", total)
+	}
+}
+
+func TestRetainAge(t *testing.T) {
+	l, err := Open(t.TempDir(), Options{SegmentBytes: 100, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	appendN(t, l, 1, 10)
+
+	now = now.Add(2 * time.Hour)
+	if err := l.Retain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty segment holding the next seq is all that is kept
+	if len(l.segments) != 1 || l.FirstSeq() != 11 || l.LastSeq() != 10 {
+		t.Fatalf("expect 1 empty segment at 11, got = %d segments, first = %d, last = %d",
+			len(l.segments), l.FirstSeq(), l.LastSeq())
+	}
+	if _, err := l.Read(10, 1); err != ErrCompacted {
+		t.Fatalf("expect = %v, got = %v", ErrCompacted, err)
+	}
+	appendN(t, l, 11, 1)
+	checkRead(t, l, 11, 10, 11)
+}
+
+func TestRetainAgeActiveSegment(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	appendN(t, l, 1, 3)
+
+	// the segment of the old records is ended, then removed with them
+	now = now.Add(90 * time.Minute)
+	appendN(t, l, 4, 1)
+	now = now.Add(time.Minute)
+	appendN(t, l, 5, 1)
+	if l.FirstSeq() != 4 {
+		t.Fatalf("expect first = 4, got = %d", l.FirstSeq())
+	}
+	l.Close()
+
+	// an empty last segment is reopened as the next seq
+	now = now.Add(2 * time.Hour)
+	l, err = Open(dir, Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+	if err := l.Retain(); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = Open(dir, Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.FirstSeq() != 6 || l.LastSeq() != 5 {
+		t.Fatalf("expect an empty log at 6, got first = %d, last = %d", l.FirstSeq(), l.LastSeq())
+	}
+	appendN(t, l, 6, 1)
+}
@@ -1,214 +1,1101 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
 // source: pubsubservice.proto
 
 package pubsubservice
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
 import (
-	context "golang.org/x/net/context"
-	grpc "google.golang.org/grpc"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Policy is what happens to the live messages of a subscriber whose queue
+// is full.
+type Policy int32
+
+const (
+	// CATCH_UP reads the messages from the log once the queue is drained.
+	// Nothing is lost, unless retention removes the messages first.
+	Policy_CATCH_UP Policy = 0
+	// BLOCK makes the publishers wait for room in the queue.
+	Policy_BLOCK Policy = 1
+	// DROP_OLDEST drops the oldest queued message for the new one.
+	Policy_DROP_OLDEST Policy = 2
+	// DROP_NEWEST drops the new message.
+	Policy_DROP_NEWEST Policy = 3
+	// DISCONNECT drops the new message and ends the stream with
+	// RESOURCE_EXHAUSTED after max_drops drops.
+	Policy_DISCONNECT Policy = 4
+)
+
+// Enum value maps for Policy.
+var (
+	Policy_name = map[int32]string{
+		0: "CATCH_UP",
+		1: "BLOCK",
+		2: "DROP_OLDEST",
+		3: "DROP_NEWEST",
+		4: "DISCONNECT",
+	}
+	Policy_value = map[string]int32{
+		"CATCH_UP":    0,
+		"BLOCK":       1,
+		"DROP_OLDEST": 2,
+		"DROP_NEWEST": 3,
+		"DISCONNECT":  4,
+	}
 )
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = fmt.Errorf
-var _ = math.Inf
+func (x Policy) Enum() *Policy {
+	p := new(Policy)
+	*p = x
+	return p
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the proto package it is being compiled against.
-// A compilation error at this line likely means your copy of the
-// proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+func (x Policy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Policy) Descriptor() protoreflect.EnumDescriptor {
+	return file_pubsubservice_proto_enumTypes[0].Descriptor()
+}
+
+func (Policy) Type() protoreflect.EnumType {
+	return &file_pubsubservice_proto_enumTypes[0]
+}
+
+func (x Policy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
 
+// Deprecated: Use Policy.Descriptor instead.
+func (Policy) EnumDescriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{0}
+}
+
+// String is a published message. New publishers set topic and payload;
+// value is the whole message of the older clients.
 type String struct {
-	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Value   string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Topic   string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Payload []byte                 `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	// origin is the peer a message was forwarded from, with its seq in the
+	// log of the peer named origin_log_id. Only the mesh sets them,
+	// Publish rejects them.
+	Origin        string `protobuf:"bytes,4,opt,name=origin,proto3" json:"origin,omitempty"`
+	OriginSeq     uint64 `protobuf:"varint,5,opt,name=origin_seq,json=originSeq,proto3" json:"origin_seq,omitempty"`
+	OriginLogId   string `protobuf:"bytes,6,opt,name=origin_log_id,json=originLogId,proto3" json:"origin_log_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (m *String) Reset()         { *m = String{} }
-func (m *String) String() string { return proto.CompactTextString(m) }
-func (*String) ProtoMessage()    {}
+func (x *String) Reset() {
+	*x = String{}
+	mi := &file_pubsubservice_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *String) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*String) ProtoMessage() {}
+
+func (x *String) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use String.ProtoReflect.Descriptor instead.
 func (*String) Descriptor() ([]byte, []int) {
-	return fileDescriptor_pubsubservice_f5055945d20b225a, []int{0}
+	return file_pubsubservice_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *String) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *String) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
 }
-func (m *String) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_String.Unmarshal(m, b)
+
+func (x *String) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *String) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *String) GetOriginSeq() uint64 {
+	if x != nil {
+		return x.OriginSeq
+	}
+	return 0
 }
-func (m *String) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_String.Marshal(b, m, deterministic)
+
+func (x *String) GetOriginLogId() string {
+	if x != nil {
+		return x.OriginLogId
+	}
+	return ""
 }
-func (dst *String) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_String.Merge(dst, src)
+
+// PublishReply identifies a logged message, as seq in the messages and in
+// Ack.
+type PublishReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	TimeUnixNano  int64                  `protobuf:"varint,2,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
-func (m *String) XXX_Size() int {
-	return xxx_messageInfo_String.Size(m)
+
+func (x *PublishReply) Reset() {
+	*x = PublishReply{}
+	mi := &file_pubsubservice_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
-func (m *String) XXX_DiscardUnknown() {
-	xxx_messageInfo_String.DiscardUnknown(m)
+
+func (x *PublishReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_String proto.InternalMessageInfo
+func (*PublishReply) ProtoMessage() {}
 
-func (m *String) GetValue() string {
-	if m != nil {
-		return m.Value
+func (x *PublishReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishReply.ProtoReflect.Descriptor instead.
+func (*PublishReply) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PublishReply) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *PublishReply) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// value is the prefix of the messages to receive, when topic is empty.
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// start_seq is the first message to replay from the log. Zero starts
+	// after the latest message.
+	StartSeq uint64 `protobuf:"varint,2,opt,name=start_seq,json=startSeq,proto3" json:"start_seq,omitempty"`
+	// topic is a pattern such as "orders.*.created" or "orders.>".
+	Topic string `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	// id names the subscription for Unsubscribe. The server picks one when
+	// empty and returns it in the "pubsub-subscription-id" header.
+	Id     string `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
+	Policy Policy `protobuf:"varint,5,opt,name=policy,proto3,enum=pubsubservice.Policy" json:"policy,omitempty"`
+	// queue_size is the number of live messages queued, 64 if zero.
+	QueueSize uint32 `protobuf:"varint,6,opt,name=queue_size,json=queueSize,proto3" json:"queue_size,omitempty"`
+	// max_drops is the number of drops before DISCONNECT, 1 if zero.
+	MaxDrops uint32 `protobuf:"varint,7,opt,name=max_drops,json=maxDrops,proto3" json:"max_drops,omitempty"`
+	// group makes the subscription a member of a consumer group. Each
+	// message of the group goes to one member and is delivered again,
+	// possibly to another member, until acknowledged with Ack. The group
+	// is created by its first member, with its topic or value, start_seq
+	// and the options below. The later members join it with the same
+	// topic or value, and either the same options or none; start_seq is
+	// ignored. A group without members is removed after a minute. policy
+	// is ignored and queue_size is the number of unacknowledged messages
+	// of the member. A group belongs to its node and skips the messages
+	// forwarded from the peers.
+	Group string `protobuf:"bytes,8,opt,name=group,proto3" json:"group,omitempty"`
+	// visibility_timeout_ms is the time to acknowledge a message before it
+	// is delivered again, 30s if zero.
+	VisibilityTimeoutMs uint32 `protobuf:"varint,9,opt,name=visibility_timeout_ms,json=visibilityTimeoutMs,proto3" json:"visibility_timeout_ms,omitempty"`
+	// max_deliveries is the number of deliveries before a message is
+	// published to dead_letter_topic, 5 if zero.
+	MaxDeliveries uint32 `protobuf:"varint,10,opt,name=max_deliveries,json=maxDeliveries,proto3" json:"max_deliveries,omitempty"`
+	// dead_letter_topic is "dead-letter.<group>" if empty. The group never
+	// receives the messages of its dead letter topic.
+	DeadLetterTopic string `protobuf:"bytes,11,opt,name=dead_letter_topic,json=deadLetterTopic,proto3" json:"dead_letter_topic,omitempty"`
+	// local_only skips the messages forwarded from the peers. The mesh
+	// forwards with it, so a message makes a single hop.
+	LocalOnly     bool `protobuf:"varint,12,opt,name=local_only,json=localOnly,proto3" json:"local_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_pubsubservice_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SubscribeRequest) GetValue() string {
+	if x != nil {
+		return x.Value
 	}
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*String)(nil), "pubsubservice.String")
+func (x *SubscribeRequest) GetStartSeq() uint64 {
+	if x != nil {
+		return x.StartSeq
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (x *SubscribeRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (x *SubscribeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
 
-// PubsubServiceClient is the client API for PubsubService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type PubsubServiceClient interface {
-	Publish(ctx context.Context, in *String, opts ...grpc.CallOption) (*String, error)
-	Subscribe(ctx context.Context, in *String, opts ...grpc.CallOption) (PubsubService_SubscribeClient, error)
+func (x *SubscribeRequest) GetPolicy() Policy {
+	if x != nil {
+		return x.Policy
+	}
+	return Policy_CATCH_UP
 }
 
-type pubsubServiceClient struct {
-	cc *grpc.ClientConn
+func (x *SubscribeRequest) GetQueueSize() uint32 {
+	if x != nil {
+		return x.QueueSize
+	}
+	return 0
 }
 
-func NewPubsubServiceClient(cc *grpc.ClientConn) PubsubServiceClient {
-	return &pubsubServiceClient{cc}
+func (x *SubscribeRequest) GetMaxDrops() uint32 {
+	if x != nil {
+		return x.MaxDrops
+	}
+	return 0
 }
 
-func (c *pubsubServiceClient) Publish(ctx context.Context, in *String, opts ...grpc.CallOption) (*String, error) {
-	out := new(String)
-	err := c.cc.Invoke(ctx, "/pubsubservice.PubsubService/Publish", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (x *SubscribeRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
 	}
-	return out, nil
+	return ""
 }
 
-func (c *pubsubServiceClient) Subscribe(ctx context.Context, in *String, opts ...grpc.CallOption) (PubsubService_SubscribeClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_PubsubService_serviceDesc.Streams[0], "/pubsubservice.PubsubService/Subscribe", opts...)
-	if err != nil {
-		return nil, err
+func (x *SubscribeRequest) GetVisibilityTimeoutMs() uint32 {
+	if x != nil {
+		return x.VisibilityTimeoutMs
 	}
-	x := &pubsubServiceSubscribeClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	return 0
+}
+
+func (x *SubscribeRequest) GetMaxDeliveries() uint32 {
+	if x != nil {
+		return x.MaxDeliveries
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	return 0
+}
+
+func (x *SubscribeRequest) GetDeadLetterTopic() string {
+	if x != nil {
+		return x.DeadLetterTopic
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetLocalOnly() bool {
+	if x != nil {
+		return x.LocalOnly
 	}
-	return x, nil
+	return false
 }
 
-type PubsubService_SubscribeClient interface {
-	Recv() (*String, error)
-	grpc.ClientStream
+// Gap tells a subscriber that messages between first_seq and last_seq were
+// lost. dropped is their number, 0 when only the range is known.
+type Gap struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FirstSeq      uint64                 `protobuf:"varint,1,opt,name=first_seq,json=firstSeq,proto3" json:"first_seq,omitempty"`
+	LastSeq       uint64                 `protobuf:"varint,2,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`
+	Dropped       uint64                 `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type pubsubServiceSubscribeClient struct {
-	grpc.ClientStream
+func (x *Gap) Reset() {
+	*x = Gap{}
+	mi := &file_pubsubservice_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *pubsubServiceSubscribeClient) Recv() (*String, error) {
-	m := new(String)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (x *Gap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Gap) ProtoMessage() {}
+
+func (x *Gap) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return m, nil
+	return mi.MessageOf(x)
 }
 
-// PubsubServiceServer is the server API for PubsubService service.
-type PubsubServiceServer interface {
-	Publish(context.Context, *String) (*String, error)
-	Subscribe(*String, PubsubService_SubscribeServer) error
+// Deprecated: Use Gap.ProtoReflect.Descriptor instead.
+func (*Gap) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{3}
 }
 
-func RegisterPubsubServiceServer(s *grpc.Server, srv PubsubServiceServer) {
-	s.RegisterService(&_PubsubService_serviceDesc, srv)
+func (x *Gap) GetFirstSeq() uint64 {
+	if x != nil {
+		return x.FirstSeq
+	}
+	return 0
 }
 
-func _PubsubService_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(String)
-	if err := dec(in); err != nil {
-		return nil, err
+func (x *Gap) GetLastSeq() uint64 {
+	if x != nil {
+		return x.LastSeq
 	}
-	if interceptor == nil {
-		return srv.(PubsubServiceServer).Publish(ctx, in)
+	return 0
+}
+
+func (x *Gap) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/pubsubservice.PubsubService/Publish",
+	return 0
+}
+
+type Message struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Value        string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Seq          uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	TimeUnixNano int64                  `protobuf:"varint,3,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+	Topic        string                 `protobuf:"bytes,4,opt,name=topic,proto3" json:"topic,omitempty"`
+	Payload      []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	// gap is set, and the other fields are empty, in a gap notification.
+	Gap *Gap `protobuf:"bytes,6,opt,name=gap,proto3" json:"gap,omitempty"`
+	// attempt counts the deliveries of the message to a consumer group.
+	Attempt uint32 `protobuf:"varint,7,opt,name=attempt,proto3" json:"attempt,omitempty"`
+	// origin is the peer the message was published on, empty if local.
+	Origin        string `protobuf:"bytes,8,opt,name=origin,proto3" json:"origin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_pubsubservice_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PubsubServiceServer).Publish(ctx, req.(*String))
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Message) GetValue() string {
+	if x != nil {
+		return x.Value
 	}
-	return interceptor(ctx, in, info, handler)
+	return ""
 }
 
-func _PubsubService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(String)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+func (x *Message) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
 	}
-	return srv.(PubsubServiceServer).Subscribe(m, &pubsubServiceSubscribeServer{stream})
+	return 0
 }
 
-type PubsubService_SubscribeServer interface {
-	Send(*String) error
-	grpc.ServerStream
+func (x *Message) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
 }
 
-type pubsubServiceSubscribeServer struct {
-	grpc.ServerStream
+func (x *Message) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
 }
 
-func (x *pubsubServiceSubscribeServer) Send(m *String) error {
-	return x.ServerStream.SendMsg(m)
+func (x *Message) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
 }
 
-var _PubsubService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "pubsubservice.PubsubService",
-	HandlerType: (*PubsubServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Publish",
-			Handler:    _PubsubService_Publish_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "Subscribe",
-			Handler:       _PubsubService_Subscribe_Handler,
-			ServerStreams: true,
+func (x *Message) GetGap() *Gap {
+	if x != nil {
+		return x.Gap
+	}
+	return nil
+}
+
+func (x *Message) GetAttempt() uint32 {
+	if x != nil {
+		return x.Attempt
+	}
+	return 0
+}
+
+func (x *Message) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+type AckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Group string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Seqs  []uint64               `protobuf:"varint,2,rep,packed,name=seqs,proto3" json:"seqs,omitempty"`
+	// id is the subscription the messages were delivered to, from the
+	// "pubsub-subscription-id" header. The seqs delivered to the other
+	// members are ignored.
+	Id            string `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckRequest) Reset() {
+	*x = AckRequest{}
+	mi := &file_pubsubservice_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckRequest) ProtoMessage() {}
+
+func (x *AckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckRequest.ProtoReflect.Descriptor instead.
+func (*AckRequest) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AckRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *AckRequest) GetSeqs() []uint64 {
+	if x != nil {
+		return x.Seqs
+	}
+	return nil
+}
+
+func (x *AckRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type AckReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckReply) Reset() {
+	*x = AckReply{}
+	mi := &file_pubsubservice_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckReply) ProtoMessage() {}
+
+func (x *AckReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckReply.ProtoReflect.Descriptor instead.
+func (*AckReply) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{6}
+}
+
+type UnsubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnsubscribeRequest) Reset() {
+	*x = UnsubscribeRequest{}
+	mi := &file_pubsubservice_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsubscribeRequest) ProtoMessage() {}
+
+func (x *UnsubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsubscribeRequest.ProtoReflect.Descriptor instead.
+func (*UnsubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UnsubscribeRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type UnsubscribeReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnsubscribeReply) Reset() {
+	*x = UnsubscribeReply{}
+	mi := &file_pubsubservice_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsubscribeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsubscribeReply) ProtoMessage() {}
+
+func (x *UnsubscribeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsubscribeReply.ProtoReflect.Descriptor instead.
+func (*UnsubscribeReply) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{8}
+}
+
+type ListSubscriptionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsRequest) Reset() {
+	*x = ListSubscriptionsRequest{}
+	mi := &file_pubsubservice_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{9}
+}
+
+type Subscription struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Topic   string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Value   string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	NextSeq uint64                 `protobuf:"varint,4,opt,name=next_seq,json=nextSeq,proto3" json:"next_seq,omitempty"`
+	Peer    string                 `protobuf:"bytes,5,opt,name=peer,proto3" json:"peer,omitempty"`
+	Policy  Policy                 `protobuf:"varint,6,opt,name=policy,proto3,enum=pubsubservice.Policy" json:"policy,omitempty"`
+	// dropped counts the messages lost by the policy.
+	Dropped uint64 `protobuf:"varint,7,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// lag is the number of logged messages after next_seq - 1.
+	Lag uint64 `protobuf:"varint,8,opt,name=lag,proto3" json:"lag,omitempty"`
+	// queued is the number of live messages waiting in the queue.
+	Queued uint32 `protobuf:"varint,9,opt,name=queued,proto3" json:"queued,omitempty"`
+	Group  string `protobuf:"bytes,10,opt,name=group,proto3" json:"group,omitempty"`
+	// unacked is the number of messages delivered to a group member and
+	// not yet acknowledged.
+	Unacked       uint32 `protobuf:"varint,11,opt,name=unacked,proto3" json:"unacked,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_pubsubservice_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Subscription) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Subscription) GetNextSeq() uint64 {
+	if x != nil {
+		return x.NextSeq
+	}
+	return 0
+}
+
+func (x *Subscription) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *Subscription) GetPolicy() Policy {
+	if x != nil {
+		return x.Policy
+	}
+	return Policy_CATCH_UP
+}
+
+func (x *Subscription) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *Subscription) GetLag() uint64 {
+	if x != nil {
+		return x.Lag
+	}
+	return 0
+}
+
+func (x *Subscription) GetQueued() uint32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *Subscription) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Subscription) GetUnacked() uint32 {
+	if x != nil {
+		return x.Unacked
+	}
+	return 0
+}
+
+type ListSubscriptionsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsReply) Reset() {
+	*x = ListSubscriptionsReply{}
+	mi := &file_pubsubservice_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsReply) ProtoMessage() {}
+
+func (x *ListSubscriptionsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pubsubservice_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsReply.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsReply) Descriptor() ([]byte, []int) {
+	return file_pubsubservice_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListSubscriptionsReply) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+var File_pubsubservice_proto protoreflect.FileDescriptor
+
+const file_pubsubservice_proto_rawDesc = "" +
+	"\n" +
+	"\x13pubsubservice.proto\x12\rpubsubservice\"\xa9\x01\n" +
+	"\x06String\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12\x16\n" +
+	"\x06origin\x18\x04 \x01(\tR\x06origin\x12\x1d\n" +
+	"\n" +
+	"origin_seq\x18\x05 \x01(\x04R\toriginSeq\x12\"\n" +
+	"\rorigin_log_id\x18\x06 \x01(\tR\voriginLogId\"F\n" +
+	"\fPublishReply\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12$\n" +
+	"\x0etime_unix_nano\x18\x02 \x01(\x03R\ftimeUnixNano\"\x92\x03\n" +
+	"\x10SubscribeRequest\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x1b\n" +
+	"\tstart_seq\x18\x02 \x01(\x04R\bstartSeq\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic\x12\x0e\n" +
+	"\x02id\x18\x04 \x01(\tR\x02id\x12-\n" +
+	"\x06policy\x18\x05 \x01(\x0e2\x15.pubsubservice.PolicyR\x06policy\x12\x1d\n" +
+	"\n" +
+	"queue_size\x18\x06 \x01(\rR\tqueueSize\x12\x1b\n" +
+	"\tmax_drops\x18\a \x01(\rR\bmaxDrops\x12\x14\n" +
+	"\x05group\x18\b \x01(\tR\x05group\x122\n" +
+	"\x15visibility_timeout_ms\x18\t \x01(\rR\x13visibilityTimeoutMs\x12%\n" +
+	"\x0emax_deliveries\x18\n" +
+	" \x01(\rR\rmaxDeliveries\x12*\n" +
+	"\x11dead_letter_topic\x18\v \x01(\tR\x0fdeadLetterTopic\x12\x1d\n" +
+	"\n" +
+	"local_only\x18\f \x01(\bR\tlocalOnly\"W\n" +
+	"\x03Gap\x12\x1b\n" +
+	"\tfirst_seq\x18\x01 \x01(\x04R\bfirstSeq\x12\x19\n" +
+	"\blast_seq\x18\x02 \x01(\x04R\alastSeq\x12\x18\n" +
+	"\adropped\x18\x03 \x01(\x04R\adropped\"\xdf\x01\n" +
+	"\aMessage\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12$\n" +
+	"\x0etime_unix_nano\x18\x03 \x01(\x03R\ftimeUnixNano\x12\x14\n" +
+	"\x05topic\x18\x04 \x01(\tR\x05topic\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayload\x12$\n" +
+	"\x03gap\x18\x06 \x01(\v2\x12.pubsubservice.GapR\x03gap\x12\x18\n" +
+	"\aattempt\x18\a \x01(\rR\aattempt\x12\x16\n" +
+	"\x06origin\x18\b \x01(\tR\x06origin\"F\n" +
+	"\n" +
+	"AckRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04seqs\x18\x02 \x03(\x04R\x04seqs\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\"\n" +
+	"\n" +
+	"\bAckReply\"$\n" +
+	"\x12UnsubscribeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x12\n" +
+	"\x10UnsubscribeReply\"\x1a\n" +
+	"\x18ListSubscriptionsRequest\"\x9c\x02\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x19\n" +
+	"\bnext_seq\x18\x04 \x01(\x04R\anextSeq\x12\x12\n" +
+	"\x04peer\x18\x05 \x01(\tR\x04peer\x12-\n" +
+	"\x06policy\x18\x06 \x01(\x0e2\x15.pubsubservice.PolicyR\x06policy\x12\x18\n" +
+	"\adropped\x18\a \x01(\x04R\adropped\x12\x10\n" +
+	"\x03lag\x18\b \x01(\x04R\x03lag\x12\x16\n" +
+	"\x06queued\x18\t \x01(\rR\x06queued\x12\x14\n" +
+	"\x05group\x18\n" +
+	" \x01(\tR\x05group\x12\x18\n" +
+	"\aunacked\x18\v \x01(\rR\aunacked\"[\n" +
+	"\x16ListSubscriptionsReply\x12A\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x1b.pubsubservice.SubscriptionR\rsubscriptions*S\n" +
+	"\x06Policy\x12\f\n" +
+	"\bCATCH_UP\x10\x00\x12\t\n" +
+	"\x05BLOCK\x10\x01\x12\x0f\n" +
+	"\vDROP_OLDEST\x10\x02\x12\x0f\n" +
+	"\vDROP_NEWEST\x10\x03\x12\x0e\n" +
+	"\n" +
+	"DISCONNECT\x10\x042\x89\x03\n" +
+	"\rPubsubService\x12=\n" +
+	"\aPublish\x12\x15.pubsubservice.String\x1a\x1b.pubsubservice.PublishReply\x12F\n" +
+	"\tSubscribe\x12\x1f.pubsubservice.SubscribeRequest\x1a\x16.pubsubservice.Message0\x01\x12Q\n" +
+	"\vUnsubscribe\x12!.pubsubservice.UnsubscribeRequest\x1a\x1f.pubsubservice.UnsubscribeReply\x12c\n" +
+	"\x11ListSubscriptions\x12'.pubsubservice.ListSubscriptionsRequest\x1a%.pubsubservice.ListSubscriptionsReply\x129\n" +
+	"\x03Ack\x12\x19.pubsubservice.AckRequest\x1a\x17.pubsubservice.AckReplyB7Z5gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubserviceb\x06proto3"
+
+var (
+	file_pubsubservice_proto_rawDescOnce sync.Once
+	file_pubsubservice_proto_rawDescData []byte
+)
+
+func file_pubsubservice_proto_rawDescGZIP() []byte {
+	file_pubsubservice_proto_rawDescOnce.Do(func() {
+		file_pubsubservice_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pubsubservice_proto_rawDesc), len(file_pubsubservice_proto_rawDesc)))
+	})
+	return file_pubsubservice_proto_rawDescData
+}
+
+var file_pubsubservice_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pubsubservice_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_pubsubservice_proto_goTypes = []any{
+	(Policy)(0),                      // 0: pubsubservice.Policy
+	(*String)(nil),                   // 1: pubsubservice.String
+	(*PublishReply)(nil),             // 2: pubsubservice.PublishReply
+	(*SubscribeRequest)(nil),         // 3: pubsubservice.SubscribeRequest
+	(*Gap)(nil),                      // 4: pubsubservice.Gap
+	(*Message)(nil),                  // 5: pubsubservice.Message
+	(*AckRequest)(nil),               // 6: pubsubservice.AckRequest
+	(*AckReply)(nil),                 // 7: pubsubservice.AckReply
+	(*UnsubscribeRequest)(nil),       // 8: pubsubservice.UnsubscribeRequest
+	(*UnsubscribeReply)(nil),         // 9: pubsubservice.UnsubscribeReply
+	(*ListSubscriptionsRequest)(nil), // 10: pubsubservice.ListSubscriptionsRequest
+	(*Subscription)(nil),             // 11: pubsubservice.Subscription
+	(*ListSubscriptionsReply)(nil),   // 12: pubsubservice.ListSubscriptionsReply
+}
+var file_pubsubservice_proto_depIdxs = []int32{
+	0,  // 0: pubsubservice.SubscribeRequest.policy:type_name -> pubsubservice.Policy
+	4,  // 1: pubsubservice.Message.gap:type_name -> pubsubservice.Gap
+	0,  // 2: pubsubservice.Subscription.policy:type_name -> pubsubservice.Policy
+	11, // 3: pubsubservice.ListSubscriptionsReply.subscriptions:type_name -> pubsubservice.Subscription
+	1,  // 4: pubsubservice.PubsubService.Publish:input_type -> pubsubservice.String
+	3,  // 5: pubsubservice.PubsubService.Subscribe:input_type -> pubsubservice.SubscribeRequest
+	8,  // 6: pubsubservice.PubsubService.Unsubscribe:input_type -> pubsubservice.UnsubscribeRequest
+	10, // 7: pubsubservice.PubsubService.ListSubscriptions:input_type -> pubsubservice.ListSubscriptionsRequest
+	6,  // 8: pubsubservice.PubsubService.Ack:input_type -> pubsubservice.AckRequest
+	2,  // 9: pubsubservice.PubsubService.Publish:output_type -> pubsubservice.PublishReply
+	5,  // 10: pubsubservice.PubsubService.Subscribe:output_type -> pubsubservice.Message
+	9,  // 11: pubsubservice.PubsubService.Unsubscribe:output_type -> pubsubservice.UnsubscribeReply
+	12, // 12: pubsubservice.PubsubService.ListSubscriptions:output_type -> pubsubservice.ListSubscriptionsReply
+	7,  // 13: pubsubservice.PubsubService.Ack:output_type -> pubsubservice.AckReply
+	9,  // [9:14] is the sub-list for method output_type
+	4,  // [4:9] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_pubsubservice_proto_init() }
+func file_pubsubservice_proto_init() {
+	if File_pubsubservice_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pubsubservice_proto_rawDesc), len(file_pubsubservice_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
 		},
-	},
-	Metadata: "pubsubservice.proto",
-}
-
-func init() { proto.RegisterFile("pubsubservice.proto", fileDescriptor_pubsubservice_f5055945d20b225a) }
-
-var fileDescriptor_pubsubservice_f5055945d20b225a = []byte{
-	// 132 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x2e, 0x28, 0x4d, 0x2a,
-	0x2e, 0x4d, 0x2a, 0x4e, 0x2d, 0x2a, 0xcb, 0x4c, 0x4e, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17,
-	0xe2, 0x45, 0x11, 0x54, 0x92, 0xe3, 0x62, 0x0b, 0x2e, 0x29, 0xca, 0xcc, 0x4b, 0x17, 0x12, 0xe1,
-	0x62, 0x2d, 0x4b, 0xcc, 0x29, 0x4d, 0x95, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x82, 0x70, 0x8c,
-	0x5a, 0x19, 0xb9, 0x78, 0x03, 0xc0, 0x3a, 0x82, 0x21, 0x3a, 0x84, 0xcc, 0xb9, 0xd8, 0x03, 0x4a,
-	0x93, 0x72, 0x32, 0x8b, 0x33, 0x84, 0x44, 0xf5, 0x50, 0x6d, 0x80, 0x98, 0x24, 0x85, 0x5d, 0x58,
-	0xc8, 0x9a, 0x8b, 0x33, 0xb8, 0x34, 0xa9, 0x38, 0xb9, 0x28, 0x33, 0x29, 0x95, 0x34, 0xad, 0x06,
-	0x8c, 0x49, 0x6c, 0x60, 0xd7, 0x1b, 0x03, 0x02, 0x00, 0x00, 0xff, 0xff, 0x25, 0x3b, 0x39, 0x24,
-	0xd4, 0x00, 0x00, 0x00,
+		GoTypes:           file_pubsubservice_proto_goTypes,
+		DependencyIndexes: file_pubsubservice_proto_depIdxs,
+		EnumInfos:         file_pubsubservice_proto_enumTypes,
+		MessageInfos:      file_pubsubservice_proto_msgTypes,
+	}.Build()
+	File_pubsubservice_proto = out.File
+	file_pubsubservice_proto_goTypes = nil
+	file_pubsubservice_proto_depIdxs = nil
 }
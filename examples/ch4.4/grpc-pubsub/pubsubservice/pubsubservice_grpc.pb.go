@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: pubsubservice.proto
+
+package pubsubservice
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PubsubService_Publish_FullMethodName           = "/pubsubservice.PubsubService/Publish"
+	PubsubService_Subscribe_FullMethodName         = "/pubsubservice.PubsubService/Subscribe"
+	PubsubService_Unsubscribe_FullMethodName       = "/pubsubservice.PubsubService/Unsubscribe"
+	PubsubService_ListSubscriptions_FullMethodName = "/pubsubservice.PubsubService/ListSubscriptions"
+	PubsubService_Ack_FullMethodName               = "/pubsubservice.PubsubService/Ack"
+)
+
+// PubsubServiceClient is the client API for PubsubService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PubsubServiceClient interface {
+	Publish(ctx context.Context, in *String, opts ...grpc.CallOption) (*PublishReply, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error)
+	// Unsubscribe ends the stream of a subscription.
+	Unsubscribe(ctx context.Context, in *UnsubscribeRequest, opts ...grpc.CallOption) (*UnsubscribeReply, error)
+	ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsReply, error)
+	// Ack acknowledges messages delivered to a member of a consumer group.
+	// It fails with PERMISSION_DENIED if id is not a member of group. The
+	// unknown seqs, such as the ones already acknowledged, are ignored.
+	Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckReply, error)
+}
+
+type pubsubServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPubsubServiceClient(cc grpc.ClientConnInterface) PubsubServiceClient {
+	return &pubsubServiceClient{cc}
+}
+
+func (c *pubsubServiceClient) Publish(ctx context.Context, in *String, opts ...grpc.CallOption) (*PublishReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishReply)
+	err := c.cc.Invoke(ctx, PubsubService_Publish_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pubsubServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Message], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PubsubService_ServiceDesc.Streams[0], PubsubService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, Message]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PubsubService_SubscribeClient = grpc.ServerStreamingClient[Message]
+
+func (c *pubsubServiceClient) Unsubscribe(ctx context.Context, in *UnsubscribeRequest, opts ...grpc.CallOption) (*UnsubscribeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnsubscribeReply)
+	err := c.cc.Invoke(ctx, PubsubService_Unsubscribe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pubsubServiceClient) ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSubscriptionsReply)
+	err := c.cc.Invoke(ctx, PubsubService_ListSubscriptions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pubsubServiceClient) Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckReply)
+	err := c.cc.Invoke(ctx, PubsubService_Ack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PubsubServiceServer is the server API for PubsubService service.
+// All implementations must embed UnimplementedPubsubServiceServer
+// for forward compatibility.
+type PubsubServiceServer interface {
+	Publish(context.Context, *String) (*PublishReply, error)
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error
+	// Unsubscribe ends the stream of a subscription.
+	Unsubscribe(context.Context, *UnsubscribeRequest) (*UnsubscribeReply, error)
+	ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsReply, error)
+	// Ack acknowledges messages delivered to a member of a consumer group.
+	// It fails with PERMISSION_DENIED if id is not a member of group. The
+	// unknown seqs, such as the ones already acknowledged, are ignored.
+	Ack(context.Context, *AckRequest) (*AckReply, error)
+	mustEmbedUnimplementedPubsubServiceServer()
+}
+
+// UnimplementedPubsubServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPubsubServiceServer struct{}
+
+func (UnimplementedPubsubServiceServer) Publish(context.Context, *String) (*PublishReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedPubsubServiceServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[Message]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedPubsubServiceServer) Unsubscribe(context.Context, *UnsubscribeRequest) (*UnsubscribeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unsubscribe not implemented")
+}
+func (UnimplementedPubsubServiceServer) ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscriptions not implemented")
+}
+func (UnimplementedPubsubServiceServer) Ack(context.Context, *AckRequest) (*AckReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ack not implemented")
+}
+func (UnimplementedPubsubServiceServer) mustEmbedUnimplementedPubsubServiceServer() {}
+func (UnimplementedPubsubServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafePubsubServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PubsubServiceServer will
+// result in compilation errors.
+type UnsafePubsubServiceServer interface {
+	mustEmbedUnimplementedPubsubServiceServer()
+}
+
+func RegisterPubsubServiceServer(s grpc.ServiceRegistrar, srv PubsubServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPubsubServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PubsubService_ServiceDesc, srv)
+}
+
+func _PubsubService_Publish_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(String)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PubsubServiceServer).Publish(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PubsubService_Publish_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PubsubServiceServer).Publish(ctx, req.(*String))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PubsubService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PubsubServiceServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, Message]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PubsubService_SubscribeServer = grpc.ServerStreamingServer[Message]
+
+func _PubsubService_Unsubscribe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnsubscribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PubsubServiceServer).Unsubscribe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PubsubService_Unsubscribe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PubsubServiceServer).Unsubscribe(ctx, req.(*UnsubscribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PubsubService_ListSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PubsubServiceServer).ListSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PubsubService_ListSubscriptions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PubsubServiceServer).ListSubscriptions(ctx, req.(*ListSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PubsubService_Ack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PubsubServiceServer).Ack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PubsubService_Ack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PubsubServiceServer).Ack(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PubsubService_ServiceDesc is the grpc.ServiceDesc for PubsubService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PubsubService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pubsubservice.PubsubService",
+	HandlerType: (*PubsubServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Publish",
+			Handler:    _PubsubService_Publish_Handler,
+		},
+		{
+			MethodName: "Unsubscribe",
+			Handler:    _PubsubService_Unsubscribe_Handler,
+		},
+		{
+			MethodName: "ListSubscriptions",
+			Handler:    _PubsubService_ListSubscriptions_Handler,
+		},
+		{
+			MethodName: "Ack",
+			Handler:    _PubsubService_Ack_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _PubsubService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pubsubservice.proto",
+}
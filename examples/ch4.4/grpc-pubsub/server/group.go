@@ -0,0 +1,314 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/msglog"
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/topics"
+)
+
+const (
+	defaultVisibilityTimeout = 30 * time.Second
+	defaultMaxDeliveries     = 5
+	deadLetterPrefix         = "dead-letter."
+)
+
+// groupIdleTimeout is how long a group without members is kept, with its
+// position and unacknowledged messages, before it is removed.
+var groupIdleTimeout = time.Minute
+
+// group hands each of its messages to one member and delivers it again
+// until it is acknowledged, or published to the dead letter topic after
+// maxDeliveries attempts. It reads the log from next, so the members see
+// nothing of the live deliveries of the other subscribers. A group only
+// takes the messages published on its node, not the ones forwarded by
+// the mesh. The groups live in memory and start over when the server
+// restarts, or once they have no member for groupIdleTimeout.
+type group struct {
+	name          string
+	topic         string
+	prefix        string
+	timeout       time.Duration
+	maxDeliveries int
+	deadLetter    string
+
+	mu      sync.Mutex
+	next    uint64               // seq of the next record to dispatch
+	pending map[uint64]*delivery // delivered, not acknowledged
+	expiry  []expiry             // pending by deadline, with stale entries
+	retry   []*delivery          // to deliver again, by seq
+	members []*member
+	rr      int // next member to try
+	timer   *time.Timer
+
+	idle *time.Timer // removes the group, guarded by PubsubService.mu
+}
+
+// delivery is a message of the group. member is nil while it waits to be
+// delivered again.
+type delivery struct {
+	rec      msglog.Record
+	attempts int
+	member   *member
+	deadline time.Time
+}
+
+type expiry struct {
+	d        *delivery
+	deadline time.Time
+}
+
+// member is a subscriber of a group. It sends the deliveries of queue
+// when woken up.
+type member struct {
+	sub      *subscriber
+	max      int // unacknowledged deliveries
+	inFlight int
+	queue    []*delivery
+	wake     chan struct{}
+}
+
+func newGroup(arg *pb.SubscribeRequest, next uint64) (*group, error) {
+	g := &group{
+		name:          arg.GetGroup(),
+		topic:         arg.GetTopic(),
+		prefix:        arg.GetValue(),
+		timeout:       time.Duration(arg.GetVisibilityTimeoutMs()) * time.Millisecond,
+		maxDeliveries: int(arg.GetMaxDeliveries()),
+		deadLetter:    arg.GetDeadLetterTopic(),
+		next:          next,
+		pending:       make(map[uint64]*delivery),
+	}
+	if g.timeout == 0 {
+		g.timeout = defaultVisibilityTimeout
+	}
+	if g.maxDeliveries == 0 {
+		g.maxDeliveries = defaultMaxDeliveries
+	}
+	if g.deadLetter == "" {
+		g.deadLetter = deadLetterPrefix + g.name
+	}
+	if err := topics.ValidTopic(g.deadLetter); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "dead letter topic %q: %v", g.deadLetter, err)
+	}
+	return g, nil
+}
+
+// join adds sub as a member. It must subscribe to the messages of the
+// group, and the options of arg must be those of the group or unset.
+func (g *group) join(sub *subscriber, arg *pb.SubscribeRequest, queueSize int) (*member, error) {
+	if sub.topic != g.topic || sub.prefix != g.prefix {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"group %q subscribes to topic %q, value %q", g.name, g.topic, g.prefix)
+	}
+	if ms := arg.GetVisibilityTimeoutMs(); ms != 0 && time.Duration(ms)*time.Millisecond != g.timeout {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"group %q has a visibility timeout of %v", g.name, g.timeout)
+	}
+	if n := arg.GetMaxDeliveries(); n != 0 && int(n) != g.maxDeliveries {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"group %q has max deliveries %d", g.name, g.maxDeliveries)
+	}
+	if topic := arg.GetDeadLetterTopic(); topic != "" && topic != g.deadLetter {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"group %q has the dead letter topic %q", g.name, g.deadLetter)
+	}
+
+	m := &member{sub: sub, max: queueSize, wake: make(chan struct{}, 1)}
+	g.mu.Lock()
+	g.members = append(g.members, m)
+	g.mu.Unlock()
+	return m, nil
+}
+
+// leave removes m, its pending messages are delivered again. It returns
+// the number of members left.
+func (g *group) leave(m *member) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, x := range g.members {
+		if x == m {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+	for _, d := range g.pending {
+		if d.member == m {
+			d.member = nil
+			g.retry = append(g.retry, d)
+		}
+	}
+	sort.Slice(g.retry, func(i, j int) bool { return g.retry[i].rec.Seq < g.retry[j].rec.Seq })
+	return len(g.members)
+}
+
+func (g *group) empty() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.members) == 0
+}
+
+// take returns a copy of the deliveries queued for m.
+func (g *group) take(m *member) []delivery {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var ds []delivery
+	for _, d := range m.queue {
+		if d.member == m { // not expired in the queue
+			ds = append(ds, *d)
+		}
+	}
+	m.queue = nil
+	return ds
+}
+
+// ack acknowledges the messages delivered to sub, the other seqs are
+// ignored.
+func (g *group) ack(sub *subscriber, seqs []uint64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, seq := range seqs {
+		d := g.pending[seq]
+		if d == nil || d.member == nil || d.member.sub != sub {
+			continue
+		}
+		delete(g.pending, seq)
+		d.member.inFlight--
+	}
+}
+
+func (g *group) unacked(sub *subscriber) uint32 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, m := range g.members {
+		if m.sub == sub {
+			return uint32(m.inFlight)
+		}
+	}
+	return 0
+}
+
+// dispatch delivers again the expired messages, then the new ones, to the
+// members with room. It returns the messages out of deliveries and sets
+// timer for the next deadline. The time is taken once g.mu is held, so a
+// dispatch waiting for another one doesn't stamp deadlines in the past.
+func (g *group) dispatch(l *msglog.Log) ([]*delivery, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+
+	var dead []*delivery
+	for len(g.expiry) > 0 && !g.expiry[0].deadline.After(now) {
+		e := g.expiry[0]
+		g.expiry = g.expiry[1:]
+		if g.pending[e.d.rec.Seq] != e.d || e.d.member == nil || e.d.deadline != e.deadline {
+			continue
+		}
+
+		e.d.member.inFlight--
+		e.d.member = nil
+		if e.d.attempts >= g.maxDeliveries {
+			delete(g.pending, e.d.rec.Seq)
+			dead = append(dead, e.d)
+		} else {
+			g.retry = append(g.retry, e.d)
+		}
+	}
+
+	for len(g.retry) > 0 {
+		m := g.pick()
+		if m == nil {
+			break
+		}
+		g.deliver(g.retry[0], m, now)
+		g.retry = g.retry[1:]
+	}
+
+	var err error
+	for g.next <= l.LastSeq() && g.room() {
+		var recs []msglog.Record
+		recs, err = l.Read(g.next, replayBatch)
+		if err == msglog.ErrCompacted {
+			g.next = l.FirstSeq()
+			continue
+		}
+		if err != nil || len(recs) == 0 {
+			break
+		}
+
+		for _, rec := range recs {
+			if g.match(rec) {
+				m := g.pick()
+				if m == nil {
+					break
+				}
+				d := &delivery{rec: rec}
+				g.pending[rec.Seq] = d
+				g.deliver(d, m, now)
+			}
+			g.next = rec.Seq + 1
+		}
+	}
+
+	if len(g.expiry) > 0 {
+		g.timer.Reset(g.expiry[0].deadline.Sub(now))
+	}
+	return dead, err
+}
+
+func (g *group) room() bool {
+	for _, m := range g.members {
+		if m.inFlight < m.max {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether rec is a message of the group, which never
+// receives its own dead letters. The messages forwarded by the mesh are
+// left to the groups of the node they were published on.
+func (g *group) match(rec msglog.Record) bool {
+	var msg pb.String
+	if err := proto.Unmarshal(rec.Data, &msg); err != nil {
+		return false
+	}
+	return msg.GetOrigin() == "" && msg.GetTopic() != g.deadLetter && matchFilter(g.topic, g.prefix, &msg)
+}
+
+// pick returns the next member with room, or nil.
+func (g *group) pick() *member {
+	for i := range g.members {
+		m := g.members[(g.rr+i)%len(g.members)]
+		if m.inFlight < m.max {
+			g.rr = (g.rr + i + 1) % len(g.members)
+			return m
+		}
+	}
+	return nil
+}
+
+func (g *group) deliver(d *delivery, m *member, now time.Time) {
+	d.attempts++
+	d.member = m
+	d.deadline = now.Add(g.timeout)
+	g.expiry = append(g.expiry, expiry{d: d, deadline: d.deadline})
+
+	m.inFlight++
+	m.queue = append(m.queue, d)
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+// receive runs a Subscribe stream until cancel, sending its messages on
+// the returned channel.
+func receive(t *testing.T, client pb.PubsubServiceClient, req *pb.SubscribeRequest) (<-chan *pb.Message, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream, err := client.Subscribe(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan *pb.Message, 100)
+	go func() {
+		defer close(ch)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			ch <- msg
+		}
+	}()
+	return ch, cancel
+}
+
+func next(t *testing.T, ch <-chan *pb.Message) *pb.Message {
+	t.Helper()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message")
+	}
+	return nil
+}
+
+func expectNothing(t *testing.T, ch <-chan *pb.Message, d time.Duration) {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		t.Fatalf("unexpected message: %v", msg)
+	case <-time.After(d):
+	}
+}
+
+func ack(t *testing.T, client pb.PubsubServiceClient, group, id string, seqs ...uint64) {
+	_, err := client.Ack(context.Background(), &pb.AckRequest{Group: group, Id: id, Seqs: seqs})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublishReply(t *testing.T) {
+	client := newTestClient(t, NewPubsubService(openLog(t, t.TempDir())))
+
+	var last int64
+	for i := 1; i <= 3; i++ {
+		reply, err := client.Publish(context.Background(), &pb.String{Value: "golang: a"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.GetSeq() != uint64(i) || reply.GetTimeUnixNano() < last || reply.GetTimeUnixNano() == 0 {
+			t.Fatalf("unexpected reply %d: %v", i, reply)
+		}
+		last = reply.GetTimeUnixNano()
+	}
+}
+
+func TestGroupShare(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	a, _ := receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers", Id: "a", VisibilityTimeoutMs: 100})
+	b, _ := receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers", Id: "b", VisibilityTimeoutMs: 100})
+	waitSubscribers(t, p, 2)
+
+	const n = 10
+	for i := 1; i <= n; i++ {
+		publish(t, client, fmt.Sprint("job: ", i), "other")
+	}
+
+	seen := make(map[uint64]bool)
+	for id, ch := range map[string]<-chan *pb.Message{"a": a, "b": b} {
+		for i := 0; i < n/2; i++ {
+			msg := next(t, ch)
+			if seen[msg.GetSeq()] || msg.GetAttempt() != 1 {
+				t.Fatalf("unexpected delivery: %v", msg)
+			}
+			seen[msg.GetSeq()] = true
+			ack(t, client, "workers", id, msg.GetSeq())
+		}
+	}
+
+	expectNothing(t, a, 300*time.Millisecond)
+	expectNothing(t, b, 0)
+
+	list, err := client.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range list.GetSubscriptions() {
+		if sub.GetGroup() != "workers" || sub.GetUnacked() != 0 {
+			t.Fatalf("unexpected subscription: %v", sub)
+		}
+	}
+}
+
+func TestGroupRedelivery(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	ch, _ := receive(t, client, &pb.SubscribeRequest{
+		Value:               "job:",
+		Group:               "workers",
+		Id:                  "a",
+		VisibilityTimeoutMs: 50,
+	})
+	waitSubscribers(t, p, 1)
+	publish(t, client, "job: a")
+
+	for attempt := uint32(1); attempt <= 2; attempt++ {
+		msg := next(t, ch)
+		if msg.GetSeq() != 1 || msg.GetAttempt() != attempt {
+			t.Fatalf("expect attempt %d of 1, got = %v", attempt, msg)
+		}
+	}
+	ack(t, client, "workers", "a", 1)
+	expectNothing(t, ch, 200*time.Millisecond)
+}
+
+func TestGroupDeadLetter(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	dead, _ := receive(t, client, &pb.SubscribeRequest{Topic: "dead-letter.workers"})
+	ch, _ := receive(t, client, &pb.SubscribeRequest{
+		Value:               "job:",
+		Group:               "workers",
+		VisibilityTimeoutMs: 30,
+		MaxDeliveries:       2,
+	})
+	waitSubscribers(t, p, 2)
+	publish(t, client, "job: a")
+
+	for attempt := uint32(1); attempt <= 2; attempt++ {
+		if msg := next(t, ch); msg.GetAttempt() != attempt {
+			t.Fatalf("expect attempt %d, got = %v", attempt, msg)
+		}
+	}
+	msg := next(t, dead)
+	if msg.GetSeq() != 2 || msg.GetValue() != "job: a" || msg.GetTopic() != "dead-letter.workers" {
+		t.Fatalf("unexpected dead letter: %v", msg)
+	}
+	expectNothing(t, ch, 100*time.Millisecond)
+}
+
+func TestGroupMemberLeave(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	req := &pb.SubscribeRequest{Value: "job:", Group: "workers", QueueSize: 1}
+	a, cancel := receive(t, client, req)
+	waitSubscribers(t, p, 1)
+	publish(t, client, "job: a")
+	if msg := next(t, a); msg.GetSeq() != 1 {
+		t.Fatalf("expect 1, got = %v", msg)
+	}
+
+	b, _ := receive(t, client, req)
+	waitSubscribers(t, p, 2)
+	expectNothing(t, b, 50*time.Millisecond)
+
+	cancel()
+	if msg := next(t, b); msg.GetSeq() != 1 || msg.GetAttempt() != 2 {
+		t.Fatalf("expect attempt 2 of 1, got = %v", msg)
+	}
+
+	stream := subscribe(t, client, &pb.SubscribeRequest{Value: "other:", Group: "workers"})
+	if _, err := stream.Recv(); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expect = %v, got = %v", codes.FailedPrecondition, err)
+	}
+	if _, err := client.Ack(context.Background(), &pb.AckRequest{Group: "nobody"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expect = %v, got = %v", codes.NotFound, err)
+	}
+}
+
+func TestGroupAckOwner(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	a, _ := receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers", Id: "a", QueueSize: 1})
+	b, _ := receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers", Id: "b", QueueSize: 1})
+	receive(t, client, &pb.SubscribeRequest{Value: "job:", Id: "plain"})
+	waitSubscribers(t, p, 3)
+	publish(t, client, "job: 1")
+	publish(t, client, "job: 2")
+
+	// each member has one message in flight, the acks of the other
+	// member, or of a non-member, do not count
+	ma, mb := next(t, a), next(t, b)
+	ack(t, client, "workers", "b", ma.GetSeq())
+	for _, id := range []string{"plain", "nobody", ""} {
+		_, err := client.Ack(context.Background(), &pb.AckRequest{Group: "workers", Id: id, Seqs: []uint64{ma.GetSeq()}})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("%q: expect = %v, got = %v", id, codes.PermissionDenied, err)
+		}
+	}
+	publish(t, client, "job: 3")
+	expectNothing(t, a, 100*time.Millisecond)
+
+	ack(t, client, "workers", "b", mb.GetSeq())
+	if msg := next(t, b); msg.GetValue() != "job: 3" {
+		t.Fatalf("expect = job: 3, got = %v", msg)
+	}
+}
+
+func TestGroupOptions(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	receive(t, client, &pb.SubscribeRequest{
+		Value:               "job:",
+		Group:               "workers",
+		VisibilityTimeoutMs: 100,
+		MaxDeliveries:       3,
+		DeadLetterTopic:     "failed",
+	})
+	waitSubscribers(t, p, 1)
+
+	for _, req := range []*pb.SubscribeRequest{
+		{Value: "job:", Group: "workers", VisibilityTimeoutMs: 200},
+		{Value: "job:", Group: "workers", MaxDeliveries: 5},
+		{Value: "job:", Group: "workers", DeadLetterTopic: "dead-letter.workers"},
+	} {
+		stream := subscribe(t, client, req)
+		if _, err := stream.Recv(); status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("%v: expect = %v, got = %v", req, codes.FailedPrecondition, err)
+		}
+	}
+
+	// the same options, or none, join the group
+	receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers"})
+	receive(t, client, &pb.SubscribeRequest{Value: "job:", Group: "workers", VisibilityTimeoutMs: 100, MaxDeliveries: 3})
+	waitSubscribers(t, p, 3)
+}
+
+func TestGroupIdle(t *testing.T) {
+	defer func(d time.Duration) { groupIdleTimeout = d }(groupIdleTimeout)
+	groupIdleTimeout = 50 * time.Millisecond
+
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+	groups := func() int {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.groups)
+	}
+
+	// a member coming back in time keeps the group
+	req := &pb.SubscribeRequest{Value: "job:", Group: "workers", Id: "a"}
+	_, cancel := receive(t, client, req)
+	waitSubscribers(t, p, 1)
+	cancel()
+	waitSubscribers(t, p, 0)
+	ch, cancel := receive(t, client, req)
+	waitSubscribers(t, p, 1)
+	time.Sleep(100 * time.Millisecond)
+	if n := groups(); n != 1 {
+		t.Fatalf("expect 1 group, got = %d", n)
+	}
+
+	publish(t, client, "job: 1")
+	next(t, ch)
+	cancel()
+	waitSubscribers(t, p, 0)
+	for i := 0; groups() != 0; i++ {
+		if i == 100 {
+			t.Fatal("expect the idle group removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
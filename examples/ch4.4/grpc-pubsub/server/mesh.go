@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+const (
+	meshMinBackoff = 100 * time.Millisecond
+	meshMaxBackoff = 2 * time.Second
+
+	// meshSaveInterval is how often a link saves the positions of the
+	// peers while it forwards messages.
+	meshSaveInterval = time.Second
+)
+
+// Peer is another PubsubService of the mesh.
+type Peer struct {
+	ID   string
+	Addr string
+}
+
+// Mesh forwards to a PubsubService the messages published on its peers.
+// Every node lists all the others: a node only forwards the messages of
+// its own publishers, so a message makes a single hop and never loops.
+// The links resume after the origin_seq of the last message forwarded
+// from the peer, which makes a reconnection free of duplicates. These
+// positions are saved every meshSaveInterval and when a link stops. A
+// peer whose log has a new ID lost its messages and counts again from 1,
+// so the link starts over from its first message.
+//
+// The consumer groups only take the messages published on their node: a
+// group of the same name on every node hands each message to a single
+// member of the mesh, one of the node it was published on.
+type Mesh struct {
+	p    *PubsubService
+	self string
+	dial func(addr string) (*grpc.ClientConn, error)
+
+	mu    sync.Mutex
+	links map[string]*link
+}
+
+type link struct {
+	peer   Peer
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewMesh(p *PubsubService, self string, dial func(addr string) (*grpc.ClientConn, error)) *Mesh {
+	return &Mesh{p: p, self: self, dial: dial, links: make(map[string]*link)}
+}
+
+// Update connects to the new peers and disconnects from the ones no
+// longer listed. The peer named self is skipped.
+func (m *Mesh) Update(peers []Peer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	want := make(map[string]Peer)
+	for _, peer := range peers {
+		if peer.ID != m.self {
+			want[peer.ID] = peer
+		}
+	}
+
+	for id, l := range m.links {
+		if peer, ok := want[id]; !ok || peer != l.peer {
+			l.cancel()
+			<-l.done
+			delete(m.links, id)
+		}
+	}
+	for id, peer := range want {
+		if m.links[id] == nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			l := &link{peer: peer, cancel: cancel, done: make(chan struct{})}
+			m.links[id] = l
+			go m.run(ctx, l)
+		}
+	}
+}
+
+// Close disconnects from all the peers.
+func (m *Mesh) Close() {
+	m.Update(nil)
+}
+
+// run forwards the messages of l.peer until ctx is canceled, reconnecting
+// with an exponential backoff.
+func (m *Mesh) run(ctx context.Context, l *link) {
+	defer close(l.done)
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		m.save(ctx)
+	}()
+	defer func() { <-saved }()
+
+	backoff := meshMinBackoff
+	for {
+		connected, err := m.forward(ctx, l.peer)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("mesh: peer %s: %v", l.peer.ID, err)
+
+		if connected {
+			backoff = meshMinBackoff
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > meshMaxBackoff {
+			backoff = meshMaxBackoff
+		}
+	}
+}
+
+// save saves the positions of the peers every meshSaveInterval until ctx
+// is canceled.
+func (m *Mesh) save(ctx context.Context) {
+	t := time.NewTicker(meshSaveInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.p.saveOrigins()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// forward subscribes to the local messages of peer and publishes them
+// with their origin. It reports whether the subscription was accepted.
+func (m *Mesh) forward(ctx context.Context, peer Peer) (bool, error) {
+	conn, err := m.dial(peer.Addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	client := pb.NewPubsubServiceClient(conn)
+	pos := m.p.position(peer.ID)
+	stream, logID, stop, err := subscribeLocal(ctx, client, pos.Seq+1)
+	if err != nil {
+		return false, err
+	}
+	if logID != pos.LogID && pos.Seq > 0 {
+		log.Printf("mesh: peer %s: new log %s, forwarding from its first message", peer.ID, logID)
+		stop()
+		stream, logID, stop, err = subscribeLocal(ctx, client, 1)
+		if err != nil {
+			return false, err
+		}
+	}
+	defer stop()
+	defer m.p.saveOrigins()
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return true, err
+		}
+		if gap := msg.GetGap(); gap != nil {
+			log.Printf("mesh: peer %s: messages %d to %d lost",
+				peer.ID, gap.GetFirstSeq(), gap.GetLastSeq())
+			continue
+		}
+
+		_, err = m.p.publish(&pb.String{
+			Value:       msg.GetValue(),
+			Topic:       msg.GetTopic(),
+			Payload:     msg.GetPayload(),
+			Origin:      peer.ID,
+			OriginSeq:   msg.GetSeq(),
+			OriginLogId: logID,
+		})
+		if err != nil && err != errDuplicate {
+			return true, err
+		}
+	}
+}
+
+// subscribeLocal subscribes to the local messages of a peer from seq
+// start, and returns the ID of the log of the peer. stop ends the stream.
+func subscribeLocal(ctx context.Context, client pb.PubsubServiceClient, start uint64) (
+	stream pb.PubsubService_SubscribeClient, logID string, stop context.CancelFunc, err error,
+) {
+	ctx, stop = context.WithCancel(ctx)
+	stream, err = client.Subscribe(ctx, &pb.SubscribeRequest{StartSeq: start, LocalOnly: true})
+	if err != nil {
+		stop()
+		return nil, "", nil, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		stop()
+		return nil, "", nil, err
+	}
+	if ids := header.Get(LogIDHeader); len(ids) > 0 {
+		logID = ids[0]
+	}
+	return stream, logID, stop, nil
+}
+
+// parsePeers parses a list of peers such as "a=host1:1234,b=host2:1234".
+func parsePeers(s string) ([]Peer, error) {
+	var peers []Peer
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		i := strings.Index(item, "=")
+		if i <= 0 || i == len(item)-1 {
+			return nil, fmt.Errorf("mesh: invalid peer %q", item)
+		}
+		peers = append(peers, Peer{ID: item[:i], Addr: item[i+1:]})
+	}
+	return peers, nil
+}
+
+// readRegistry reads a file of "<id> <addr>" lines. The empty lines and
+// the ones starting with # are skipped.
+func readRegistry(path string) ([]Peer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var peers []Peer
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("mesh: %s:%d: expect <id> <addr>", path, n)
+		}
+		peers = append(peers, Peer{ID: fields[0], Addr: fields[1]})
+	}
+	return peers, scanner.Err()
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/msglog"
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+// testNetwork connects the mesh nodes over in-memory listeners, by id.
+type testNetwork struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+func (n *testNetwork) dial(addr string) (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///"+addr,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			n.mu.Lock()
+			lis := n.listeners[addr]
+			n.mu.Unlock()
+			if lis == nil {
+				return nil, errors.New("unreachable")
+			}
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+}
+
+type testNode struct {
+	id     string
+	p      *PubsubService
+	mesh   *Mesh
+	srv    *grpc.Server
+	client pb.PubsubServiceClient // not cut by a partition
+	sub    <-chan *pb.Message
+}
+
+func (n *testNetwork) start(t *testing.T, id string) *testNode {
+	node := &testNode{id: id, p: NewPubsubService(openLog(t, t.TempDir()))}
+	node.mesh = NewMesh(node.p, id, n.dial)
+	t.Cleanup(node.mesh.Close)
+	node.client = newTestClient(t, node.p)
+	node.sub, _ = receive(t, node.client, &pb.SubscribeRequest{Value: "m:"})
+	n.serve(t, node)
+	return node
+}
+
+func (n *testNetwork) serve(t *testing.T, node *testNode) {
+	lis := bufconn.Listen(1 << 20)
+	node.srv = grpc.NewServer()
+	pb.RegisterPubsubServiceServer(node.srv, node.p)
+	go node.srv.Serve(lis)
+	t.Cleanup(node.srv.Stop)
+
+	n.mu.Lock()
+	n.listeners[node.id] = lis
+	n.mu.Unlock()
+}
+
+// partition cuts node from its peers, in both directions.
+func (n *testNetwork) partition(node *testNode) {
+	n.mu.Lock()
+	delete(n.listeners, node.id)
+	n.mu.Unlock()
+
+	node.srv.Stop()
+	node.mesh.Update(nil)
+}
+
+func (n *testNetwork) rejoin(t *testing.T, node *testNode, peers []Peer) {
+	n.serve(t, node)
+	node.mesh.Update(peers)
+}
+
+// waitLinks waits until n subscriptions are registered, the test
+// subscriber and the links of the peers.
+func waitLinks(t *testing.T, p *PubsubService, n int) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		p.mu.Lock()
+		m := len(p.subs)
+		p.mu.Unlock()
+		if m == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expect %d subscriptions", n)
+}
+
+// expectValues receives the values, in any order, and nothing else.
+func expectValues(t *testing.T, node *testNode, expect ...string) {
+	t.Helper()
+	var got []string
+	for range expect {
+		msg := next(t, node.sub)
+		got = append(got, msg.GetValue())
+	}
+	expectNothing(t, node.sub, 100*time.Millisecond)
+
+	sort.Strings(got)
+	sort.Strings(expect)
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("node %s: expect = %q, got = %q", node.id, expect, got)
+	}
+}
+
+func TestMeshPartition(t *testing.T) {
+	network := &testNetwork{listeners: make(map[string]*bufconn.Listener)}
+	a, b, c := network.start(t, "a"), network.start(t, "b"), network.start(t, "c")
+	nodes := []*testNode{a, b, c}
+
+	peers := []Peer{{"a", "a"}, {"b", "b"}, {"c", "c"}}
+	for _, node := range nodes {
+		node.mesh.Update(peers)
+	}
+	for _, node := range nodes {
+		waitLinks(t, node.p, 3)
+	}
+
+	publish(t, a.client, "m: a1")
+	publish(t, b.client, "m: b1")
+	publish(t, c.client, "m: c1")
+	for _, node := range nodes {
+		expectValues(t, node, "m: a1", "m: b1", "m: c1")
+	}
+
+	network.partition(c)
+	waitLinks(t, a.p, 2)
+	waitLinks(t, b.p, 2)
+	waitLinks(t, c.p, 1)
+
+	publish(t, a.client, "m: a2")
+	publish(t, c.client, "m: c2")
+	expectValues(t, a, "m: a2")
+	expectValues(t, b, "m: a2")
+	expectValues(t, c, "m: c2")
+
+	network.rejoin(t, c, peers)
+	for _, node := range nodes {
+		waitLinks(t, node.p, 3)
+	}
+	expectValues(t, a, "m: c2")
+	expectValues(t, b, "m: c2")
+	expectValues(t, c, "m: a2")
+
+	for _, node := range nodes {
+		if last := node.p.log.LastSeq(); last != 5 {
+			t.Fatalf("node %s: expect 5 messages, got = %d", node.id, last)
+		}
+	}
+}
+
+func TestOriginsAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	l, err := msglog.Open(dir, msglog.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPubsubService(l)
+	if _, err := p.publish(&pb.String{Value: "m: x", Origin: "a", OriginSeq: 3, OriginLogId: "log1"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	p = NewPubsubService(openLog(t, dir))
+	expect := position{LogID: "log1", Seq: 3, LocalSeq: 1}
+	if pos := p.position("a"); pos != expect {
+		t.Fatalf("expect = %v, got = %v", expect, pos)
+	}
+	if _, err := p.publish(&pb.String{Value: "m: x", Origin: "a", OriginSeq: 3, OriginLogId: "log1"}); err != errDuplicate {
+		t.Fatalf("expect = %v, got = %v", errDuplicate, err)
+	}
+
+	// a new log of the peer counts again from 1
+	if _, err := p.publish(&pb.String{Value: "m: z", Origin: "a", OriginSeq: 1, OriginLogId: "log2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []*pb.String{
+		{Value: "m: y", Origin: "a", OriginSeq: 4},
+		{Value: "m: y", OriginLogId: "log1"},
+	} {
+		_, err = p.Publish(context.Background(), msg)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expect = %v, got = %v", codes.InvalidArgument, err)
+		}
+	}
+}
+
+func TestOriginsAfterRetention(t *testing.T) {
+	dir := t.TempDir()
+	opts := msglog.Options{SegmentBytes: 1, MaxBytes: 1} // the last record only
+	l, err := msglog.Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPubsubService(l)
+	if _, err := p.publish(&pb.String{Value: "m: x", Origin: "a", OriginSeq: 3, OriginLogId: "log1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, originsFile)); !os.IsNotExist(err) {
+		t.Fatalf("expect no %s before saveOrigins, got = %v", originsFile, err)
+	}
+	p.saveOrigins()
+	if _, err := p.publish(&pb.String{Value: "m: local"}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, err = msglog.Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if first := l.FirstSeq(); first != 2 {
+		t.Fatalf("expect the forwarded message removed, got first seq = %d", first)
+	}
+	p = NewPubsubService(l)
+	if _, err := p.publish(&pb.String{Value: "m: x", Origin: "a", OriginSeq: 3, OriginLogId: "log1"}); err != errDuplicate {
+		t.Fatalf("expect = %v, got = %v", errDuplicate, err)
+	}
+}
+
+func TestMeshPeerReset(t *testing.T) {
+	network := &testNetwork{listeners: make(map[string]*bufconn.Listener)}
+	a, b := network.start(t, "a"), network.start(t, "b")
+	peers := []Peer{{"a", "a"}, {"b", "b"}}
+	a.mesh.Update(peers)
+	b.mesh.Update(peers)
+	waitLinks(t, a.p, 2)
+	waitLinks(t, b.p, 2)
+
+	publish(t, a.client, "m: a1")
+	publish(t, a.client, "m: a2")
+	expectValues(t, b, "m: a1", "m: a2")
+
+	// a comes back with an empty log, its seqs start over
+	network.partition(a)
+	a.mesh.Close()
+	a = network.start(t, "a")
+	a.mesh.Update(peers)
+	waitLinks(t, a.p, 2)
+	waitLinks(t, b.p, 2)
+
+	publish(t, a.client, "m: a3")
+	expectValues(t, b, "m: a3")
+	if pos := b.p.position("a"); pos.LogID != a.p.log.ID() || pos.Seq != 1 {
+		t.Fatalf("expect = %s 1, got = %v", a.p.log.ID(), pos)
+	}
+}
+
+func TestMeshGroups(t *testing.T) {
+	network := &testNetwork{listeners: make(map[string]*bufconn.Listener)}
+	a, b := network.start(t, "a"), network.start(t, "b")
+	peers := []Peer{{"a", "a"}, {"b", "b"}}
+	a.mesh.Update(peers)
+	b.mesh.Update(peers)
+
+	type node struct {
+		*testNode
+		worker, dead <-chan *pb.Message
+	}
+	var nodes []node
+	for _, n := range []*testNode{a, b} {
+		worker, _ := receive(t, n.client, &pb.SubscribeRequest{
+			Value:               "job:",
+			Group:               "workers",
+			VisibilityTimeoutMs: 30,
+			MaxDeliveries:       1,
+		})
+		dead, _ := receive(t, n.client, &pb.SubscribeRequest{Topic: "dead-letter.workers"})
+		nodes = append(nodes, node{n, worker, dead})
+	}
+	waitLinks(t, a.p, 4)
+	waitLinks(t, b.p, 4)
+
+	// the group of a takes the job, the one of b leaves it to a; the dead
+	// letter of a is a new message, forwarded to b
+	publish(t, a.client, "job: a")
+	if msg := next(t, nodes[0].worker); msg.GetValue() != "job: a" {
+		t.Fatalf("expect = job: a, got = %v", msg)
+	}
+	for _, n := range nodes {
+		msg := next(t, n.dead)
+		if msg.GetValue() != "job: a" || msg.GetTopic() != "dead-letter.workers" {
+			t.Fatalf("node %s: unexpected dead letter: %v", n.id, msg)
+		}
+		expectNothing(t, n.dead, 100*time.Millisecond)
+	}
+	expectNothing(t, nodes[0].worker, 0)
+	expectNothing(t, nodes[1].worker, 0)
+}
+
+func TestReadRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers")
+	data := "# mesh\na host1:1234\n\nb  host2:1234\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := readRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	static, err := parsePeers("a=host1:1234, b=host2:1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Peer{{"a", "host1:1234"}, {"b", "host2:1234"}}
+	if !reflect.DeepEqual(peers, expect) || !reflect.DeepEqual(static, expect) {
+		t.Fatalf("expect = %v, got = %v and %v", expect, peers, static)
+	}
+
+	if _, err := parsePeers("a"); err == nil {
+		t.Fatal("expect error")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+// originsFile keeps the positions of the mesh peers in the directory of
+// the log. Retention removes the forwarded messages, not their positions.
+// The mesh saves them from time to time and when a link stops; the ones
+// not saved yet are found again in the log by loadOrigins.
+const originsFile = "origins.json"
+
+// position is the last message forwarded from a peer: its seq in the log
+// of the peer with the ID LogID, and the seq it was logged at here.
+type position struct {
+	LogID    string `json:"log_id"`
+	Seq      uint64 `json:"seq"`
+	LocalSeq uint64 `json:"local_seq"`
+}
+
+// position returns the position of peer.
+func (p *PubsubService) position(peer string) position {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.origins[peer]
+}
+
+// loadOrigins reads the positions saved by saveOrigins, then moves them
+// to the forwarded messages logged after them, which a crash may have
+// left unsaved.
+func (p *PubsubService) loadOrigins() {
+	data, err := os.ReadFile(filepath.Join(p.log.Dir(), originsFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &p.origins); err != nil {
+			log.Printf("mesh: %s: %v", originsFile, err)
+		}
+	case !os.IsNotExist(err):
+		log.Printf("mesh: %v", err)
+	}
+	if p.origins == nil {
+		p.origins = make(map[string]position)
+	}
+
+	for seq := p.log.FirstSeq(); seq <= p.log.LastSeq(); {
+		recs, err := p.log.Read(seq, replayBatch)
+		if err != nil || len(recs) == 0 {
+			return
+		}
+		for _, rec := range recs {
+			var msg pb.String
+			if proto.Unmarshal(rec.Data, &msg) == nil && msg.GetOrigin() != "" &&
+				rec.Seq > p.origins[msg.GetOrigin()].LocalSeq {
+				p.origins[msg.GetOrigin()] = position{
+					LogID:    msg.GetOriginLogId(),
+					Seq:      msg.GetOriginSeq(),
+					LocalSeq: rec.Seq,
+				}
+				p.originsNew = true
+			}
+			seq = rec.Seq + 1
+		}
+	}
+}
+
+// saveOrigins writes the positions changed since the last call to
+// originsFile, replacing it in one step. The file is written without
+// p.mu, so the publishers don't wait for it.
+func (p *PubsubService) saveOrigins() {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	p.mu.Lock()
+	if !p.originsNew {
+		p.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(p.origins)
+	p.originsNew = false
+	p.mu.Unlock()
+
+	if err == nil {
+		path := filepath.Join(p.log.Dir(), originsFile)
+		if err = os.WriteFile(path+".tmp", data, 0644); err == nil {
+			err = os.Rename(path+".tmp", path)
+		}
+	}
+	if err != nil {
+		log.Printf("mesh: %v", err)
+		p.mu.Lock()
+		p.originsNew = true // tried again by the next call
+		p.mu.Unlock()
+	}
+}
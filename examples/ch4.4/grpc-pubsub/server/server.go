@@ -2,69 +2,619 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/docker/docker/pkg/pubsub"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/msglog"
 	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/topics"
 )
 
+var (
+	flagAddr           = flag.String("addr", ":1234", "listen address")
+	flagDir            = flag.String("dir", "./pubsub-data", "directory of the message log")
+	flagRetentionBytes = flag.Int64("retention-bytes", 0, "max size of the message log, 0 for no limit")
+	flagRetentionAge   = flag.Duration("retention-age", 0, "max age of the logged messages, 0 for no limit")
+	flagID             = flag.String("id", "", "name of the node in the mesh")
+	flagPeers          = flag.String("peers", "", `mesh peers such as "a=host1:1234,b=host2:1234"`)
+	flagRegistry       = flag.String("registry", "", `file of the mesh peers, "<id> <addr>" per line, reread every 5s`)
+)
+
+var errDuplicate = errors.New("pubsub: message already forwarded")
+
+const (
+	// replayBatch is the number of records read from the log at once.
+	replayBatch = 128
+
+	// SubscriptionIDHeader is the header carrying the subscription id of
+	// a Subscribe stream.
+	SubscriptionIDHeader = "pubsub-subscription-id"
+
+	// LogIDHeader is the header carrying the ID of the log the seqs of a
+	// Subscribe stream refer to.
+	LogIDHeader = "pubsub-log-id"
+)
+
+// PubsubService appends the published messages to a log. A subscriber
+// replays the log from its start offset, then receives the new messages
+// as they are published.
 type PubsubService struct {
-	pub *pubsub.Publisher
+	pb.UnimplementedPubsubServiceServer
+
+	log *msglog.Log
+
+	// deliverMu orders the live deliveries. It is held while a BLOCK
+	// subscriber makes the publisher wait, unlike mu.
+	deliverMu sync.Mutex
+
+	mu         sync.Mutex // orders the appends, protects the subscribers
+	subs       map[string]*subscriber
+	topics     *topics.Trie             // subscribers by topic pattern
+	prefixSubs map[*subscriber]struct{} // subscribers matching a prefix of value
+	groups     map[string]*group
+	origins    map[string]position // last message forwarded by peer
+	originsNew bool                // origins changed since saveOrigins
+	lastID     uint64
+
+	saveMu sync.Mutex // orders the writes of originsFile, unlike mu
 }
 
-func NewPubsubService() *PubsubService {
-	return &PubsubService{
-		pub: pubsub.NewPublisher(100*time.Millisecond, 10),
+func NewPubsubService(l *msglog.Log) *PubsubService {
+	p := &PubsubService{
+		log:        l,
+		subs:       make(map[string]*subscriber),
+		topics:     topics.NewTrie(),
+		prefixSubs: make(map[*subscriber]struct{}),
+		groups:     make(map[string]*group),
+		origins:    make(map[string]position),
 	}
+	p.loadOrigins()
+	return p
 }
 
 func (p *PubsubService) Publish(
 	ctx context.Context, arg *pb.String,
-) (*pb.String, error) {
-	p.pub.Publish(arg.GetValue())
-	//debug
-	//reply := &String{Value: "<Publish>  " + arg.GetValue()}
-	//fmt.Println(reply.GetValue())
-	return &pb.String{}, nil
+) (*pb.PublishReply, error) {
+	if topic := arg.GetTopic(); topic != "" {
+		if err := topics.ValidTopic(topic); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if arg.GetOrigin() != "" || arg.GetOriginSeq() != 0 || arg.GetOriginLogId() != "" {
+		return nil, status.Error(codes.InvalidArgument, "origin is set by the mesh")
+	}
+
+	rec, err := p.publish(arg)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PublishReply{Seq: rec.Seq, TimeUnixNano: rec.Time.UnixNano()}, nil
+}
+
+// publish logs msg, delivers it to the subscribers, then to the consumer
+// groups.
+func (p *PubsubService) publish(msg *pb.String) (msglog.Record, error) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return msglog.Record{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	p.deliverMu.Lock()
+	rec, subs, err := p.append(msg, data)
+	if err == errDuplicate {
+		p.deliverMu.Unlock()
+		return rec, err
+	}
+	if err != nil {
+		p.deliverMu.Unlock()
+		return rec, status.Error(codes.Unavailable, err.Error())
+	}
+	for _, sub := range subs {
+		sub.push(rec)
+	}
+	p.deliverMu.Unlock()
+
+	p.mu.Lock()
+	groups := make([]*group, 0, len(p.groups))
+	for _, g := range p.groups {
+		groups = append(groups, g)
+	}
+	p.mu.Unlock()
+
+	for _, g := range groups {
+		p.dispatch(g)
+	}
+	return rec, nil
+}
+
+// append logs data and returns the subscribers of msg, all registered
+// before the record was appended. A forwarded message already logged is
+// skipped with errDuplicate, unless it comes from a new log of its
+// origin, whose seqs started over.
+func (p *PubsubService) append(msg *pb.String, data []byte) (msglog.Record, []*subscriber, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	origin := msg.GetOrigin()
+	if origin != "" {
+		pos := p.origins[origin]
+		if msg.GetOriginLogId() == pos.LogID && msg.GetOriginSeq() <= pos.Seq {
+			return msglog.Record{}, nil, errDuplicate
+		}
+	}
+
+	rec, err := p.log.Append(data)
+	if err != nil {
+		return rec, nil, err
+	}
+	if origin != "" {
+		p.origins[origin] = position{LogID: msg.GetOriginLogId(), Seq: msg.GetOriginSeq(), LocalSeq: rec.Seq}
+		p.originsNew = true
+	}
+
+	var subs []*subscriber
+	if topic := msg.GetTopic(); topic != "" {
+		p.topics.Match(topic, func(v interface{}) {
+			if sub := v.(*subscriber); !sub.local || origin == "" {
+				subs = append(subs, sub)
+			}
+		})
+	}
+	for sub := range p.prefixSubs {
+		if (!sub.local || origin == "") && strings.HasPrefix(msg.GetValue(), sub.prefix) {
+			subs = append(subs, sub)
+		}
+	}
+	return rec, subs, nil
 }
 
 func (p *PubsubService) Subscribe(
-	arg *pb.String, stream pb.PubsubService_SubscribeServer,
+	arg *pb.SubscribeRequest, stream pb.PubsubService_SubscribeServer,
 ) error {
-	ch := p.pub.SubscribeTopic(func(v interface{}) bool {
-		if key, ok := v.(string); ok {
-			//debug
-			//fmt.Printf("<debug> %t %s %s %t\n",
-			//	ok,arg.GetValue(),key,strings.HasPrefix(key,arg.GetValue()))
-			if strings.HasPrefix(key, arg.GetValue()) {
-				return true
+	if arg.GetTopic() != "" {
+		if err := topics.ValidPattern(arg.GetTopic()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	sub := newSubscriber(stream.Context(), arg)
+	if pr, ok := peer.FromContext(stream.Context()); ok {
+		sub.peer = pr.Addr.String()
+	}
+	if arg.GetGroup() != "" {
+		return p.subscribeGroup(arg, stream, sub)
+	}
+
+	head, err := p.addSubscriber(sub, arg.GetId())
+	if err != nil {
+		return err
+	}
+	defer p.removeSubscriber(sub)
+
+	if err := stream.SendHeader(metadata.Pairs(SubscriptionIDHeader, sub.id, LogIDHeader, p.log.ID())); err != nil {
+		return err
+	}
+
+	next := arg.GetStartSeq()
+	if next == 0 {
+		next = head + 1
+	}
+
+	for {
+		if next <= head {
+			next, err = p.replay(stream, sub, next, head)
+			if err != nil {
+				return err
 			}
 		}
+		atomic.StoreUint64(&sub.next, next)
+
+		select {
+		case rec := <-sub.ch:
+			if err := sendGap(stream, sub, rec.Seq); err != nil {
+				return err
+			}
+			if rec.Seq >= next {
+				if err := send(stream, rec); err != nil {
+					return err
+				}
+				next = rec.Seq + 1
+				atomic.StoreUint64(&sub.next, next)
+			}
+			if len(sub.ch) == 0 {
+				if err := sendGap(stream, sub, 0); err != nil {
+					return err
+				}
+			}
+		case <-sub.done:
+			return sub.err
+		case <-stream.Context().Done():
+			return nil
+		}
+
+		if last, ok := sub.catchUp(p.log); ok {
+			head = last
+		}
+	}
+}
+
+// addSubscriber registers sub under id, or a new id if empty, and returns
+// the seq of the latest message, after which sub receives the live ones.
+func (p *PubsubService) addSubscriber(sub *subscriber, id string) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if id == "" {
+		for id == "" || p.subs[id] != nil {
+			p.lastID++
+			id = "sub-" + strconv.FormatUint(p.lastID, 10)
+		}
+	}
+	if p.subs[id] != nil {
+		return 0, status.Errorf(codes.AlreadyExists, "subscription %q already exists", id)
+	}
+
+	sub.id = id
+	p.subs[id] = sub
+	switch {
+	case sub.group != nil:
+		// the group dispatches the messages of its members
+	case sub.topic != "":
+		p.topics.Add(sub.topic, sub)
+	default:
+		p.prefixSubs[sub] = struct{}{}
+	}
+	return p.log.LastSeq(), nil
+}
+
+// removeSubscriber stops the live deliveries to sub and reports whether
+// it was registered.
+func (p *PubsubService) removeSubscriber(sub *subscriber) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.subs[sub.id] != sub {
 		return false
+	}
+	delete(p.subs, sub.id)
+	switch {
+	case sub.group != nil:
+	case sub.topic != "":
+		p.topics.Remove(sub.topic, sub)
+	default:
+		delete(p.prefixSubs, sub)
+	}
+	return true
+}
+
+func (p *PubsubService) Unsubscribe(
+	ctx context.Context, arg *pb.UnsubscribeRequest,
+) (*pb.UnsubscribeReply, error) {
+	p.mu.Lock()
+	sub := p.subs[arg.GetId()]
+	p.mu.Unlock()
+
+	if sub == nil || !p.removeSubscriber(sub) {
+		return nil, status.Errorf(codes.NotFound, "subscription %q not found", arg.GetId())
+	}
+	sub.close(nil)
+	return &pb.UnsubscribeReply{}, nil
+}
+
+func (p *PubsubService) Ack(
+	ctx context.Context, arg *pb.AckRequest,
+) (*pb.AckReply, error) {
+	p.mu.Lock()
+	g := p.groups[arg.GetGroup()]
+	sub := p.subs[arg.GetId()]
+	p.mu.Unlock()
+
+	if g == nil {
+		return nil, status.Errorf(codes.NotFound, "group %q not found", arg.GetGroup())
+	}
+	if sub == nil || sub.group != g {
+		return nil, status.Errorf(codes.PermissionDenied,
+			"subscription %q is not a member of group %q", arg.GetId(), arg.GetGroup())
+	}
+	g.ack(sub, arg.GetSeqs())
+	p.dispatch(g)
+	return &pb.AckReply{}, nil
+}
+
+// subscribeGroup sends the messages dispatched to sub as a member of its
+// group.
+func (p *PubsubService) subscribeGroup(
+	arg *pb.SubscribeRequest, stream pb.PubsubService_SubscribeServer, sub *subscriber,
+) error {
+	g, m, err := p.join(arg, sub)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		p.mu.Lock()
+		if g.leave(m) == 0 {
+			p.idleGroup(g)
+		}
+		p.mu.Unlock()
+		p.dispatch(g)
+	}()
+
+	sub.group = g
+	if _, err := p.addSubscriber(sub, arg.GetId()); err != nil {
+		return err
+	}
+	defer p.removeSubscriber(sub)
+
+	if err := stream.SendHeader(metadata.Pairs(SubscriptionIDHeader, sub.id, LogIDHeader, p.log.ID())); err != nil {
+		return err
+	}
+	p.dispatch(g)
+
+	for {
+		select {
+		case <-m.wake:
+			for _, d := range g.take(m) {
+				msg, err := message(d.rec)
+				if err != nil {
+					return err
+				}
+				msg.Attempt = uint32(d.attempts)
+				if err := stream.Send(msg); err != nil {
+					return err
+				}
+			}
+		case <-sub.done:
+			return sub.err
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// join adds sub to the group of arg, created if needed. A new group
+// starts at start_seq, or after the latest message.
+func (p *PubsubService) join(arg *pb.SubscribeRequest, sub *subscriber) (*group, *member, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	g := p.groups[arg.GetGroup()]
+	if g == nil {
+		next := arg.GetStartSeq()
+		if next == 0 {
+			next = p.log.LastSeq() + 1
+		}
+		var err error
+		if g, err = newGroup(arg, next); err != nil {
+			return nil, nil, err
+		}
+		g.timer = time.AfterFunc(g.timeout, func() { p.dispatch(g) })
+		p.groups[g.name] = g
+	}
+
+	m, err := g.join(sub, arg, cap(sub.ch))
+	if err != nil {
+		if g.empty() {
+			p.idleGroup(g)
+		}
+		return nil, nil, err
+	}
+	if g.idle != nil {
+		g.idle.Stop()
+		g.idle = nil
+	}
+	return g, m, nil
+}
+
+// idleGroup removes g, which has no member, after groupIdleTimeout unless
+// a member joins it first. p.mu must be held.
+func (p *PubsubService) idleGroup(g *group) {
+	if g.idle == nil {
+		g.idle = time.AfterFunc(groupIdleTimeout, func() { p.removeGroup(g) })
+	}
+}
+
+func (p *PubsubService) removeGroup(g *group) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.groups[g.name] != g || !g.empty() {
+		return
+	}
+	delete(p.groups, g.name)
+	g.timer.Stop()
+}
+
+// dispatch runs the deliveries of g and publishes its dead letters.
+func (p *PubsubService) dispatch(g *group) {
+	dead, err := g.dispatch(p.log)
+	if err != nil {
+		log.Printf("group %s: %v", g.name, err)
+	}
+	for _, d := range dead {
+		var msg pb.String
+		if err := proto.Unmarshal(d.rec.Data, &msg); err != nil {
+			continue
+		}
+		// a new local message, which the mesh forwards as such
+		msg.Topic = g.deadLetter
+		msg.Origin, msg.OriginSeq, msg.OriginLogId = "", 0, ""
+		if _, err := p.publish(&msg); err != nil {
+			log.Printf("group %s: dead letter %d: %v", g.name, d.rec.Seq, err)
+		}
+	}
+}
+
+func (p *PubsubService) ListSubscriptions(
+	ctx context.Context, arg *pb.ListSubscriptionsRequest,
+) (*pb.ListSubscriptionsReply, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reply := &pb.ListSubscriptionsReply{}
+	for _, sub := range p.subs {
+		reply.Subscriptions = append(reply.Subscriptions, sub.info(p.log))
+	}
+	sort.Slice(reply.Subscriptions, func(i, j int) bool {
+		return reply.Subscriptions[i].Id < reply.Subscriptions[j].Id
 	})
+	return reply, nil
+}
 
-	for v := range ch {
-		if err := stream.Send(&pb.String{Value: v.(string)}); err != nil {
-			return err
+// replay sends the records from next to head matching sub and returns
+// the seq following the last one read. The records removed by retention
+// are reported with a gap notification.
+func (p *PubsubService) replay(
+	stream pb.PubsubService_SubscribeServer, sub *subscriber, next, head uint64,
+) (uint64, error) {
+	for next <= head {
+		recs, err := p.log.Read(next, replayBatch)
+		if err == msglog.ErrCompacted {
+			first := p.log.FirstSeq()
+			gap := &pb.Gap{FirstSeq: next, LastSeq: first - 1}
+			if err := stream.Send(&pb.Message{Gap: gap}); err != nil {
+				return next, err
+			}
+			next = first
+			continue
 		}
+		if err != nil {
+			return next, status.Error(codes.Unavailable, err.Error())
+		}
+		if len(recs) == 0 {
+			break
+		}
+
+		for _, rec := range recs {
+			if rec.Seq > head {
+				break
+			}
+			if matchRecord(rec, sub) {
+				if err := send(stream, rec); err != nil {
+					return next, err
+				}
+			}
+			next = rec.Seq + 1
+		}
+	}
+	return next, nil
+}
+
+func matchRecord(rec msglog.Record, sub *subscriber) bool {
+	var msg pb.String
+	if err := proto.Unmarshal(rec.Data, &msg); err != nil {
+		return false
 	}
+	return sub.match(&msg)
+}
 
+// sendGap notifies the drops before seq, or all of them if seq is 0.
+func sendGap(stream pb.PubsubService_SubscribeServer, sub *subscriber, seq uint64) error {
+	if gap := sub.takeGap(seq); gap != nil {
+		return stream.Send(&pb.Message{Gap: gap})
+	}
 	return nil
 }
 
+func send(stream pb.PubsubService_SubscribeServer, rec msglog.Record) error {
+	msg, err := message(rec)
+	if err != nil {
+		return err
+	}
+	return stream.Send(msg)
+}
+
+func message(rec msglog.Record) (*pb.Message, error) {
+	var msg pb.String
+	if err := proto.Unmarshal(rec.Data, &msg); err != nil {
+		return nil, status.Error(codes.DataLoss, err.Error())
+	}
+	return &pb.Message{
+		Value:        msg.GetValue(),
+		Seq:          rec.Seq,
+		TimeUnixNano: rec.Time.UnixNano(),
+		Topic:        msg.GetTopic(),
+		Payload:      msg.GetPayload(),
+		Origin:       msg.GetOrigin(),
+	}, nil
+}
+
 func main() {
+	flag.Parse()
+
+	l, err := msglog.Open(*flagDir, msglog.Options{
+		MaxBytes: *flagRetentionBytes,
+		MaxAge:   *flagRetentionAge,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := l.Retain(); err != nil {
+				log.Println("retain:", err)
+			}
+		}
+	}()
+
+	svc := NewPubsubService(l)
+	if *flagPeers != "" || *flagRegistry != "" {
+		startMesh(svc)
+	}
+
 	grpcServer := grpc.NewServer()
-	pb.RegisterPubsubServiceServer(grpcServer, NewPubsubService())
+	pb.RegisterPubsubServiceServer(grpcServer, svc)
 
-	lis, err := net.Listen("tcp", ":1234")
+	lis, err := net.Listen("tcp", *flagAddr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	grpcServer.Serve(lis)
 }
+
+func startMesh(svc *PubsubService) {
+	if *flagID == "" {
+		log.Fatal("mesh: -id is required with -peers or -registry")
+	}
+	mesh := NewMesh(svc, *flagID, func(addr string) (*grpc.ClientConn, error) {
+		return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	})
+
+	if *flagRegistry == "" {
+		peers, err := parsePeers(*flagPeers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mesh.Update(peers)
+		return
+	}
+
+	go func() {
+		for ; ; time.Sleep(5 * time.Second) {
+			peers, err := readRegistry(*flagRegistry)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			mesh.Update(peers)
+		}
+	}()
+}
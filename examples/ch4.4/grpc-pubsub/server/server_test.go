@@ -0,0 +1,480 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/msglog"
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+)
+
+func openLog(t *testing.T, dir string) *msglog.Log {
+	l, err := msglog.Open(dir, msglog.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// newTestClient serves p over an in-memory connection.
+func newTestClient(t *testing.T, p *PubsubService) pb.PubsubServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterPubsubServiceServer(srv, p)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewPubsubServiceClient(conn)
+}
+
+func publish(t *testing.T, client pb.PubsubServiceClient, values ...string) {
+	for _, v := range values {
+		if _, err := client.Publish(context.Background(), &pb.String{Value: v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func subscribe(t *testing.T, client pb.PubsubServiceClient, req *pb.SubscribeRequest) pb.PubsubService_SubscribeClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream, err := client.Subscribe(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+// expectMessages receives len(expect) messages, given as "seq:value".
+func expectMessages(t *testing.T, stream pb.PubsubService_SubscribeClient, expect ...string) {
+	t.Helper()
+	for _, want := range expect {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("%d:%s", msg.GetSeq(), msg.GetValue()); got != want {
+			t.Fatalf("expect = %s, got = %s", want, got)
+		}
+	}
+}
+
+// waitSubscribers waits until n subscriptions are registered.
+func waitSubscribers(t *testing.T, p *PubsubService, n int) {
+	for i := 0; i < 100; i++ {
+		p.mu.Lock()
+		m := len(p.subs)
+		p.mu.Unlock()
+		if m == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expect %d subscribers", n)
+}
+
+func TestReplayThenLive(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	publish(t, client, "golang: a", "docker: b", "golang: c")
+
+	stream := subscribe(t, client, &pb.SubscribeRequest{Value: "golang:", StartSeq: 1})
+	expectMessages(t, stream, "1:golang: a", "3:golang: c")
+
+	publish(t, client, "golang: d", "docker: e", "golang: f")
+	expectMessages(t, stream, "4:golang: d", "6:golang: f")
+}
+
+func TestSubscribeLatest(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	publish(t, client, "golang: old")
+	stream := subscribe(t, client, &pb.SubscribeRequest{Value: "golang:"})
+	waitSubscribers(t, p, 1)
+
+	publish(t, client, "golang: new")
+	expectMessages(t, stream, "2:golang: new")
+}
+
+func TestReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := msglog.Open(dir, msglog.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish(t, newTestClient(t, NewPubsubService(l)), "golang: a", "golang: b")
+	l.Close()
+
+	client := newTestClient(t, NewPubsubService(openLog(t, dir)))
+	stream := subscribe(t, client, &pb.SubscribeRequest{Value: "golang:", StartSeq: 2})
+	expectMessages(t, stream, "2:golang: b")
+
+	publish(t, client, "golang: c")
+	expectMessages(t, stream, "3:golang: c")
+}
+
+// slowStream is a subscribe stream whose Send blocks until the test reads
+// the message.
+type slowStream struct {
+	pb.PubsubService_SubscribeServer
+	ctx context.Context
+	ch  chan *pb.Message
+}
+
+func (s *slowStream) Context() context.Context     { return s.ctx }
+func (s *slowStream) SendHeader(metadata.MD) error { return nil }
+
+func (s *slowStream) Send(msg *pb.Message) error {
+	select {
+	case s.ch <- msg:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// startSlowSubscriber runs Subscribe on a slowStream. The error of
+// Subscribe is sent on the returned channel.
+func startSlowSubscriber(t *testing.T, p *PubsubService, req *pb.SubscribeRequest) (*slowStream, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream := &slowStream{ctx: ctx, ch: make(chan *pb.Message)}
+	errc := make(chan error, 1)
+	go func() { errc <- p.Subscribe(req, stream) }()
+	waitSubscribers(t, p, 1)
+	return stream, errc
+}
+
+func publishN(t *testing.T, p *PubsubService, from, to int) {
+	for i := from; i <= to; i++ {
+		if _, err := p.Publish(context.Background(), &pb.String{Value: fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// publishFirst publishes message 1 and waits until the subscriber took it
+// from its queue, so that it blocks in Send.
+func publishFirst(t *testing.T, p *PubsubService) {
+	publishN(t, p, 1, 1)
+	for i := 0; i < 100; i++ {
+		if subscription(t, p).GetQueued() == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("message 1 not taken from the queue")
+}
+
+func subscription(t *testing.T, p *PubsubService) *pb.Subscription {
+	list, err := p.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetSubscriptions()) != 1 {
+		t.Fatalf("expect 1 subscription, got = %v", list.GetSubscriptions())
+	}
+	return list.GetSubscriptions()[0]
+}
+
+// expectStream receives messages given as "seq" or "gap:first-last/dropped".
+func expectStream(t *testing.T, stream *slowStream, expect ...string) {
+	t.Helper()
+	for _, want := range expect {
+		var msg *pb.Message
+		select {
+		case msg = <-stream.ch:
+		case <-time.After(time.Second):
+			t.Fatalf("expect = %s, got nothing", want)
+		}
+
+		got := fmt.Sprint(msg.GetSeq())
+		if gap := msg.GetGap(); gap != nil {
+			got = fmt.Sprintf("gap:%d-%d/%d", gap.GetFirstSeq(), gap.GetLastSeq(), gap.GetDropped())
+		}
+		if got != want {
+			t.Fatalf("expect = %s, got = %s", want, got)
+		}
+	}
+}
+
+// TestLaggingSubscriber checks that the messages dropped from the live
+// queue of a slow subscriber are read back from the log, in order.
+func TestLaggingSubscriber(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	stream, _ := startSlowSubscriber(t, p, &pb.SubscribeRequest{})
+
+	const n = 500
+	publishN(t, p, 1, n)
+
+	for i := 1; i <= n; i++ {
+		msg := <-stream.ch
+		if msg.GetSeq() != uint64(i) || msg.GetValue() != fmt.Sprint(i) {
+			t.Fatalf("expect = %d, got = %d:%s", i, msg.GetSeq(), msg.GetValue())
+		}
+	}
+}
+
+func TestDropNewest(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	stream, _ := startSlowSubscriber(t, p, &pb.SubscribeRequest{
+		Policy:    pb.Policy_DROP_NEWEST,
+		QueueSize: 4,
+	})
+
+	publishFirst(t, p)
+	publishN(t, p, 2, 20)
+
+	sub := subscription(t, p)
+	if sub.GetDropped() != 15 || sub.GetLag() != 20 || sub.GetQueued() != 4 {
+		t.Fatalf("unexpected counters: %v", sub)
+	}
+
+	expectStream(t, stream, "1", "2", "3", "4", "5", "gap:6-20/15")
+	publishN(t, p, 21, 21)
+	expectStream(t, stream, "21")
+
+	if sub := subscription(t, p); sub.GetLag() != 0 || sub.GetNextSeq() != 22 {
+		t.Fatalf("unexpected counters: %v", sub)
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	stream, _ := startSlowSubscriber(t, p, &pb.SubscribeRequest{
+		Policy:    pb.Policy_DROP_OLDEST,
+		QueueSize: 4,
+	})
+
+	publishFirst(t, p)
+	publishN(t, p, 2, 20)
+
+	expectStream(t, stream, "1", "gap:2-16/15", "17", "18", "19", "20")
+	if sub := subscription(t, p); sub.GetDropped() != 15 {
+		t.Fatalf("expect 15 drops, got = %v", sub)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	stream, errc := startSlowSubscriber(t, p, &pb.SubscribeRequest{
+		Policy:    pb.Policy_DISCONNECT,
+		QueueSize: 2,
+		MaxDrops:  3,
+	})
+
+	publishFirst(t, p)
+	publishN(t, p, 2, 5)
+	if sub := subscription(t, p); sub.GetDropped() != 2 {
+		t.Fatalf("expect 2 drops, got = %v", sub)
+	}
+	publishN(t, p, 6, 6)
+
+	for {
+		select {
+		case <-stream.ch:
+			continue
+		case err := <-errc:
+			if status.Code(err) != codes.ResourceExhausted {
+				t.Fatalf("expect = %v, got = %v", codes.ResourceExhausted, err)
+			}
+		}
+		break
+	}
+	waitSubscribers(t, p, 0)
+}
+
+func TestBlock(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	stream, _ := startSlowSubscriber(t, p, &pb.SubscribeRequest{
+		Policy:    pb.Policy_BLOCK,
+		QueueSize: 1,
+	})
+
+	publishFirst(t, p)
+	publishN(t, p, 2, 2)
+
+	published := make(chan struct{})
+	go func() {
+		publishN(t, p, 3, 3)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expect the publisher to wait for the subscriber")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// a waiting publisher doesn't hold up the admin RPCs
+	if sub := subscription(t, p); sub.GetQueued() != 1 || sub.GetDropped() != 0 {
+		t.Fatalf("unexpected counters: %v", sub)
+	}
+
+	expectStream(t, stream, "1", "2", "3")
+	<-published
+}
+
+func TestGapAfterRetention(t *testing.T) {
+	l, err := msglog.Open(t.TempDir(), msglog.Options{SegmentBytes: 100, MaxBytes: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	p := NewPubsubService(l)
+	publishN(t, p, 1, 30)
+	first := l.FirstSeq()
+
+	client := newTestClient(t, p)
+	stream := subscribe(t, client, &pb.SubscribeRequest{StartSeq: 1})
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap := msg.GetGap(); gap.GetFirstSeq() != 1 || gap.GetLastSeq() != first-1 {
+		t.Fatalf("expect gap 1-%d, got = %v", first-1, msg)
+	}
+	expectMessages(t, stream, fmt.Sprintf("%d:%d", first, first))
+}
+
+func TestTopicPatterns(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+
+	star := subscribe(t, client, &pb.SubscribeRequest{Topic: "orders.*.created"})
+	tail := subscribe(t, client, &pb.SubscribeRequest{Topic: "orders.>"})
+	legacy := subscribe(t, client, &pb.SubscribeRequest{Value: "golang:"})
+	waitSubscribers(t, p, 3)
+
+	for _, msg := range []*pb.String{
+		{Topic: "orders.eu.created", Payload: []byte("1")},
+		{Topic: "orders.created", Payload: []byte("2")},
+		{Topic: "orders.eu.paid", Payload: []byte("3")},
+		{Value: "golang: 4"},
+		{Topic: "users.eu.created", Payload: []byte("5")},
+	} {
+		if _, err := client.Publish(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectTopics := func(stream pb.PubsubService_SubscribeClient, expect ...string) {
+		t.Helper()
+		for _, want := range expect {
+			msg, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%d:%s:%s", msg.GetSeq(), msg.GetTopic(), msg.GetPayload()); got != want {
+				t.Fatalf("expect = %s, got = %s", want, got)
+			}
+		}
+	}
+	expectTopics(star, "1:orders.eu.created:1")
+	expectTopics(tail, "1:orders.eu.created:1", "2:orders.created:2", "3:orders.eu.paid:3")
+	expectMessages(t, legacy, "4:golang: 4")
+
+	// replay applies the same pattern
+	replay := subscribe(t, client, &pb.SubscribeRequest{Topic: "*.eu.created", StartSeq: 1})
+	expectTopics(replay, "1:orders.eu.created:1", "5:users.eu.created:5")
+}
+
+func TestInvalidTopics(t *testing.T) {
+	client := newTestClient(t, NewPubsubService(openLog(t, t.TempDir())))
+
+	_, err := client.Publish(context.Background(), &pb.String{Topic: "orders.*"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expect = %v, got = %v", codes.InvalidArgument, err)
+	}
+
+	stream := subscribe(t, client, &pb.SubscribeRequest{Topic: "orders.>.eu"})
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expect = %v, got = %v", codes.InvalidArgument, err)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	p := NewPubsubService(openLog(t, t.TempDir()))
+	client := newTestClient(t, p)
+	ctx := context.Background()
+
+	named := subscribe(t, client, &pb.SubscribeRequest{Topic: "a.>", Id: "mine"})
+	other := subscribe(t, client, &pb.SubscribeRequest{Value: "x"})
+
+	header, err := other.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := header.Get(SubscriptionIDHeader)
+	if len(ids) != 1 || ids[0] == "" {
+		t.Fatalf("expect a subscription id header, got = %v", ids)
+	}
+	if header, _ := named.Header(); header.Get(SubscriptionIDHeader)[0] != "mine" {
+		t.Fatalf("expect id = mine, got = %v", header)
+	}
+
+	dup := subscribe(t, client, &pb.SubscribeRequest{Id: "mine"})
+	if _, err := dup.Recv(); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expect = %v, got = %v", codes.AlreadyExists, err)
+	}
+
+	list, err := client.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(list.GetSubscriptions()); n != 2 {
+		t.Fatalf("expect 2 subscriptions, got = %d", n)
+	}
+	for _, sub := range list.GetSubscriptions() {
+		if sub.GetId() == "mine" && (sub.GetTopic() != "a.>" || sub.GetNextSeq() != 1 || sub.GetPeer() == "") {
+			t.Fatalf("unexpected subscription: %v", sub)
+		}
+	}
+
+	if _, err := client.Unsubscribe(ctx, &pb.UnsubscribeRequest{Id: "mine"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := named.Recv(); err != io.EOF {
+		t.Fatalf("expect = %v, got = %v", io.EOF, err)
+	}
+	_, err = client.Unsubscribe(ctx, &pb.UnsubscribeRequest{Id: "mine"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expect = %v, got = %v", codes.NotFound, err)
+	}
+
+	list, err = client.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(list.GetSubscriptions()); n != 1 || list.GetSubscriptions()[0].GetId() != ids[0] {
+		t.Fatalf("expect only %s, got = %v", ids[0], list.GetSubscriptions())
+	}
+}
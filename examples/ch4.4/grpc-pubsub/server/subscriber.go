@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/msglog"
+	pb "gobook.examples/ch4-04-grpc/grpc-pubsub/pubsubservice"
+	"gobook.examples/ch4-04-grpc/grpc-pubsub/topics"
+)
+
+const defaultQueueSize = 64
+
+// subscriber receives the live messages on ch. What happens when ch is
+// full depends on its policy.
+type subscriber struct {
+	next uint64 // atomic, seq of the next message to send
+
+	id       string
+	topic    string
+	prefix   string
+	peer     string
+	policy   pb.Policy
+	maxDrops uint64
+	group    *group // of a consumer group member
+	local    bool   // skips the forwarded messages
+
+	ctx      context.Context // of the stream
+	ch       chan msglog.Record
+	done     chan struct{} // closed by Unsubscribe or DISCONNECT
+	doneOnce sync.Once
+	err      error // returned by Subscribe once done is closed
+
+	mu      sync.Mutex
+	lagging bool    // CATCH_UP: messages to read from the log
+	dropped uint64  // total of the messages dropped
+	gap     *pb.Gap // dropped since the last gap notification
+}
+
+func newSubscriber(ctx context.Context, arg *pb.SubscribeRequest) *subscriber {
+	queueSize := int(arg.GetQueueSize())
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	maxDrops := uint64(arg.GetMaxDrops())
+	if maxDrops == 0 {
+		maxDrops = 1
+	}
+
+	return &subscriber{
+		topic:    arg.GetTopic(),
+		prefix:   arg.GetValue(),
+		policy:   arg.GetPolicy(),
+		maxDrops: maxDrops,
+		local:    arg.GetLocalOnly(),
+		ctx:      ctx,
+		ch:       make(chan msglog.Record, queueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *subscriber) match(msg *pb.String) bool {
+	if s.local && msg.GetOrigin() != "" {
+		return false
+	}
+	return matchFilter(s.topic, s.prefix, msg)
+}
+
+// matchFilter reports whether msg matches the topic pattern, or the value
+// prefix when topic is empty.
+func matchFilter(topic, prefix string, msg *pb.String) bool {
+	if topic != "" {
+		return msg.GetTopic() != "" && topics.Match(topic, msg.GetTopic())
+	}
+	return strings.HasPrefix(msg.GetValue(), prefix)
+}
+
+// push queues rec, applying the policy when the queue is full. Only BLOCK
+// waits, until there is room or the subscription ends.
+func (s *subscriber) push(rec msglog.Record) {
+	if s.policy == pb.Policy_BLOCK {
+		select {
+		case s.ch <- rec:
+		case <-s.done:
+		case <-s.ctx.Done():
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lagging {
+		return
+	}
+	for {
+		select {
+		case s.ch <- rec:
+			return
+		default:
+		}
+
+		switch s.policy {
+		case pb.Policy_DROP_OLDEST:
+			select {
+			case old := <-s.ch:
+				s.drop(old)
+			default:
+			}
+			continue
+		case pb.Policy_DROP_NEWEST:
+			s.drop(rec)
+		case pb.Policy_DISCONNECT:
+			s.drop(rec)
+			if s.dropped >= s.maxDrops {
+				s.close(status.Errorf(codes.ResourceExhausted,
+					"subscription %q dropped %d messages", s.id, s.dropped))
+			}
+		default:
+			s.lagging = true
+		}
+		return
+	}
+}
+
+// drop must be called with s.mu held.
+func (s *subscriber) drop(rec msglog.Record) {
+	s.dropped++
+	if s.gap == nil {
+		s.gap = &pb.Gap{FirstSeq: rec.Seq, LastSeq: rec.Seq}
+	}
+	if rec.Seq < s.gap.FirstSeq {
+		s.gap.FirstSeq = rec.Seq
+	}
+	if rec.Seq > s.gap.LastSeq {
+		s.gap.LastSeq = rec.Seq
+	}
+	s.gap.Dropped++
+}
+
+// takeGap returns the drops since the last call if some came before seq,
+// or if seq is 0. It returns nil otherwise.
+func (s *subscriber) takeGap(seq uint64) *pb.Gap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gap := s.gap
+	if gap == nil || (seq != 0 && gap.FirstSeq > seq) {
+		return nil
+	}
+	s.gap = nil
+	return gap
+}
+
+// catchUp reports whether live messages were skipped, in which case the
+// subscriber reads the log up to head before its queue.
+func (s *subscriber) catchUp(l *msglog.Log) (head uint64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lagging || len(s.ch) > 0 {
+		return 0, false
+	}
+	s.lagging = false
+	return l.LastSeq(), true
+}
+
+// close ends the subscription, Subscribe returns err.
+func (s *subscriber) close(err error) {
+	s.doneOnce.Do(func() {
+		s.err = err
+		close(s.done)
+	})
+}
+
+func (s *subscriber) info(l *msglog.Log) *pb.Subscription {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.mu.Unlock()
+
+	next := atomic.LoadUint64(&s.next)
+	var lag uint64
+	if last := l.LastSeq(); last >= next {
+		lag = last - next + 1
+	}
+
+	info := &pb.Subscription{
+		Id:      s.id,
+		Topic:   s.topic,
+		Value:   s.prefix,
+		NextSeq: next,
+		Peer:    s.peer,
+		Policy:  s.policy,
+		Dropped: dropped,
+		Lag:     lag,
+		Queued:  uint32(len(s.ch)),
+	}
+	if s.group != nil {
+		info.Group = s.group.name
+		info.Unacked = s.group.unacked(s)
+	}
+	return info
+}
@@ -0,0 +1,167 @@
+// Package topics matches dot separated topics, such as "orders.eu.created",
+// against NATS-style patterns: "*" matches exactly one token and ">", only
+// allowed as the last token, matches one or more tokens.
+//
+//	orders.*.created  matches orders.eu.created, not orders.created
+//	orders.>          matches orders.eu and orders.eu.created, not orders
+package topics
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	Separator   = "."
+	AnyToken    = "*"
+	AnyTrailing = ">"
+)
+
+var (
+	ErrInvalidTopic   = errors.New("topics: invalid topic")
+	ErrInvalidPattern = errors.New("topics: invalid pattern")
+)
+
+// ValidTopic reports whether topic has no empty and no wildcard token.
+func ValidTopic(topic string) error {
+	for _, tok := range strings.Split(topic, Separator) {
+		if tok == "" || tok == AnyToken || tok == AnyTrailing {
+			return ErrInvalidTopic
+		}
+	}
+	return nil
+}
+
+// ValidPattern reports whether pattern has no empty token and ">" only
+// as its last token.
+func ValidPattern(pattern string) error {
+	toks := strings.Split(pattern, Separator)
+	for i, tok := range toks {
+		if tok == "" || (tok == AnyTrailing && i != len(toks)-1) {
+			return ErrInvalidPattern
+		}
+	}
+	return nil
+}
+
+// Match reports whether topic matches pattern.
+func Match(pattern, topic string) bool {
+	p := strings.Split(pattern, Separator)
+	t := strings.Split(topic, Separator)
+	for i, tok := range p {
+		if tok == AnyTrailing {
+			return len(t) > i
+		}
+		if i >= len(t) || (tok != AnyToken && tok != t[i]) {
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
+
+// Trie holds values under patterns and finds the values whose pattern
+// matches a topic, in time depending on the number of tokens and not on
+// the number of patterns. It is not safe for concurrent use.
+type Trie struct {
+	root node
+}
+
+type node struct {
+	children map[string]*node // by token, including AnyToken
+	values   map[interface{}]struct{}
+	trailing map[interface{}]struct{} // values of "<prefix>.>"
+}
+
+func NewTrie() *Trie {
+	return new(Trie)
+}
+
+// Add adds v under pattern. v must be comparable.
+func (t *Trie) Add(pattern string, v interface{}) error {
+	if err := ValidPattern(pattern); err != nil {
+		return err
+	}
+
+	n := &t.root
+	toks := strings.Split(pattern, Separator)
+	for _, tok := range toks {
+		if tok == AnyTrailing {
+			if n.trailing == nil {
+				n.trailing = make(map[interface{}]struct{})
+			}
+			n.trailing[v] = struct{}{}
+			return nil
+		}
+		if n.children == nil {
+			n.children = make(map[string]*node)
+		}
+		child, ok := n.children[tok]
+		if !ok {
+			child = new(node)
+			n.children[tok] = child
+		}
+		n = child
+	}
+	if n.values == nil {
+		n.values = make(map[interface{}]struct{})
+	}
+	n.values[v] = struct{}{}
+	return nil
+}
+
+// Remove removes v from pattern and prunes the empty nodes. It reports
+// whether v was there.
+func (t *Trie) Remove(pattern string, v interface{}) bool {
+	return t.root.remove(strings.Split(pattern, Separator), v)
+}
+
+func (n *node) remove(toks []string, v interface{}) bool {
+	if len(toks) == 0 {
+		_, ok := n.values[v]
+		delete(n.values, v)
+		return ok
+	}
+	if toks[0] == AnyTrailing {
+		_, ok := n.trailing[v]
+		delete(n.trailing, v)
+		return ok
+	}
+
+	child, ok := n.children[toks[0]]
+	if !ok || !child.remove(toks[1:], v) {
+		return false
+	}
+	if child.empty() {
+		delete(n.children, toks[0])
+	}
+	return true
+}
+
+func (n *node) empty() bool {
+	return len(n.children) == 0 && len(n.values) == 0 && len(n.trailing) == 0
+}
+
+// Match calls fn for every value whose pattern matches topic. A value
+// added under several matching patterns is passed once per pattern.
+func (t *Trie) Match(topic string, fn func(v interface{})) {
+	t.root.match(strings.Split(topic, Separator), fn)
+}
+
+func (n *node) match(toks []string, fn func(v interface{})) {
+	if len(toks) == 0 {
+		for v := range n.values {
+			fn(v)
+		}
+		return
+	}
+
+	for v := range n.trailing {
+		fn(v)
+	}
+	if child, ok := n.children[toks[0]]; ok {
+		child.match(toks[1:], fn)
+	}
+	if child, ok := n.children[AnyToken]; ok {
+		child.match(toks[1:], fn)
+	}
+}
@@ -0,0 +1,109 @@
+package topics
+
+import (
+	"sort"
+	"testing"
+)
+
+var matchTests = []struct {
+	pattern, topic string
+	match          bool
+}{
+	{"a.b.c", "a.b.c", true},
+	{"a.b.c", "a.b", false},
+	{"a.b", "a.b.c", false},
+	{"a.*.c", "a.b.c", true},
+	{"a.*.c", "a.c", false},
+	{"a.*.c", "a.b.d", false},
+	{"*", "a", true},
+	{"*", "a.b", false},
+	{"a.>", "a.b", true},
+	{"a.>", "a.b.c", true},
+	{"a.>", "a", false},
+	{">", "a", true},
+	{">", "a.b.c", true},
+	{"*.b.>", "a.b.c.d", true},
+	{"*.b.>", "a.x.c", false},
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range matchTests {
+		if got := Match(tt.pattern, tt.topic); got != tt.match {
+			t.Errorf("Match(%q, %q): expect = %v, got = %v", tt.pattern, tt.topic, tt.match, got)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, s := range []string{"a", "a.b", "a.*.c", "a.>", ">", "*"} {
+		if err := ValidPattern(s); err != nil {
+			t.Errorf("ValidPattern(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "a.", ".a", "a..b", "a.>.c", ">.a"} {
+		if err := ValidPattern(s); err == nil {
+			t.Errorf("ValidPattern(%q): expect error", s)
+		}
+	}
+	for _, s := range []string{"", "a.*", "a.>", "a..b"} {
+		if err := ValidTopic(s); err == nil {
+			t.Errorf("ValidTopic(%q): expect error", s)
+		}
+	}
+}
+
+func matchAll(trie *Trie, topic string) []string {
+	var got []string
+	trie.Match(topic, func(v interface{}) { got = append(got, v.(string)) })
+	sort.Strings(got)
+	return got
+}
+
+func TestTrie(t *testing.T) {
+	trie := NewTrie()
+	for _, tt := range matchTests {
+		if err := trie.Add(tt.pattern, tt.pattern); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range matchTests {
+		found := false
+		for _, p := range matchAll(trie, tt.topic) {
+			if !Match(p, tt.topic) {
+				t.Errorf("Trie.Match(%q) returned %q", tt.topic, p)
+			}
+			found = found || p == tt.pattern
+		}
+		if found != tt.match {
+			t.Errorf("Trie.Match(%q): expect %q = %v", tt.topic, tt.pattern, tt.match)
+		}
+	}
+
+	if err := trie.Add("a.>.b", "x"); err != ErrInvalidPattern {
+		t.Fatalf("expect = %v, got = %v", ErrInvalidPattern, err)
+	}
+}
+
+func TestTrieRemove(t *testing.T) {
+	trie := NewTrie()
+	trie.Add("a.*.c", 1)
+	trie.Add("a.*.c", 2)
+	trie.Add("a.>", 3)
+
+	if !trie.Remove("a.*.c", 1) || trie.Remove("a.*.c", 1) {
+		t.Fatal("expect a single removal")
+	}
+	var got []int
+	trie.Match("a.b.c", func(v interface{}) { got = append(got, v.(int)) })
+	sort.Ints(got)
+	if len(got) != 2 || got[0] != 2 || got[1] != 3 {
+		t.Fatalf("expect = [2 3], got = %v", got)
+	}
+
+	trie.Remove("a.*.c", 2)
+	trie.Remove("a.>", 3)
+	if !trie.root.empty() {
+		t.Fatal("expect empty nodes pruned")
+	}
+}
@@ -0,0 +1,58 @@
+// Code generated by protoc-gen-godefaults. DO NOT EDIT.
+// source: helloworld.proto
+
+package main
+
+// SetDefaults sets the fields of x which are unset to their default
+// values, in the message fields too.
+func (x *Message) SetDefaults() {
+	if x == nil {
+		return
+	}
+	if x.Name == "" {
+		x.Name = "gopher"
+	}
+	if x.Age == 0 {
+		x.Age = 10
+	}
+}
+
+func (x *Message) GetNameOrDefault() string {
+	if x != nil && x.Name != "" {
+		return x.Name
+	}
+	return "gopher"
+}
+
+func (x *Message) GetAgeOrDefault() int32 {
+	if x != nil && x.Age != 0 {
+		return x.Age
+	}
+	return 10
+}
+
+// SetDefaults sets the fields of x which are unset to their default
+// values, in the message fields too.
+func (x *Group) SetDefaults() {
+	if x == nil {
+		return
+	}
+	if x.Limit == nil {
+		v := int64(100)
+		x.Limit = &v
+	}
+	x.Leader.SetDefaults()
+	for _, v := range x.Members {
+		v.SetDefaults()
+	}
+	for _, v := range x.Roles {
+		v.SetDefaults()
+	}
+}
+
+func (x *Group) GetLimitOrDefault() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 100
+}
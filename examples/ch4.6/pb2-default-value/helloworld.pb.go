@@ -1,110 +1,257 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
 // source: helloworld.proto
 
 package main
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-import descriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = fmt.Errorf
-var _ = math.Inf
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the proto package it is being compiled against.
-// A compilation error at this line likely means your copy of the
-// proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type Message struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Age                  int32    `protobuf:"varint,2,opt,name=age,proto3" json:"age,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Age           int32                  `protobuf:"varint,2,opt,name=age,proto3" json:"age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_helloworld_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (m *Message) Reset()         { *m = Message{} }
-func (m *Message) String() string { return proto.CompactTextString(m) }
-func (*Message) ProtoMessage()    {}
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_helloworld_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
 func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_helloworld_0196a2ef510324e3, []int{0}
+	return file_helloworld_proto_rawDescGZIP(), []int{0}
 }
-func (m *Message) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Message.Unmarshal(m, b)
+
+func (x *Message) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
-func (m *Message) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Message.Marshal(b, m, deterministic)
+
+func (x *Message) GetAge() int32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
 }
-func (dst *Message) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Message.Merge(dst, src)
+
+type Group struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Leader        *Message               `protobuf:"bytes,1,opt,name=leader,proto3" json:"leader,omitempty"`
+	Members       []*Message             `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	Roles         map[string]*Message    `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Limit         *int64                 `protobuf:"varint,4,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
-func (m *Message) XXX_Size() int {
-	return xxx_messageInfo_Message.Size(m)
+
+func (x *Group) Reset() {
+	*x = Group{}
+	mi := &file_helloworld_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
-func (m *Message) XXX_DiscardUnknown() {
-	xxx_messageInfo_Message.DiscardUnknown(m)
+
+func (x *Group) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_Message proto.InternalMessageInfo
+func (*Group) ProtoMessage() {}
 
-func (m *Message) GetName() string {
-	if m != nil {
-		return m.Name
+func (x *Group) ProtoReflect() protoreflect.Message {
+	mi := &file_helloworld_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Group.ProtoReflect.Descriptor instead.
+func (*Group) Descriptor() ([]byte, []int) {
+	return file_helloworld_proto_rawDescGZIP(), []int{1}
 }
 
-func (m *Message) GetAge() int32 {
-	if m != nil {
-		return m.Age
+func (x *Group) GetLeader() *Message {
+	if x != nil {
+		return x.Leader
+	}
+	return nil
+}
+
+func (x *Group) GetMembers() []*Message {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *Group) GetRoles() map[string]*Message {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *Group) GetLimit() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
 	}
 	return 0
 }
 
-var E_DefaultString = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.FieldOptions)(nil),
-	ExtensionType: (*string)(nil),
-	Field:         50000,
-	Name:          "main.default_string",
-	Tag:           "bytes,50000,opt,name=default_string,json=defaultString",
-	Filename:      "helloworld.proto",
-}
-
-var E_DefaultInt = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.FieldOptions)(nil),
-	ExtensionType: (*int32)(nil),
-	Field:         50001,
-	Name:          "main.default_int",
-	Tag:           "varint,50001,opt,name=default_int,json=defaultInt",
-	Filename:      "helloworld.proto",
-}
-
-func init() {
-	proto.RegisterType((*Message)(nil), "main.Message")
-	proto.RegisterExtension(E_DefaultString)
-	proto.RegisterExtension(E_DefaultInt)
-}
-
-func init() { proto.RegisterFile("helloworld.proto", fileDescriptor_helloworld_0196a2ef510324e3) }
-
-var fileDescriptor_helloworld_0196a2ef510324e3 = []byte{
-	// 217 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0xc8, 0x48, 0xcd, 0xc9,
-	0xc9, 0x2f, 0xcf, 0x2f, 0xca, 0x49, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xc9, 0x4d,
-	0xcc, 0xcc, 0x93, 0x52, 0x48, 0xcf, 0xcf, 0x4f, 0xcf, 0x49, 0xd5, 0x07, 0x8b, 0x25, 0x95, 0xa6,
-	0xe9, 0xa7, 0xa4, 0x16, 0x27, 0x17, 0x65, 0x16, 0x94, 0xe4, 0x17, 0x41, 0xd4, 0x29, 0x39, 0x72,
-	0xb1, 0xfb, 0xa6, 0x16, 0x17, 0x27, 0xa6, 0xa7, 0x0a, 0xc9, 0x71, 0xb1, 0xe4, 0x25, 0xe6, 0xa6,
-	0x4a, 0x30, 0x2a, 0x30, 0x6a, 0x70, 0x3a, 0x71, 0x35, 0x6d, 0x95, 0x60, 0x4b, 0xcf, 0x2f, 0xc8,
-	0x48, 0x2d, 0x0a, 0x02, 0x8b, 0x0b, 0x89, 0x71, 0x31, 0x27, 0xa6, 0xa7, 0x4a, 0x30, 0x29, 0x30,
-	0x6a, 0xb0, 0x3a, 0xb1, 0x74, 0x6c, 0x95, 0xe0, 0x0a, 0x02, 0x09, 0x58, 0xb9, 0x71, 0xf1, 0xa5,
-	0xa4, 0xa6, 0x25, 0x96, 0xe6, 0x94, 0xc4, 0x17, 0x97, 0x14, 0x65, 0xe6, 0xa5, 0x0b, 0xc9, 0xea,
-	0x41, 0xec, 0xd5, 0x83, 0xd9, 0xab, 0xe7, 0x96, 0x99, 0x9a, 0x93, 0xe2, 0x5f, 0x50, 0x92, 0x99,
-	0x9f, 0x57, 0x2c, 0x71, 0xa1, 0x8d, 0x19, 0x64, 0x45, 0x10, 0x2f, 0x54, 0x5b, 0x30, 0x58, 0x97,
-	0x95, 0x03, 0x17, 0x37, 0xcc, 0x9c, 0xcc, 0xbc, 0x12, 0x42, 0x86, 0x5c, 0x04, 0x1b, 0xc2, 0x1a,
-	0xc4, 0x05, 0xd5, 0xe3, 0x99, 0x57, 0x92, 0xc4, 0x06, 0x56, 0x6a, 0x0c, 0x08, 0x00, 0x00, 0xff,
-	0xff, 0xce, 0x8c, 0x0b, 0x48, 0x0f, 0x01, 0x00, 0x00,
+var file_helloworld_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         50000,
+		Name:          "main.default_string",
+		Tag:           "bytes,50000,opt,name=default_string",
+		Filename:      "helloworld.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*int32)(nil),
+		Field:         50001,
+		Name:          "main.default_int",
+		Tag:           "varint,50001,opt,name=default_int",
+		Filename:      "helloworld.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional string default_string = 50000;
+	E_DefaultString = &file_helloworld_proto_extTypes[0]
+	// optional int32 default_int = 50001;
+	E_DefaultInt = &file_helloworld_proto_extTypes[1]
+)
+
+var File_helloworld_proto protoreflect.FileDescriptor
+
+const file_helloworld_proto_rawDesc = "" +
+	"\n" +
+	"\x10helloworld.proto\x12\x04main\x1a google/protobuf/descriptor.proto\"A\n" +
+	"\aMessage\x12\x1e\n" +
+	"\x04name\x18\x01 \x01(\tB\n" +
+	"\x82\xb5\x18\x06gopherR\x04name\x12\x16\n" +
+	"\x03age\x18\x02 \x01(\x05B\x04\x88\xb5\x18\n" +
+	"R\x03age\"\xf9\x01\n" +
+	"\x05Group\x12%\n" +
+	"\x06leader\x18\x01 \x01(\v2\r.main.MessageR\x06leader\x12'\n" +
+	"\amembers\x18\x02 \x03(\v2\r.main.MessageR\amembers\x12,\n" +
+	"\x05roles\x18\x03 \x03(\v2\x16.main.Group.RolesEntryR\x05roles\x12\x1f\n" +
+	"\x05limit\x18\x04 \x01(\x03B\x04\x88\xb5\x18dH\x00R\x05limit\x88\x01\x01\x1aG\n" +
+	"\n" +
+	"RolesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.main.MessageR\x05value:\x028\x01B\b\n" +
+	"\x06_limit25\n" +
+	"\fHelloService\x12%\n" +
+	"\x05Hello\x12\r.main.Message\x1a\r.main.Message:F\n" +
+	"\x0edefault_string\x12\x1d.google.protobuf.FieldOptions\x18І\x03 \x01(\tR\rdefaultString:@\n" +
+	"\vdefault_int\x12\x1d.google.protobuf.FieldOptions\x18ц\x03 \x01(\x05R\n" +
+	"defaultIntB8Z6gobook.examples/ch4-06-grpc-ext/pb2-default-value;mainb\x06proto3"
+
+var (
+	file_helloworld_proto_rawDescOnce sync.Once
+	file_helloworld_proto_rawDescData []byte
+)
+
+func file_helloworld_proto_rawDescGZIP() []byte {
+	file_helloworld_proto_rawDescOnce.Do(func() {
+		file_helloworld_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_helloworld_proto_rawDesc), len(file_helloworld_proto_rawDesc)))
+	})
+	return file_helloworld_proto_rawDescData
+}
+
+var file_helloworld_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_helloworld_proto_goTypes = []any{
+	(*Message)(nil),                   // 0: main.Message
+	(*Group)(nil),                     // 1: main.Group
+	nil,                               // 2: main.Group.RolesEntry
+	(*descriptorpb.FieldOptions)(nil), // 3: google.protobuf.FieldOptions
+}
+var file_helloworld_proto_depIdxs = []int32{
+	0, // 0: main.Group.leader:type_name -> main.Message
+	0, // 1: main.Group.members:type_name -> main.Message
+	2, // 2: main.Group.roles:type_name -> main.Group.RolesEntry
+	0, // 3: main.Group.RolesEntry.value:type_name -> main.Message
+	3, // 4: main.default_string:extendee -> google.protobuf.FieldOptions
+	3, // 5: main.default_int:extendee -> google.protobuf.FieldOptions
+	0, // 6: main.HelloService.Hello:input_type -> main.Message
+	0, // 7: main.HelloService.Hello:output_type -> main.Message
+	7, // [7:8] is the sub-list for method output_type
+	6, // [6:7] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	4, // [4:6] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_helloworld_proto_init() }
+func file_helloworld_proto_init() {
+	if File_helloworld_proto != nil {
+		return
+	}
+	file_helloworld_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_helloworld_proto_rawDesc), len(file_helloworld_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 2,
+			NumServices:   1,
+		},
+		GoTypes:           file_helloworld_proto_goTypes,
+		DependencyIndexes: file_helloworld_proto_depIdxs,
+		MessageInfos:      file_helloworld_proto_msgTypes,
+		ExtensionInfos:    file_helloworld_proto_extTypes,
+	}.Build()
+	File_helloworld_proto = out.File
+	file_helloworld_proto_goTypes = nil
+	file_helloworld_proto_depIdxs = nil
 }
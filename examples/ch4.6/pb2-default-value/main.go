@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+type HelloService struct{}
+
+func (p *HelloService) Hello(request *Message, reply *Message) error {
+	reply.Name = fmt.Sprintf("hello:%s(%d)", request.Name, request.Age)
+	return nil
+}
+
+func main() {
+	var msg Message
+	fmt.Println(msg.GetNameOrDefault(), msg.GetAgeOrDefault()) // gopher 10
+
+	_, client, err := NewHelloServicePipe(new(HelloService))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	var reply Message
+	if err := client.Hello(&Message{Age: 3}, &reply); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reply.Name) // hello:gopher(3)
+}
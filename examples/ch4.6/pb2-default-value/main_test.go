@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSetDefaults(t *testing.T) {
+	var msg *Message
+	if msg.GetNameOrDefault() != "gopher" || msg.GetAgeOrDefault() != 10 {
+		t.Fatalf("unexpected defaults: %q, %d", msg.GetNameOrDefault(), msg.GetAgeOrDefault())
+	}
+	msg.SetDefaults()
+
+	group := &Group{
+		Leader:  &Message{Name: "chai"},
+		Members: []*Message{{Age: 20}, {}},
+		Roles:   map[string]*Message{"admin": {}},
+	}
+	group.SetDefaults()
+
+	if group.Leader.Name != "chai" || group.Leader.Age != 10 {
+		t.Fatalf("unexpected leader: %v", group.Leader)
+	}
+	if group.Members[0].Name != "gopher" || group.Members[0].Age != 20 {
+		t.Fatalf("unexpected member: %v", group.Members[0])
+	}
+	if group.Members[1].Name != "gopher" || group.Members[1].Age != 10 {
+		t.Fatalf("unexpected member: %v", group.Members[1])
+	}
+	if group.Roles["admin"].Name != "gopher" {
+		t.Fatalf("unexpected role: %v", group.Roles["admin"])
+	}
+	if group.GetLimit() != 100 {
+		t.Fatalf("expect = %d, got = %d", 100, group.GetLimit())
+	}
+
+	group.Limit = new(int64)
+	group.SetDefaults()
+	if group.GetLimitOrDefault() != 0 {
+		t.Fatalf("expect = %d, got = %d", 0, group.GetLimitOrDefault())
+	}
+}
+
+func TestRegisterSetDefaults(t *testing.T) {
+	fake := new(FakeHelloService)
+	_, client, err := NewHelloServicePipe(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.Hello(&Message{Age: 3}, new(Message)); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := fake.HelloCalls()
+	if len(calls) != 1 || calls[0].Name != "gopher" || calls[0].Age != 3 {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+}
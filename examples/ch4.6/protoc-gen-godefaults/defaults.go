@@ -0,0 +1,299 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	optionDefaultString = "default_string"
+	optionDefaultInt    = "default_int"
+)
+
+type defaultsPlugin struct {
+	*protogen.Plugin
+
+	// types holds the default_string and default_int extensions declared
+	// by the files of the request, to parse the field options with.
+	types *protoregistry.Types
+
+	// hasDefaults holds the generated messages which get SetDefaults.
+	hasDefaults map[*protogen.Message]bool
+}
+
+func newDefaultsPlugin(gen *protogen.Plugin) *defaultsPlugin {
+	p := &defaultsPlugin{
+		Plugin:      gen,
+		types:       new(protoregistry.Types),
+		hasDefaults: make(map[*protogen.Message]bool),
+	}
+
+	var messages []*protogen.Message
+	for _, f := range gen.Files {
+		for _, x := range allExtensions(f) {
+			name := x.Desc.Name()
+			if x.Desc.ContainingMessage().FullName() != "google.protobuf.FieldOptions" {
+				continue
+			}
+			if name == optionDefaultString || name == optionDefaultInt {
+				p.types.RegisterExtension(dynamicpb.NewExtensionType(x.Desc))
+			}
+		}
+		if f.Generate {
+			messages = append(messages, allMessages(f.Messages)...)
+		}
+	}
+
+	// a message has defaults if one of its fields has, or if one of its
+	// message fields has, repeat until nothing changes to follow cycles.
+	for changed := true; changed; {
+		changed = false
+		for _, m := range messages {
+			if p.hasDefaults[m] {
+				continue
+			}
+			for _, f := range m.Fields {
+				if p.hasDefaults[fieldMessage(f)] || p.getFieldDefault(f) != nil {
+					p.hasDefaults[m] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return p
+}
+
+func allExtensions(f *protogen.File) []*protogen.Extension {
+	list := append([]*protogen.Extension(nil), f.Extensions...)
+	for _, m := range allMessages(f.Messages) {
+		list = append(list, m.Extensions...)
+	}
+	return list
+}
+
+func allMessages(messages []*protogen.Message) []*protogen.Message {
+	var list []*protogen.Message
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		list = append(list, m)
+		list = append(list, allMessages(m.Messages)...)
+	}
+	return list
+}
+
+// fieldMessage returns the message type of f, or of the values of a map.
+func fieldMessage(f *protogen.Field) *protogen.Message {
+	if f.Desc.IsMap() {
+		return f.Message.Fields[1].Message
+	}
+	return f.Message
+}
+
+type fieldDefault struct {
+	option string
+	value  protoreflect.Value
+}
+
+func (p *defaultsPlugin) getFieldDefault(f *protogen.Field) *fieldDefault {
+	opts, _ := f.Desc.Options().(*descriptorpb.FieldOptions)
+	if opts == nil {
+		return nil
+	}
+
+	// the options were parsed without knowing the extensions
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	opts = new(descriptorpb.FieldOptions)
+	if err := (proto.UnmarshalOptions{Resolver: p.types}).Unmarshal(data, opts); err != nil {
+		return nil
+	}
+
+	var d *fieldDefault
+	opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsExtension() && (fd.Name() == optionDefaultString || fd.Name() == optionDefaultInt) {
+			d = &fieldDefault{option: string(fd.Name()), value: v}
+		}
+		return d == nil
+	})
+	return d
+}
+
+func (p *defaultsPlugin) Generate(file *protogen.File) error {
+	var messages []*protogen.Message
+	for _, m := range allMessages(file.Messages) {
+		if p.hasDefaults[m] {
+			messages = append(messages, m)
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	g := p.NewGeneratedFile(file.GeneratedFilenamePrefix+".defaults.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-godefaults. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, m := range messages {
+		if err := p.genMessage(g, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *defaultsPlugin) genMessage(g *protogen.GeneratedFile, m *protogen.Message) error {
+	type fieldSpec struct {
+		field   *protogen.Field
+		goType  string
+		literal string
+		zero    string
+		pointer bool
+	}
+
+	var specs []fieldSpec
+	for _, f := range m.Fields {
+		d := p.getFieldDefault(f)
+		if d == nil {
+			continue
+		}
+		if f.Desc.IsList() || f.Desc.IsMap() || f.Message != nil || f.Desc.ContainingOneof() != nil && !f.Desc.HasOptionalKeyword() {
+			return fmt.Errorf("godefaults: %s: %s is only supported on singular scalar fields", f.Desc.FullName(), d.option)
+		}
+
+		goType, literal, zero, err := fieldLiteral(g, f, d)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, fieldSpec{
+			field:   f,
+			goType:  goType,
+			literal: literal,
+			zero:    zero,
+			pointer: f.Desc.HasPresence() && f.Desc.Kind() != protoreflect.BytesKind,
+		})
+	}
+
+	g.P("// SetDefaults sets the fields of x which are unset to their default")
+	g.P("// values, in the message fields too.")
+	g.P("func (x *", m.GoIdent, ") SetDefaults() {")
+	g.P("if x == nil {")
+	g.P("return")
+	g.P("}")
+	for _, s := range specs {
+		if s.pointer {
+			g.P("if x.", s.field.GoName, " == nil {")
+			if s.goType == "string" || strings.HasPrefix(s.literal, s.goType+"(") {
+				g.P("v := ", s.literal)
+			} else {
+				g.P("v := ", s.goType, "(", s.literal, ")")
+			}
+			g.P("x.", s.field.GoName, " = &v")
+		} else {
+			g.P("if ", fmt.Sprintf(s.zero, "x."+s.field.GoName), " {")
+			g.P("x.", s.field.GoName, " = ", s.literal)
+		}
+		g.P("}")
+	}
+	for _, f := range m.Fields {
+		if !p.hasDefaults[fieldMessage(f)] {
+			continue
+		}
+		switch {
+		case f.Desc.IsList() || f.Desc.IsMap():
+			g.P("for _, v := range x.", f.GoName, " {")
+			g.P("v.SetDefaults()")
+			g.P("}")
+		case f.Oneof != nil && !f.Oneof.Desc.IsSynthetic():
+			g.P("if v, ok := x.", f.Oneof.GoName, ".(*", f.GoIdent, "); ok {")
+			g.P("v.", f.GoName, ".SetDefaults()")
+			g.P("}")
+		default:
+			g.P("x.", f.GoName, ".SetDefaults()")
+		}
+	}
+	g.P("}")
+	g.P()
+
+	for _, s := range specs {
+		g.P("func (x *", m.GoIdent, ") Get", s.field.GoName, "OrDefault() ", s.goType, " {")
+		if s.pointer {
+			g.P("if x != nil && x.", s.field.GoName, " != nil {")
+			g.P("return *x.", s.field.GoName)
+		} else {
+			nonzero := strings.Replace(s.zero, "==", "!=", 1)
+			g.P("if x != nil && ", fmt.Sprintf(nonzero, "x."+s.field.GoName), " {")
+			g.P("return x.", s.field.GoName)
+		}
+		g.P("}")
+		g.P("return ", s.literal)
+		g.P("}")
+		g.P()
+	}
+	return nil
+}
+
+// fieldLiteral returns the Go type of f, its default value as a Go literal
+// and the format of the zero value test.
+func fieldLiteral(g *protogen.GeneratedFile, f *protogen.Field, d *fieldDefault) (goType, literal, zero string, err error) {
+	kind := f.Desc.Kind()
+	mismatch := fmt.Errorf("godefaults: %s: %s is not supported on %v fields", f.Desc.FullName(), d.option, kind)
+
+	if d.option == optionDefaultString {
+		switch kind {
+		case protoreflect.StringKind:
+			return "string", strconv.Quote(d.value.String()), `%s == ""`, nil
+		case protoreflect.BytesKind:
+			return "[]byte", "[]byte(" + strconv.Quote(d.value.String()) + ")", "len(%s) == 0", nil
+		}
+		return "", "", "", mismatch
+	}
+
+	n := d.value.Int()
+	switch kind {
+	case protoreflect.EnumKind:
+		goType = g.QualifiedGoIdent(f.Enum.GoIdent)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		goType = "int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		goType = "int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		goType = "uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		goType = "uint64"
+	case protoreflect.FloatKind:
+		goType = "float32"
+	case protoreflect.DoubleKind:
+		goType = "float64"
+	default:
+		return "", "", "", mismatch
+	}
+	if n < 0 && (goType == "uint32" || goType == "uint64") {
+		return "", "", "", fmt.Errorf("godefaults: %s: %s %d overflows the field", f.Desc.FullName(), d.option, n)
+	}
+
+	literal = strconv.FormatInt(n, 10)
+	if kind == protoreflect.EnumKind {
+		literal = goType + "(" + literal + ")"
+	}
+	return goType, literal, "%s == 0", nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// protoc-gen-godefaults is a plugin for the Google protocol buffer compiler
+// to give proto3 fields the proto2 style default values declared by field
+// options:
+//
+//	extend google.protobuf.FieldOptions {
+//		string default_string = 50000;
+//		int32 default_int = 50001;
+//	}
+//
+//	message Message {
+//		string name = 1 [(default_string) = "gopher"];
+//		int32 age = 2 [(default_int) = 10];
+//	}
+//
+// The options are found by name, so they may be declared in any package.
+// Run it next to the regular protoc-gen-go plugin:
+//
+//	protoc --go_out=. --godefaults_out=. hello.proto
+//
+// hello.defaults.pb.go gets a GetXxxOrDefault method for every field with
+// a default, and a SetDefaults method for every message with such fields,
+// directly or in its nested, repeated and map message fields. SetDefaults
+// only replaces the zero values.
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	protogen.Options{}.Run(generate)
+}
+
+func generate(gen *protogen.Plugin) error {
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+	p := newDefaultsPlugin(gen)
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if err := p.Generate(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
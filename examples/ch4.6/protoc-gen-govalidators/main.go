@@ -0,0 +1,47 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// protoc-gen-govalidators is a plugin for the Google protocol buffer
+// compiler to generate Validate methods from the (validator.field) option:
+//
+//	import "validator/validator.proto";
+//
+//	message Message {
+//		string important_string = 1 [(validator.field) = {regex: "^[a-z]{2,5}$"}];
+//		int32 age = 2 [(validator.field) = {int_gt: 0, int_lt: 100}];
+//	}
+//
+// Run it next to the regular protoc-gen-go plugin:
+//
+//	protoc --go_out=. --govalidators_out=. hello.proto
+//
+// The Validate methods are written to hello.validator.pb.go. Every message
+// of the file gets one, so nested messages are validated too. A failed rule
+// is reported as a *validator.Error holding the path of the field, such as
+// "Items[2].Name".
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	protogen.Options{}.Run(generate)
+}
+
+func generate(gen *protogen.Plugin) error {
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+	p := &validatorPlugin{Plugin: gen}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if err := p.Generate(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"gobook.examples/ch4-06-grpc-ext/validator"
+)
+
+const (
+	errorsPackage = protogen.GoImportPath("errors")
+	fmtPackage    = protogen.GoImportPath("fmt")
+	regexpPackage = protogen.GoImportPath("regexp")
+	utf8Package   = protogen.GoImportPath("unicode/utf8")
+
+	// validatorPackage is the runtime support of the generated code.
+	validatorPackage = protogen.GoImportPath("gobook.examples/ch4-06-grpc-ext/validator")
+)
+
+type validatorPlugin struct{ *protogen.Plugin }
+
+func (p *validatorPlugin) Generate(file *protogen.File) error {
+	messages := allMessages(file.Messages)
+	if len(messages) == 0 {
+		return nil
+	}
+
+	g := p.NewGeneratedFile(file.GeneratedFilenamePrefix+".validator.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-govalidators. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, m := range messages {
+		if err := p.genMessage(g, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func allMessages(messages []*protogen.Message) []*protogen.Message {
+	var list []*protogen.Message
+	for _, m := range messages {
+		if m.Desc.IsMapEntry() {
+			continue
+		}
+		list = append(list, m)
+		list = append(list, allMessages(m.Messages)...)
+	}
+	return list
+}
+
+func (p *validatorPlugin) getFieldValidator(f *protogen.Field) *validator.FieldValidator {
+	if f.Desc.Options() == nil || !proto.HasExtension(f.Desc.Options(), validator.E_Field) {
+		return nil
+	}
+	rule, _ := proto.GetExtension(f.Desc.Options(), validator.E_Field).(*validator.FieldValidator)
+	return rule
+}
+
+func (p *validatorPlugin) genMessage(g *protogen.GeneratedFile, m *protogen.Message) error {
+	for _, f := range m.Fields {
+		rule := p.getFieldValidator(f)
+		if rule == nil || rule.Regex == nil {
+			continue
+		}
+		if _, err := regexp.Compile(rule.GetRegex()); err != nil {
+			return fmt.Errorf("validator: %s: %v", f.Desc.FullName(), err)
+		}
+		g.P("var ", regexName(m, f), " = ", regexpPackage.Ident("MustCompile"), "(", strconv.Quote(rule.GetRegex()), ")")
+	}
+	g.P()
+
+	g.P("func (this *", m.GoIdent, ") Validate() error {")
+	g.P("if this == nil {")
+	g.P("return nil")
+	g.P("}")
+	for _, f := range m.Fields {
+		rule := p.getFieldValidator(f)
+		if rule == nil {
+			rule = new(validator.FieldValidator)
+		}
+		if err := checkRule(f, rule); err != nil {
+			return err
+		}
+
+		if f.Oneof != nil && !f.Oneof.Desc.IsSynthetic() {
+			g.P("if _, ok := this.", f.Oneof.GoName, ".(*", f.GoIdent, "); ok {")
+			p.genSingularField(g, m, f, rule)
+			g.P("}")
+			continue
+		}
+
+		switch {
+		case f.Desc.IsMap():
+			p.genMapField(g, f, rule)
+		case f.Desc.IsList():
+			p.genListField(g, m, f, rule)
+		default:
+			p.genSingularField(g, m, f, rule)
+		}
+	}
+	g.P("return nil")
+	g.P("}")
+	g.P()
+	return nil
+}
+
+func (p *validatorPlugin) genSingularField(g *protogen.GeneratedFile, m *protogen.Message, f *protogen.Field, rule *validator.FieldValidator) {
+	path := strconv.Quote(f.GoName)
+	value := "this.Get" + f.GoName + "()"
+
+	if f.Message != nil {
+		if rule.GetMsgExists() {
+			g.P("if ", value, " == nil {")
+			genFieldError(g, path, rule, `"message must exist"`)
+			g.P("}")
+		}
+		genCallValidator(g, path, value)
+		return
+	}
+	genScalarChecks(g, m, f, rule, path, value)
+}
+
+func (p *validatorPlugin) genListField(g *protogen.GeneratedFile, m *protogen.Message, f *protogen.Field, rule *validator.FieldValidator) {
+	genCountChecks(g, f, rule)
+	if f.Message == nil && !hasScalarRules(rule) {
+		return
+	}
+
+	path := fmt.Sprintf("%s(%q, i)", g.QualifiedGoIdent(fmtPackage.Ident("Sprintf")), f.GoName+"[%d]")
+	g.P("for i, v := range this.", f.GoName, " {")
+	if f.Message != nil {
+		genCallValidator(g, path, "v")
+	} else {
+		genScalarChecks(g, m, f, rule, path, "v")
+	}
+	g.P("}")
+}
+
+func (p *validatorPlugin) genMapField(g *protogen.GeneratedFile, f *protogen.Field, rule *validator.FieldValidator) {
+	genCountChecks(g, f, rule)
+
+	if f.Message.Fields[1].Message != nil {
+		path := fmt.Sprintf("%s(%q, k)", g.QualifiedGoIdent(fmtPackage.Ident("Sprintf")), f.GoName+"[%v]")
+		g.P("for k, v := range this.", f.GoName, " {")
+		genCallValidator(g, path, "v")
+		g.P("}")
+	}
+}
+
+func genCallValidator(g *protogen.GeneratedFile, path, value string) {
+	g.P("if err := ", validatorPackage.Ident("CallValidatorIfExists"), "(", value, "); err != nil {")
+	g.P("return ", validatorPackage.Ident("FieldError"), "(", path, ", err)")
+	g.P("}")
+}
+
+// genFieldError returns the error of the field at path. The reason is a
+// fmt.Errorf format with the arguments args, or the human_error of rule.
+func genFieldError(g *protogen.GeneratedFile, path string, rule *validator.FieldValidator, reason string, args ...string) {
+	var err string
+	if rule.HumanError != nil {
+		err = g.QualifiedGoIdent(errorsPackage.Ident("New")) + "(" + strconv.Quote(rule.GetHumanError()) + ")"
+	} else {
+		err = g.QualifiedGoIdent(fmtPackage.Ident("Errorf")) + "(" + reason
+		for _, arg := range args {
+			err += ", " + arg
+		}
+		err += ")"
+	}
+	g.P("return ", validatorPackage.Ident("FieldError"), "(", path, ", ", err, ")")
+}
+
+func genCountChecks(g *protogen.GeneratedFile, f *protogen.Field, rule *validator.FieldValidator) {
+	if rule.RepeatedCountMin != nil {
+		n := strconv.FormatInt(rule.GetRepeatedCountMin(), 10)
+		g.P("if len(this.", f.GoName, ") < ", n, " {")
+		genFieldError(g, strconv.Quote(f.GoName), rule, strconv.Quote("must contain at least "+n+" elements"))
+		g.P("}")
+	}
+	if rule.RepeatedCountMax != nil {
+		n := strconv.FormatInt(rule.GetRepeatedCountMax(), 10)
+		g.P("if len(this.", f.GoName, ") > ", n, " {")
+		genFieldError(g, strconv.Quote(f.GoName), rule, strconv.Quote("must contain at most "+n+" elements"))
+		g.P("}")
+	}
+}
+
+func hasScalarRules(rule *validator.FieldValidator) bool {
+	return rule.Regex != nil ||
+		rule.IntGt != nil || rule.IntLt != nil ||
+		rule.FloatGt != nil || rule.FloatLt != nil ||
+		rule.FloatGte != nil || rule.FloatLte != nil ||
+		rule.GetStringNotEmpty() ||
+		rule.LengthGt != nil || rule.LengthLt != nil || rule.LengthEq != nil ||
+		rule.GetIsInEnum()
+}
+
+func genScalarChecks(g *protogen.GeneratedFile, m *protogen.Message, f *protogen.Field, rule *validator.FieldValidator, path, value string) {
+	check := func(cond, reason string, args ...string) {
+		g.P("if !(", cond, ") {")
+		genFieldError(g, path, rule, reason, append([]string{value}, args...)...)
+		g.P("}")
+	}
+
+	if rule.Regex != nil {
+		match := "MatchString"
+		if f.Desc.Kind() == protoreflect.BytesKind {
+			match = "Match"
+		}
+		check(
+			regexName(m, f)+"."+match+"("+value+")",
+			strconv.Quote("value '%v' must be a string conforming to regex %q"),
+			strconv.Quote(rule.GetRegex()),
+		)
+	}
+
+	if rule.IntGt != nil {
+		n := strconv.FormatInt(rule.GetIntGt(), 10)
+		check(value+" > "+n, strconv.Quote("value '%v' must be greater than '"+n+"'"))
+	}
+	if rule.IntLt != nil {
+		n := strconv.FormatInt(rule.GetIntLt(), 10)
+		check(value+" < "+n, strconv.Quote("value '%v' must be less than '"+n+"'"))
+	}
+
+	for _, r := range []struct {
+		bound *float64
+		op    string
+		text  string
+	}{
+		{rule.FloatGt, ">", "greater than"},
+		{rule.FloatLt, "<", "less than"},
+		{rule.FloatGte, ">=", "greater than or equal to"},
+		{rule.FloatLte, "<=", "less than or equal to"},
+	} {
+		if r.bound != nil {
+			n := strconv.FormatFloat(*r.bound, 'g', -1, 64)
+			check(value+" "+r.op+" "+n, strconv.Quote("value '%v' must be "+r.text+" '"+n+"'"))
+		}
+	}
+
+	if rule.GetStringNotEmpty() {
+		check(value+` != ""`, strconv.Quote("value '%v' must not be an empty string"))
+	}
+
+	var length string
+	if rule.LengthGt != nil || rule.LengthLt != nil || rule.LengthEq != nil {
+		length = "len(" + value + ")"
+		if f.Desc.Kind() == protoreflect.StringKind {
+			length = g.QualifiedGoIdent(utf8Package.Ident("RuneCountInString")) + "(" + value + ")"
+		}
+	}
+	if rule.LengthGt != nil {
+		n := strconv.FormatInt(rule.GetLengthGt(), 10)
+		check(length+" > "+n, strconv.Quote("value '%v' must have a length greater than '"+n+"'"))
+	}
+	if rule.LengthLt != nil {
+		n := strconv.FormatInt(rule.GetLengthLt(), 10)
+		check(length+" < "+n, strconv.Quote("value '%v' must have a length smaller than '"+n+"'"))
+	}
+	if rule.LengthEq != nil {
+		n := strconv.FormatInt(rule.GetLengthEq(), 10)
+		check(length+" == "+n, strconv.Quote("value '%v' must have a length equal to '"+n+"'"))
+	}
+
+	if rule.GetIsInEnum() {
+		names := g.QualifiedGoIdent(protogen.GoIdent{
+			GoName:       f.Enum.GoIdent.GoName + "_name",
+			GoImportPath: f.Enum.GoIdent.GoImportPath,
+		})
+		g.P("if _, ok := ", names, "[int32(", value, ")]; !ok {")
+		genFieldError(g, path, rule, strconv.Quote("value '%v' must be a valid "+f.Enum.GoIdent.GoName+" field"), value)
+		g.P("}")
+	}
+}
+
+func regexName(m *protogen.Message, f *protogen.Field) string {
+	return "_regex_" + m.GoIdent.GoName + "_" + f.GoName
+}
+
+// checkRule reports the rules of f which don't apply to its type, and
+// integer bounds which don't fit in it.
+func checkRule(f *protogen.Field, rule *validator.FieldValidator) error {
+	kind := f.Desc.Kind()
+	isString := kind == protoreflect.StringKind
+	isBytes := kind == protoreflect.BytesKind
+	isFloat := kind == protoreflect.FloatKind || kind == protoreflect.DoubleKind
+	isMessage := f.Message != nil
+	isInt := !isString && !isBytes && !isFloat && !isMessage &&
+		kind != protoreflect.BoolKind && kind != protoreflect.EnumKind
+	isRepeated := f.Desc.IsList() || f.Desc.IsMap()
+
+	for _, r := range []struct {
+		name string
+		set  bool
+		ok   bool
+	}{
+		{"regex", rule.Regex != nil, isString || isBytes},
+		{"int_gt", rule.IntGt != nil, isInt},
+		{"int_lt", rule.IntLt != nil, isInt},
+		{"float_gt", rule.FloatGt != nil, isFloat},
+		{"float_lt", rule.FloatLt != nil, isFloat},
+		{"float_gte", rule.FloatGte != nil, isFloat},
+		{"float_lte", rule.FloatLte != nil, isFloat},
+		{"string_not_empty", rule.StringNotEmpty != nil, isString},
+		{"length_gt", rule.LengthGt != nil, isString || isBytes},
+		{"length_lt", rule.LengthLt != nil, isString || isBytes},
+		{"length_eq", rule.LengthEq != nil, isString || isBytes},
+		{"is_in_enum", rule.IsInEnum != nil, kind == protoreflect.EnumKind},
+		{"msg_exists", rule.MsgExists != nil, isMessage && !isRepeated},
+		{"repeated_count_min", rule.RepeatedCountMin != nil, isRepeated},
+		{"repeated_count_max", rule.RepeatedCountMax != nil, isRepeated},
+	} {
+		if r.set && !r.ok {
+			return fmt.Errorf("validator: %s: %s is not supported on this field", f.Desc.FullName(), r.name)
+		}
+	}
+
+	if !isInt {
+		return nil
+	}
+	min, max := intRange(kind)
+	for _, r := range []struct {
+		name  string
+		bound *int64
+	}{
+		{"int_gt", rule.IntGt},
+		{"int_lt", rule.IntLt},
+	} {
+		if r.bound == nil {
+			continue
+		}
+		if *r.bound < min || (*r.bound >= 0 && uint64(*r.bound) > max) {
+			return fmt.Errorf("validator: %s: %s %d overflows the field", f.Desc.FullName(), r.name, *r.bound)
+		}
+	}
+	return nil
+}
+
+func intRange(kind protoreflect.Kind) (min int64, max uint64) {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return math.MinInt32, math.MaxInt32
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return 0, math.MaxUint32
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return 0, math.MaxUint64
+	}
+	return math.MinInt64, math.MaxInt64
+}
@@ -0,0 +1,422 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: test.proto
+
+package testpb
+
+import (
+	_ "gobook.examples/ch4-06-grpc-ext/validator"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Color int32
+
+const (
+	Color_RED   Color = 0
+	Color_GREEN Color = 1
+)
+
+// Enum value maps for Color.
+var (
+	Color_name = map[int32]string{
+		0: "RED",
+		1: "GREEN",
+	}
+	Color_value = map[string]int32{
+		"RED":   0,
+		"GREEN": 1,
+	}
+)
+
+func (x Color) Enum() *Color {
+	p := new(Color)
+	*p = x
+	return p
+}
+
+func (x Color) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Color) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_proto_enumTypes[0].Descriptor()
+}
+
+func (Color) Type() protoreflect.EnumType {
+	return &file_test_proto_enumTypes[0]
+}
+
+func (x Color) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Color.Descriptor instead.
+func (Color) EnumDescriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0}
+}
+
+type Inner struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Age           int32                  `protobuf:"varint,2,opt,name=age,proto3" json:"age,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Inner) Reset() {
+	*x = Inner{}
+	mi := &file_test_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Inner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inner) ProtoMessage() {}
+
+func (x *Inner) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inner.ProtoReflect.Descriptor instead.
+func (*Inner) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Inner) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Inner) GetAge() int32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+type Outer struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code   string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Key    []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Count  uint64                 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	Ratio  float64                `protobuf:"fixed64,5,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Score  float32                `protobuf:"fixed32,6,opt,name=score,proto3" json:"score,omitempty"`
+	Inner  *Inner                 `protobuf:"bytes,7,opt,name=inner,proto3" json:"inner,omitempty"`
+	Items  []*Inner               `protobuf:"bytes,8,rep,name=items,proto3" json:"items,omitempty"`
+	Labels map[string]*Inner      `protobuf:"bytes,9,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Tags   []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Color  Color                  `protobuf:"varint,11,opt,name=color,proto3,enum=testpb.Color" json:"color,omitempty"`
+	Colors []Color                `protobuf:"varint,12,rep,packed,name=colors,proto3,enum=testpb.Color" json:"colors,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//
+	//	*Outer_Number
+	//	*Outer_Nested
+	Value         isOuter_Value `protobuf_oneof:"value"`
+	Limit         *int32        `protobuf:"varint,15,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Outer) Reset() {
+	*x = Outer{}
+	mi := &file_test_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Outer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Outer) ProtoMessage() {}
+
+func (x *Outer) ProtoReflect() protoreflect.Message {
+	mi := &file_test_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Outer.ProtoReflect.Descriptor instead.
+func (*Outer) Descriptor() ([]byte, []int) {
+	return file_test_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Outer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Outer) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Outer) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *Outer) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Outer) GetRatio() float64 {
+	if x != nil {
+		return x.Ratio
+	}
+	return 0
+}
+
+func (x *Outer) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Outer) GetInner() *Inner {
+	if x != nil {
+		return x.Inner
+	}
+	return nil
+}
+
+func (x *Outer) GetItems() []*Inner {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Outer) GetLabels() map[string]*Inner {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Outer) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Outer) GetColor() Color {
+	if x != nil {
+		return x.Color
+	}
+	return Color_RED
+}
+
+func (x *Outer) GetColors() []Color {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *Outer) GetValue() isOuter_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Outer) GetNumber() int32 {
+	if x != nil {
+		if x, ok := x.Value.(*Outer_Number); ok {
+			return x.Number
+		}
+	}
+	return 0
+}
+
+func (x *Outer) GetNested() *Inner {
+	if x != nil {
+		if x, ok := x.Value.(*Outer_Nested); ok {
+			return x.Nested
+		}
+	}
+	return nil
+}
+
+func (x *Outer) GetLimit() int32 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+type isOuter_Value interface {
+	isOuter_Value()
+}
+
+type Outer_Number struct {
+	Number int32 `protobuf:"varint,13,opt,name=number,proto3,oneof"`
+}
+
+type Outer_Nested struct {
+	Nested *Inner `protobuf:"bytes,14,opt,name=nested,proto3,oneof"`
+}
+
+func (*Outer_Number) isOuter_Value() {}
+
+func (*Outer_Nested) isOuter_Value() {}
+
+var File_test_proto protoreflect.FileDescriptor
+
+const file_test_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"test.proto\x12\x06testpb\x1a\x19validator/validator.proto\"G\n" +
+	"\x05Inner\x12\"\n" +
+	"\x04name\x18\x01 \x01(\tB\x0e\xe2\xdf\x1f\n" +
+	"\n" +
+	"\b^[a-z]+$R\x04name\x12\x1a\n" +
+	"\x03age\x18\x02 \x01(\x05B\b\xe2\xdf\x1f\x04\x10\x00\x18dR\x03age\"\xbc\x05\n" +
+	"\x05Outer\x12\x16\n" +
+	"\x02id\x18\x01 \x01(\tB\x06\xe2\xdf\x1f\x02X\x01R\x02id\x12\x1b\n" +
+	"\x04code\x18\x02 \x01(\tB\a\xe2\xdf\x1f\x03\x80\x01\x03R\x04code\x12\x1a\n" +
+	"\x03key\x18\x03 \x01(\fB\b\xe2\xdf\x1f\x04p\x00x\x05R\x03key\x12\x1c\n" +
+	"\x05count\x18\x04 \x01(\x04B\x06\xe2\xdf\x1f\x02\x18\n" +
+	"R\x05count\x12,\n" +
+	"\x05ratio\x18\x05 \x01(\x01B\x16\xe2\xdf\x1f\x12I\x00\x00\x00\x00\x00\x00\x00\x00Q\x00\x00\x00\x00\x00\x00\xf0?R\x05ratio\x12,\n" +
+	"\x05score\x18\x06 \x01(\x02B\x16\xe2\xdf\x1f\x121\x00\x00\x00\x00\x00\x00\xf8\xbf9\x00\x00\x00\x00\x00\x00\xf8?R\x05score\x12+\n" +
+	"\x05inner\x18\a \x01(\v2\r.testpb.InnerB\x06\xe2\xdf\x1f\x02 \x01R\x05inner\x12+\n" +
+	"\x05items\x18\b \x03(\v2\r.testpb.InnerB\x06\xe2\xdf\x1f\x02h\x02R\x05items\x121\n" +
+	"\x06labels\x18\t \x03(\v2\x19.testpb.Outer.LabelsEntryR\x06labels\x12\x1e\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tB\n" +
+	"\xe2\xdf\x1f\x06\n" +
+	"\x02^#`\x01R\x04tags\x12,\n" +
+	"\x05color\x18\v \x01(\x0e2\r.testpb.ColorB\a\xe2\xdf\x1f\x03\x88\x01\x01R\x05color\x12.\n" +
+	"\x06colors\x18\f \x03(\x0e2\r.testpb.ColorB\a\xe2\xdf\x1f\x03\x88\x01\x01R\x06colors\x12 \n" +
+	"\x06number\x18\r \x01(\x05B\x06\xe2\xdf\x1f\x02\x10\x00H\x00R\x06number\x12'\n" +
+	"\x06nested\x18\x0e \x01(\v2\r.testpb.InnerH\x00R\x06nested\x125\n" +
+	"\x05limit\x18\x0f \x01(\x05B\x1a\xe2\xdf\x1f\x16\x18\n" +
+	"*\x12limit is too largeH\x01R\x05limit\x88\x01\x01\x1aH\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.testpb.InnerR\x05value:\x028\x01B\a\n" +
+	"\x05valueB\b\n" +
+	"\x06_limit*\x1b\n" +
+	"\x05Color\x12\a\n" +
+	"\x03RED\x10\x00\x12\t\n" +
+	"\x05GREEN\x10\x012\x88\x01\n" +
+	"\vTestService\x12%\n" +
+	"\x05Check\x12\r.testpb.Outer\x1a\r.testpb.Inner\x12'\n" +
+	"\x05Watch\x12\r.testpb.Inner\x1a\r.testpb.Inner0\x01\x12)\n" +
+	"\aCollect\x12\r.testpb.Inner\x1a\r.testpb.Inner(\x01BBZ@gobook.examples/ch4-06-grpc-ext/validator/internal/testpb;testpbb\x06proto3"
+
+var (
+	file_test_proto_rawDescOnce sync.Once
+	file_test_proto_rawDescData []byte
+)
+
+func file_test_proto_rawDescGZIP() []byte {
+	file_test_proto_rawDescOnce.Do(func() {
+		file_test_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_test_proto_rawDesc), len(file_test_proto_rawDesc)))
+	})
+	return file_test_proto_rawDescData
+}
+
+var file_test_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_test_proto_goTypes = []any{
+	(Color)(0),    // 0: testpb.Color
+	(*Inner)(nil), // 1: testpb.Inner
+	(*Outer)(nil), // 2: testpb.Outer
+	nil,           // 3: testpb.Outer.LabelsEntry
+}
+var file_test_proto_depIdxs = []int32{
+	1,  // 0: testpb.Outer.inner:type_name -> testpb.Inner
+	1,  // 1: testpb.Outer.items:type_name -> testpb.Inner
+	3,  // 2: testpb.Outer.labels:type_name -> testpb.Outer.LabelsEntry
+	0,  // 3: testpb.Outer.color:type_name -> testpb.Color
+	0,  // 4: testpb.Outer.colors:type_name -> testpb.Color
+	1,  // 5: testpb.Outer.nested:type_name -> testpb.Inner
+	1,  // 6: testpb.Outer.LabelsEntry.value:type_name -> testpb.Inner
+	2,  // 7: testpb.TestService.Check:input_type -> testpb.Outer
+	1,  // 8: testpb.TestService.Watch:input_type -> testpb.Inner
+	1,  // 9: testpb.TestService.Collect:input_type -> testpb.Inner
+	1,  // 10: testpb.TestService.Check:output_type -> testpb.Inner
+	1,  // 11: testpb.TestService.Watch:output_type -> testpb.Inner
+	1,  // 12: testpb.TestService.Collect:output_type -> testpb.Inner
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_test_proto_init() }
+func file_test_proto_init() {
+	if File_test_proto != nil {
+		return
+	}
+	file_test_proto_msgTypes[1].OneofWrappers = []any{
+		(*Outer_Number)(nil),
+		(*Outer_Nested)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_test_proto_rawDesc), len(file_test_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_test_proto_goTypes,
+		DependencyIndexes: file_test_proto_depIdxs,
+		EnumInfos:         file_test_proto_enumTypes,
+		MessageInfos:      file_test_proto_msgTypes,
+	}.Build()
+	File_test_proto = out.File
+	file_test_proto_goTypes = nil
+	file_test_proto_depIdxs = nil
+}
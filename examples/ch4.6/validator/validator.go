@@ -0,0 +1,50 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package validator is the runtime support of the Validate methods
+// generated by protoc-gen-govalidators from the (validator.field) option.
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validator is implemented by the generated messages.
+type Validator interface {
+	Validate() error
+}
+
+// CallValidatorIfExists validates x if it implements Validator.
+func CallValidatorIfExists(x interface{}) error {
+	if v, ok := x.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// Error is the error of an invalid field. Field is the path of the field
+// from the validated message, such as "Items[2].Name" or "Labels[env]".
+type Error struct {
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid field %s: %v", e.Field, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// FieldError returns the error of field. An *Error returned by the
+// Validate method of a nested message gets field prepended to its path.
+func FieldError(field string, err error) error {
+	if e, ok := err.(*Error); ok {
+		if strings.HasPrefix(e.Field, "[") {
+			return &Error{Field: field + e.Field, Err: e.Err}
+		}
+		return &Error{Field: field + "." + e.Field, Err: e.Err}
+	}
+	return &Error{Field: field, Err: err}
+}
@@ -0,0 +1,300 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: validator/validator.proto
+
+package validator
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FieldValidator struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Uses a Golang RE2-syntax regex to match the field contents.
+	Regex *string `protobuf:"bytes,1,opt,name=regex" json:"regex,omitempty"`
+	// Field value of integer strictly greater than this value.
+	IntGt *int64 `protobuf:"varint,2,opt,name=int_gt,json=intGt" json:"int_gt,omitempty"`
+	// Field value of integer strictly smaller than this value.
+	IntLt *int64 `protobuf:"varint,3,opt,name=int_lt,json=intLt" json:"int_lt,omitempty"`
+	// Used for nested message types, requires that the message type exists.
+	MsgExists *bool `protobuf:"varint,4,opt,name=msg_exists,json=msgExists" json:"msg_exists,omitempty"`
+	// Human error specifies a user-customizable error that is visible to the user.
+	HumanError *string `protobuf:"bytes,5,opt,name=human_error,json=humanError" json:"human_error,omitempty"`
+	// Field value of double strictly greater than this value.
+	FloatGt *float64 `protobuf:"fixed64,6,opt,name=float_gt,json=floatGt" json:"float_gt,omitempty"`
+	// Field value of double strictly smaller than this value.
+	FloatLt *float64 `protobuf:"fixed64,7,opt,name=float_lt,json=floatLt" json:"float_lt,omitempty"`
+	// Field value of double greater than or equal to this value.
+	FloatGte *float64 `protobuf:"fixed64,9,opt,name=float_gte,json=floatGte" json:"float_gte,omitempty"`
+	// Field value of double smaller than or equal to this value.
+	FloatLte *float64 `protobuf:"fixed64,10,opt,name=float_lte,json=floatLte" json:"float_lte,omitempty"`
+	// Used for string fields, requires the string to be not empty.
+	StringNotEmpty *bool `protobuf:"varint,11,opt,name=string_not_empty,json=stringNotEmpty" json:"string_not_empty,omitempty"`
+	// Repeated field with at least this number of elements.
+	RepeatedCountMin *int64 `protobuf:"varint,12,opt,name=repeated_count_min,json=repeatedCountMin" json:"repeated_count_min,omitempty"`
+	// Repeated field with at most this number of elements.
+	RepeatedCountMax *int64 `protobuf:"varint,13,opt,name=repeated_count_max,json=repeatedCountMax" json:"repeated_count_max,omitempty"`
+	// Field value of length greater than this value.
+	LengthGt *int64 `protobuf:"varint,14,opt,name=length_gt,json=lengthGt" json:"length_gt,omitempty"`
+	// Field value of length smaller than this value.
+	LengthLt *int64 `protobuf:"varint,15,opt,name=length_lt,json=lengthLt" json:"length_lt,omitempty"`
+	// Field value of length strictly equal to this value.
+	LengthEq *int64 `protobuf:"varint,16,opt,name=length_eq,json=lengthEq" json:"length_eq,omitempty"`
+	// Requires that the value is in the enum.
+	IsInEnum      *bool `protobuf:"varint,17,opt,name=is_in_enum,json=isInEnum" json:"is_in_enum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldValidator) Reset() {
+	*x = FieldValidator{}
+	mi := &file_validator_validator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldValidator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldValidator) ProtoMessage() {}
+
+func (x *FieldValidator) ProtoReflect() protoreflect.Message {
+	mi := &file_validator_validator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldValidator.ProtoReflect.Descriptor instead.
+func (*FieldValidator) Descriptor() ([]byte, []int) {
+	return file_validator_validator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FieldValidator) GetRegex() string {
+	if x != nil && x.Regex != nil {
+		return *x.Regex
+	}
+	return ""
+}
+
+func (x *FieldValidator) GetIntGt() int64 {
+	if x != nil && x.IntGt != nil {
+		return *x.IntGt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetIntLt() int64 {
+	if x != nil && x.IntLt != nil {
+		return *x.IntLt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetMsgExists() bool {
+	if x != nil && x.MsgExists != nil {
+		return *x.MsgExists
+	}
+	return false
+}
+
+func (x *FieldValidator) GetHumanError() string {
+	if x != nil && x.HumanError != nil {
+		return *x.HumanError
+	}
+	return ""
+}
+
+func (x *FieldValidator) GetFloatGt() float64 {
+	if x != nil && x.FloatGt != nil {
+		return *x.FloatGt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetFloatLt() float64 {
+	if x != nil && x.FloatLt != nil {
+		return *x.FloatLt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetFloatGte() float64 {
+	if x != nil && x.FloatGte != nil {
+		return *x.FloatGte
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetFloatLte() float64 {
+	if x != nil && x.FloatLte != nil {
+		return *x.FloatLte
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetStringNotEmpty() bool {
+	if x != nil && x.StringNotEmpty != nil {
+		return *x.StringNotEmpty
+	}
+	return false
+}
+
+func (x *FieldValidator) GetRepeatedCountMin() int64 {
+	if x != nil && x.RepeatedCountMin != nil {
+		return *x.RepeatedCountMin
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetRepeatedCountMax() int64 {
+	if x != nil && x.RepeatedCountMax != nil {
+		return *x.RepeatedCountMax
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetLengthGt() int64 {
+	if x != nil && x.LengthGt != nil {
+		return *x.LengthGt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetLengthLt() int64 {
+	if x != nil && x.LengthLt != nil {
+		return *x.LengthLt
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetLengthEq() int64 {
+	if x != nil && x.LengthEq != nil {
+		return *x.LengthEq
+	}
+	return 0
+}
+
+func (x *FieldValidator) GetIsInEnum() bool {
+	if x != nil && x.IsInEnum != nil {
+		return *x.IsInEnum
+	}
+	return false
+}
+
+var file_validator_validator_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*FieldValidator)(nil),
+		Field:         65020,
+		Name:          "validator.field",
+		Tag:           "bytes,65020,opt,name=field",
+		Filename:      "validator/validator.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional validator.FieldValidator field = 65020;
+	E_Field = &file_validator_validator_proto_extTypes[0]
+)
+
+var File_validator_validator_proto protoreflect.FileDescriptor
+
+const file_validator_validator_proto_rawDesc = "" +
+	"\n" +
+	"\x19validator/validator.proto\x12\tvalidator\x1a google/protobuf/descriptor.proto\"\xff\x03\n" +
+	"\x0eFieldValidator\x12\x14\n" +
+	"\x05regex\x18\x01 \x01(\tR\x05regex\x12\x15\n" +
+	"\x06int_gt\x18\x02 \x01(\x03R\x05intGt\x12\x15\n" +
+	"\x06int_lt\x18\x03 \x01(\x03R\x05intLt\x12\x1d\n" +
+	"\n" +
+	"msg_exists\x18\x04 \x01(\bR\tmsgExists\x12\x1f\n" +
+	"\vhuman_error\x18\x05 \x01(\tR\n" +
+	"humanError\x12\x19\n" +
+	"\bfloat_gt\x18\x06 \x01(\x01R\afloatGt\x12\x19\n" +
+	"\bfloat_lt\x18\a \x01(\x01R\afloatLt\x12\x1b\n" +
+	"\tfloat_gte\x18\t \x01(\x01R\bfloatGte\x12\x1b\n" +
+	"\tfloat_lte\x18\n" +
+	" \x01(\x01R\bfloatLte\x12(\n" +
+	"\x10string_not_empty\x18\v \x01(\bR\x0estringNotEmpty\x12,\n" +
+	"\x12repeated_count_min\x18\f \x01(\x03R\x10repeatedCountMin\x12,\n" +
+	"\x12repeated_count_max\x18\r \x01(\x03R\x10repeatedCountMax\x12\x1b\n" +
+	"\tlength_gt\x18\x0e \x01(\x03R\blengthGt\x12\x1b\n" +
+	"\tlength_lt\x18\x0f \x01(\x03R\blengthLt\x12\x1b\n" +
+	"\tlength_eq\x18\x10 \x01(\x03R\blengthEq\x12\x1c\n" +
+	"\n" +
+	"is_in_enum\x18\x11 \x01(\bR\bisInEnum:P\n" +
+	"\x05field\x12\x1d.google.protobuf.FieldOptions\x18\xfc\xfb\x03 \x01(\v2\x19.validator.FieldValidatorR\x05fieldB5Z3gobook.examples/ch4-06-grpc-ext/validator;validator"
+
+var (
+	file_validator_validator_proto_rawDescOnce sync.Once
+	file_validator_validator_proto_rawDescData []byte
+)
+
+func file_validator_validator_proto_rawDescGZIP() []byte {
+	file_validator_validator_proto_rawDescOnce.Do(func() {
+		file_validator_validator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_validator_validator_proto_rawDesc), len(file_validator_validator_proto_rawDesc)))
+	})
+	return file_validator_validator_proto_rawDescData
+}
+
+var file_validator_validator_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_validator_validator_proto_goTypes = []any{
+	(*FieldValidator)(nil),            // 0: validator.FieldValidator
+	(*descriptorpb.FieldOptions)(nil), // 1: google.protobuf.FieldOptions
+}
+var file_validator_validator_proto_depIdxs = []int32{
+	1, // 0: validator.field:extendee -> google.protobuf.FieldOptions
+	0, // 1: validator.field:type_name -> validator.FieldValidator
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_validator_validator_proto_init() }
+func file_validator_validator_proto_init() {
+	if File_validator_validator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_validator_validator_proto_rawDesc), len(file_validator_validator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_validator_validator_proto_goTypes,
+		DependencyIndexes: file_validator_validator_proto_depIdxs,
+		MessageInfos:      file_validator_validator_proto_msgTypes,
+		ExtensionInfos:    file_validator_validator_proto_extTypes,
+	}.Build()
+	File_validator_validator_proto = out.File
+	file_validator_validator_proto_goTypes = nil
+	file_validator_validator_proto_depIdxs = nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package validator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"gobook.examples/ch4-06-grpc-ext/validator"
+	pb "gobook.examples/ch4-06-grpc-ext/validator/internal/testpb"
+)
+
+func newOuter() *pb.Outer {
+	return &pb.Outer{
+		Id:    "id",
+		Code:  "abc",
+		Key:   []byte("k"),
+		Count: 9,
+		Ratio: 0.5,
+		Inner: &pb.Inner{Name: "gopher", Age: 10},
+		Items: []*pb.Inner{{Name: "a", Age: 1}},
+		Labels: map[string]*pb.Inner{
+			"env": {Name: "b", Age: 2},
+		},
+		Tags:   []string{"#go"},
+		Colors: []pb.Color{pb.Color_GREEN},
+		Value:  &pb.Outer_Number{Number: 1},
+		Limit:  proto.Int32(9),
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := newOuter().Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (*pb.Outer)(nil).Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		field  string
+		modify func(x *pb.Outer)
+	}{
+		{"Id", func(x *pb.Outer) { x.Id = "" }},
+		{"Code", func(x *pb.Outer) { x.Code = "ab" }},
+		{"Code", func(x *pb.Outer) { x.Code = "abcd" }},
+		{"Key", func(x *pb.Outer) { x.Key = nil }},
+		{"Key", func(x *pb.Outer) { x.Key = []byte("12345") }},
+		{"Count", func(x *pb.Outer) { x.Count = 10 }},
+		{"Ratio", func(x *pb.Outer) { x.Ratio = 1.1 }},
+		{"Score", func(x *pb.Outer) { x.Score = -1.5 }},
+		{"Inner", func(x *pb.Outer) { x.Inner = nil }},
+		{"Inner.Name", func(x *pb.Outer) { x.Inner.Name = "Gopher" }},
+		{"Inner.Age", func(x *pb.Outer) { x.Inner.Age = 100 }},
+		{"Items", func(x *pb.Outer) { x.Items = append(x.Items, x.Inner, x.Inner) }},
+		{"Items[1].Age", func(x *pb.Outer) { x.Items = append(x.Items, &pb.Inner{Name: "b"}) }},
+		{"Labels[env].Name", func(x *pb.Outer) { x.Labels["env"].Name = "" }},
+		{"Tags", func(x *pb.Outer) { x.Tags = nil }},
+		{"Tags[1]", func(x *pb.Outer) { x.Tags = append(x.Tags, "go") }},
+		{"Color", func(x *pb.Outer) { x.Color = 7 }},
+		{"Colors[1]", func(x *pb.Outer) { x.Colors = append(x.Colors, 7) }},
+		{"Number", func(x *pb.Outer) { x.Value = &pb.Outer_Number{Number: 0} }},
+		{"Nested.Age", func(x *pb.Outer) { x.Value = &pb.Outer_Nested{Nested: &pb.Inner{Name: "a"}} }},
+		{"Limit", func(x *pb.Outer) { x.Limit = proto.Int32(10) }},
+	} {
+		x := newOuter()
+		tt.modify(x)
+
+		err := x.Validate()
+		var e *validator.Error
+		if !errors.As(err, &e) {
+			t.Fatalf("%s: expect *validator.Error, got = %v", tt.field, err)
+		}
+		if e.Field != tt.field {
+			t.Fatalf("expect = %s, got = %s (%v)", tt.field, e.Field, err)
+		}
+	}
+
+	x := newOuter()
+	x.Limit = proto.Int32(10)
+	if err := x.Validate(); err.Error() != "invalid field Limit: limit is too large" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the unset oneof member is not validated
+	x = newOuter()
+	x.Value = nil
+	if err := x.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := validator.UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/testpb.Test/Call"}
+
+	if _, err := interceptor(context.Background(), newOuter(), info, handler); err != nil {
+		t.Fatal(err)
+	}
+
+	x := newOuter()
+	x.Inner.Age = 0
+	_, err := interceptor(context.Background(), x, info, handler)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expect = %v, got = %v", codes.InvalidArgument, err)
+	}
+}
+
+type innerSlice []*pb.Inner
+
+func (s *innerSlice) Send(m *pb.Inner) error {
+	*s = append(*s, m)
+	return nil
+}
+
+func (s *innerSlice) Recv() (*pb.Inner, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	m := (*s)[0]
+	*s = (*s)[1:]
+	return m, nil
+}
+
+func TestRegisterValidate(t *testing.T) {
+	fake := new(pb.FakeTestService)
+	_, client, err := pb.NewTestServicePipe(fake)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// gob can't encode the oneof wrappers
+	x := newOuter()
+	x.Value = nil
+
+	var out pb.Inner
+	if err := client.Check(x, &out); err != nil {
+		t.Fatal(err)
+	}
+	x.Items[0].Age = 0
+	err = client.Check(x, &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid field Items[0].Age") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = client.Watch(&pb.Inner{Name: "a"}, new(innerSlice))
+	if err == nil || !strings.Contains(err.Error(), "invalid field Age") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in := innerSlice{{Name: "a", Age: 1}, {Name: "B", Age: 1}}
+	err = client.Collect(&in, &out)
+	if err == nil || !strings.Contains(err.Error(), "invalid field Name") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(fake.CheckCalls()); n != 1 {
+		t.Fatalf("expect = %d calls, got = %d", 1, n)
+	}
+	if n := len(fake.WatchCalls()); n != 0 {
+		t.Fatalf("expect = %d calls, got = %d", 0, n)
+	}
+}
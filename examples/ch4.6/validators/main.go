@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"gobook.examples/ch4-06-grpc-ext/validator"
+)
+
+var port = ":5000"
+
+type myGrpcServer struct {
+	UnimplementedHelloServiceServer
+}
+
+func (s *myGrpcServer) Hello(ctx context.Context, in *Message) (*Message, error) {
+	return &Message{ImportantString: "hello" + in.ImportantString, Age: in.Age}, nil
+}
+
+func newServer() *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(validator.UnaryServerInterceptor()))
+	RegisterHelloServiceServer(server, new(myGrpcServer))
+	return server
+}
+
+func main() {
+	lis, err := net.Listen("tcp", port)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go newServer().Serve(lis)
+
+	conn, err := grpc.NewClient("localhost"+port, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := NewHelloServiceClient(conn)
+
+	reply, err := client.Hello(context.Background(), &Message{ImportantString: "go", Age: 10})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(reply.ImportantString)
+
+	// rpc error: code = InvalidArgument desc = invalid field Age: value '0' must be greater than '0'
+	_, err = client.Hello(context.Background(), &Message{ImportantString: "go"})
+	log.Println(err)
+}
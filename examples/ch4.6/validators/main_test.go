@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHelloValidate(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	server := newServer()
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := NewHelloServiceClient(conn)
+
+	reply, err := client.Hello(context.Background(), &Message{ImportantString: "go", Age: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.ImportantString != "hellogo" {
+		t.Fatalf("expect = %q, got = %q", "hellogo", reply.ImportantString)
+	}
+
+	for _, in := range []*Message{
+		{ImportantString: "Go", Age: 10},
+		{ImportantString: "go", Age: 0},
+		{ImportantString: "go", Age: 100},
+	} {
+		_, err := client.Hello(context.Background(), in)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("%v: expect = %v, got = %v", in, codes.InvalidArgument, err)
+		}
+	}
+}
@@ -1,255 +1,278 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
 // source: helloworld.proto
 
 package main
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-import descriptor "github.com/golang/protobuf/protoc-gen-go/descriptor"
-
 import (
-	context "golang.org/x/net/context"
-	grpc "google.golang.org/grpc"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
 )
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ = proto.Marshal
-var _ = fmt.Errorf
-var _ = math.Inf
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the proto package it is being compiled against.
-// A compilation error at this line likely means your copy of the
-// proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
 
 type Message struct {
-	Name                 *string  `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          *string                `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (m *Message) Reset()         { *m = Message{} }
-func (m *Message) String() string { return proto.CompactTextString(m) }
-func (*Message) ProtoMessage()    {}
-func (*Message) Descriptor() ([]byte, []int) {
-	return fileDescriptor_helloworld_ed50fb97dddb84f0, []int{0}
-}
-func (m *Message) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Message.Unmarshal(m, b)
-}
-func (m *Message) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Message.Marshal(b, m, deterministic)
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_helloworld_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
-func (dst *Message) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Message.Merge(dst, src)
-}
-func (m *Message) XXX_Size() int {
-	return xxx_messageInfo_Message.Size(m)
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
-func (m *Message) XXX_DiscardUnknown() {
-	xxx_messageInfo_Message.DiscardUnknown(m)
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_helloworld_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var xxx_messageInfo_Message proto.InternalMessageInfo
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_helloworld_proto_rawDescGZIP(), []int{0}
+}
 
-func (m *Message) GetName() string {
-	if m != nil && m.Name != nil {
-		return *m.Name
+func (x *Message) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
 	}
 	return ""
 }
 
 type String struct {
-	Value                *string  `protobuf:"bytes,1,opt,name=value" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         *string                `protobuf:"bytes,1,opt,name=value" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (m *String) Reset()         { *m = String{} }
-func (m *String) String() string { return proto.CompactTextString(m) }
-func (*String) ProtoMessage()    {}
-func (*String) Descriptor() ([]byte, []int) {
-	return fileDescriptor_helloworld_ed50fb97dddb84f0, []int{1}
-}
-func (m *String) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_String.Unmarshal(m, b)
-}
-func (m *String) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_String.Marshal(b, m, deterministic)
-}
-func (dst *String) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_String.Merge(dst, src)
-}
-func (m *String) XXX_Size() int {
-	return xxx_messageInfo_String.Size(m)
+func (x *String) Reset() {
+	*x = String{}
+	mi := &file_helloworld_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
-func (m *String) XXX_DiscardUnknown() {
-	xxx_messageInfo_String.DiscardUnknown(m)
+
+func (x *String) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var xxx_messageInfo_String proto.InternalMessageInfo
+func (*String) ProtoMessage() {}
 
-func (m *String) GetValue() string {
-	if m != nil && m.Value != nil {
-		return *m.Value
+func (x *String) ProtoReflect() protoreflect.Message {
+	mi := &file_helloworld_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-var E_FileOption = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.FileOptions)(nil),
-	ExtensionType: (*String)(nil),
-	Field:         50000,
-	Name:          "main.file_option",
-	Tag:           "bytes,50000,opt,name=file_option,json=fileOption",
-	Filename:      "helloworld.proto",
+// Deprecated: Use String.ProtoReflect.Descriptor instead.
+func (*String) Descriptor() ([]byte, []int) {
+	return file_helloworld_proto_rawDescGZIP(), []int{1}
 }
 
-var E_MessageOption = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.MessageOptions)(nil),
-	ExtensionType: (*String)(nil),
-	Field:         50000,
-	Name:          "main.message_option",
-	Tag:           "bytes,50000,opt,name=message_option,json=messageOption",
-	Filename:      "helloworld.proto",
+func (x *String) GetValue() string {
+	if x != nil && x.Value != nil {
+		return *x.Value
+	}
+	return ""
 }
 
-var E_FiledOption = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.FieldOptions)(nil),
-	ExtensionType: (*String)(nil),
-	Field:         50000,
-	Name:          "main.filed_option",
-	Tag:           "bytes,50000,opt,name=filed_option,json=filedOption",
-	Filename:      "helloworld.proto",
+var file_helloworld_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FileOptions)(nil),
+		ExtensionType: (*String)(nil),
+		Field:         50000,
+		Name:          "main.file_option",
+		Tag:           "bytes,50000,opt,name=file_option",
+		Filename:      "helloworld.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*String)(nil),
+		Field:         50000,
+		Name:          "main.message_option",
+		Tag:           "bytes,50000,opt,name=message_option",
+		Filename:      "helloworld.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*String)(nil),
+		Field:         50000,
+		Name:          "main.filed_option",
+		Tag:           "bytes,50000,opt,name=filed_option",
+		Filename:      "helloworld.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.ServiceOptions)(nil),
+		ExtensionType: (*String)(nil),
+		Field:         50000,
+		Name:          "main.service_option",
+		Tag:           "bytes,50000,opt,name=service_option",
+		Filename:      "helloworld.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*String)(nil),
+		Field:         50000,
+		Name:          "main.method_option",
+		Tag:           "bytes,50000,opt,name=method_option",
+		Filename:      "helloworld.proto",
+	},
 }
 
-var E_ServiceOption = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.ServiceOptions)(nil),
-	ExtensionType: (*String)(nil),
-	Field:         50000,
-	Name:          "main.service_option",
-	Tag:           "bytes,50000,opt,name=service_option,json=serviceOption",
-	Filename:      "helloworld.proto",
-}
+// Extension fields to descriptorpb.FileOptions.
+var (
+	// optional main.String file_option = 50000;
+	E_FileOption = &file_helloworld_proto_extTypes[0]
+)
 
-var E_MethodOption = &proto.ExtensionDesc{
-	ExtendedType:  (*descriptor.MethodOptions)(nil),
-	ExtensionType: (*String)(nil),
-	Field:         50000,
-	Name:          "main.method_option",
-	Tag:           "bytes,50000,opt,name=method_option,json=methodOption",
-	Filename:      "helloworld.proto",
-}
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// optional main.String message_option = 50000;
+	E_MessageOption = &file_helloworld_proto_extTypes[1]
+)
 
-func init() {
-	proto.RegisterType((*Message)(nil), "main.Message")
-	proto.RegisterType((*String)(nil), "main.String")
-	proto.RegisterExtension(E_FileOption)
-	proto.RegisterExtension(E_MessageOption)
-	proto.RegisterExtension(E_FiledOption)
-	proto.RegisterExtension(E_ServiceOption)
-	proto.RegisterExtension(E_MethodOption)
-}
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional main.String filed_option = 50000;
+	E_FiledOption = &file_helloworld_proto_extTypes[2]
+)
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+// Extension fields to descriptorpb.ServiceOptions.
+var (
+	// optional main.String service_option = 50000;
+	E_ServiceOption = &file_helloworld_proto_extTypes[3]
+)
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// optional main.String method_option = 50000;
+	E_MethodOption = &file_helloworld_proto_extTypes[4]
+)
 
-// HelloServiceClient is the client API for HelloService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type HelloServiceClient interface {
-	Hello(ctx context.Context, in *String, opts ...grpc.CallOption) (*String, error)
-}
+var File_helloworld_proto protoreflect.FileDescriptor
 
-type helloServiceClient struct {
-	cc *grpc.ClientConn
-}
+const file_helloworld_proto_rawDesc = "" +
+	"\n" +
+	"\x10helloworld.proto\x12\x04main\x1a google/protobuf/descriptor.proto\";\n" +
+	"\aMessage\x12\x1a\n" +
+	"\x04name\x18\x01 \x01(\tB\x06\x82\xb5\x18\x02\n" +
+	"\x00R\x04name:\x14\x82\xb5\x18\x10\n" +
+	"\x0emessage option\"\x1e\n" +
+	"\x06String\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value2\x83\x01\n" +
+	"\fHelloService\x12+\n" +
+	"\x05Hello\x12\f.main.String\x1a\f.main.String\"\x06\x82\xb5\x18\x02\n" +
+	"\x00\x120\n" +
+	"\x04Ping\x12\f.main.String\x1a\f.main.String\"\f\x82\xb5\x18\b\n" +
+	"\x06public\x1a\x14\x82\xb5\x18\x10\n" +
+	"\x0emessage option:M\n" +
+	"\vfile_option\x12\x1c.google.protobuf.FileOptions\x18І\x03 \x01(\v2\f.main.StringR\n" +
+	"fileOption:V\n" +
+	"\x0emessage_option\x12\x1f.google.protobuf.MessageOptions\x18І\x03 \x01(\v2\f.main.StringR\rmessageOption:P\n" +
+	"\ffiled_option\x12\x1d.google.protobuf.FieldOptions\x18І\x03 \x01(\v2\f.main.StringR\vfiledOption:V\n" +
+	"\x0eservice_option\x12\x1f.google.protobuf.ServiceOptions\x18І\x03 \x01(\v2\f.main.StringR\rserviceOption:S\n" +
+	"\rmethod_option\x12\x1e.google.protobuf.MethodOptions\x18І\x03 \x01(\v2\f.main.StringR\fmethodOptionBG\x82\xb5\x18\x17\n" +
+	"\x15this is a file optionZ*gobook.examples/ch4-07-pbgo/pb-option;main"
 
-func NewHelloServiceClient(cc *grpc.ClientConn) HelloServiceClient {
-	return &helloServiceClient{cc}
-}
+var (
+	file_helloworld_proto_rawDescOnce sync.Once
+	file_helloworld_proto_rawDescData []byte
+)
 
-func (c *helloServiceClient) Hello(ctx context.Context, in *String, opts ...grpc.CallOption) (*String, error) {
-	out := new(String)
-	err := c.cc.Invoke(ctx, "/main.HelloService/Hello", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+func file_helloworld_proto_rawDescGZIP() []byte {
+	file_helloworld_proto_rawDescOnce.Do(func() {
+		file_helloworld_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_helloworld_proto_rawDesc), len(file_helloworld_proto_rawDesc)))
+	})
+	return file_helloworld_proto_rawDescData
 }
 
-// HelloServiceServer is the server API for HelloService service.
-type HelloServiceServer interface {
-	Hello(context.Context, *String) (*String, error)
+var file_helloworld_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_helloworld_proto_goTypes = []any{
+	(*Message)(nil),                     // 0: main.Message
+	(*String)(nil),                      // 1: main.String
+	(*descriptorpb.FileOptions)(nil),    // 2: google.protobuf.FileOptions
+	(*descriptorpb.MessageOptions)(nil), // 3: google.protobuf.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),   // 4: google.protobuf.FieldOptions
+	(*descriptorpb.ServiceOptions)(nil), // 5: google.protobuf.ServiceOptions
+	(*descriptorpb.MethodOptions)(nil),  // 6: google.protobuf.MethodOptions
 }
-
-func RegisterHelloServiceServer(s *grpc.Server, srv HelloServiceServer) {
-	s.RegisterService(&_HelloService_serviceDesc, srv)
+var file_helloworld_proto_depIdxs = []int32{
+	2,  // 0: main.file_option:extendee -> google.protobuf.FileOptions
+	3,  // 1: main.message_option:extendee -> google.protobuf.MessageOptions
+	4,  // 2: main.filed_option:extendee -> google.protobuf.FieldOptions
+	5,  // 3: main.service_option:extendee -> google.protobuf.ServiceOptions
+	6,  // 4: main.method_option:extendee -> google.protobuf.MethodOptions
+	1,  // 5: main.file_option:type_name -> main.String
+	1,  // 6: main.message_option:type_name -> main.String
+	1,  // 7: main.filed_option:type_name -> main.String
+	1,  // 8: main.service_option:type_name -> main.String
+	1,  // 9: main.method_option:type_name -> main.String
+	1,  // 10: main.HelloService.Hello:input_type -> main.String
+	1,  // 11: main.HelloService.Ping:input_type -> main.String
+	1,  // 12: main.HelloService.Hello:output_type -> main.String
+	1,  // 13: main.HelloService.Ping:output_type -> main.String
+	12, // [12:14] is the sub-list for method output_type
+	10, // [10:12] is the sub-list for method input_type
+	5,  // [5:10] is the sub-list for extension type_name
+	0,  // [0:5] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
-func _HelloService_Hello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(String)
-	if err := dec(in); err != nil {
-		return nil, err
+func init() { file_helloworld_proto_init() }
+func file_helloworld_proto_init() {
+	if File_helloworld_proto != nil {
+		return
 	}
-	if interceptor == nil {
-		return srv.(HelloServiceServer).Hello(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/main.HelloService/Hello",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(HelloServiceServer).Hello(ctx, req.(*String))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _HelloService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "main.HelloService",
-	HandlerType: (*HelloServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Hello",
-			Handler:    _HelloService_Hello_Handler,
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_helloworld_proto_rawDesc), len(file_helloworld_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 5,
+			NumServices:   1,
 		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "helloworld.proto",
-}
-
-func init() { proto.RegisterFile("helloworld.proto", fileDescriptor_helloworld_ed50fb97dddb84f0) }
-
-var fileDescriptor_helloworld_ed50fb97dddb84f0 = []byte{
-	// 325 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x51, 0xcf, 0x4e, 0xc2, 0x30,
-	0x1c, 0x16, 0x05, 0x8c, 0x3f, 0x86, 0x21, 0x0d, 0xc6, 0x65, 0x2a, 0x36, 0x9c, 0x48, 0x4c, 0x4a,
-	0xc2, 0x71, 0xde, 0x38, 0x18, 0x2f, 0x44, 0x85, 0xc4, 0xab, 0x99, 0xec, 0xc7, 0x68, 0xd2, 0xae,
-	0xa4, 0x2d, 0x78, 0xe7, 0xe0, 0x1b, 0xf1, 0x1e, 0x3e, 0x92, 0xd9, 0x3a, 0x10, 0x22, 0xe2, 0xf1,
-	0x6b, 0xbf, 0x7e, 0xff, 0x0a, 0x8d, 0x29, 0x0a, 0xa1, 0x3e, 0x94, 0x16, 0x31, 0x9b, 0x69, 0x65,
-	0x15, 0x29, 0xcb, 0x88, 0xa7, 0x01, 0x4d, 0x94, 0x4a, 0x04, 0x76, 0xf3, 0xb3, 0xf7, 0xf9, 0xa4,
-	0x1b, 0xa3, 0x19, 0x6b, 0x3e, 0xb3, 0x4a, 0x3b, 0x5e, 0xfb, 0x1e, 0x4e, 0x07, 0x68, 0x4c, 0x94,
-	0x20, 0x09, 0xa0, 0x9c, 0x46, 0x12, 0xfd, 0x12, 0x2d, 0x75, 0xce, 0xfa, 0xd5, 0xe5, 0xca, 0x3f,
-	0x86, 0xa3, 0x61, 0x7e, 0x16, 0x36, 0x97, 0x2b, 0xbf, 0x01, 0xe7, 0xd2, 0x51, 0xa9, 0x9a, 0x59,
-	0xae, 0xd2, 0x76, 0x0b, 0xaa, 0x23, 0xab, 0x79, 0x9a, 0x90, 0x26, 0x54, 0x16, 0x91, 0x98, 0x17,
-	0x8f, 0x87, 0x0e, 0xf4, 0x5e, 0xc0, 0x7b, 0xcc, 0x82, 0x8d, 0x50, 0x2f, 0xf8, 0x18, 0xc9, 0x1d,
-	0x54, 0x72, 0x4c, 0x3c, 0x96, 0xc5, 0x63, 0xee, 0x71, 0xb0, 0x83, 0xda, 0x85, 0x71, 0xb0, 0xd7,
-	0x32, 0x1c, 0x40, 0x6d, 0xc2, 0x05, 0xbe, 0x39, 0x48, 0xae, 0x99, 0x6b, 0xc8, 0xd6, 0x0d, 0xd9,
-	0x03, 0x17, 0xf8, 0x94, 0x5f, 0x1a, 0xff, 0xeb, 0xf3, 0x84, 0x96, 0x3a, 0xb5, 0xde, 0x8e, 0xc1,
-	0x10, 0x26, 0x1b, 0x4a, 0xf8, 0xba, 0x31, 0x58, 0x2b, 0xde, 0xfe, 0x52, 0x2c, 0xf6, 0x39, 0x2c,
-	0x5a, 0x97, 0xdb, 0xac, 0xf0, 0x19, 0xbc, 0xcc, 0x25, 0x5e, 0xab, 0xde, 0xec, 0xc9, 0x89, 0x22,
-	0x3e, 0xac, 0x99, 0x37, 0x8d, 0x7f, 0x92, 0x1a, 0x37, 0xe3, 0xdf, 0x49, 0x8b, 0x9d, 0xff, 0x49,
-	0x6a, 0xb6, 0x59, 0xe1, 0x08, 0xea, 0x12, 0xed, 0x54, 0x6d, 0xa2, 0xb6, 0xf6, 0x0c, 0x90, 0xdd,
-	0x1f, 0x56, 0xf5, 0xe4, 0x16, 0xa9, 0x7f, 0xb5, 0x5c, 0xf9, 0x97, 0x70, 0x61, 0xa7, 0xdc, 0x50,
-	0x6e, 0x68, 0x44, 0xb3, 0x26, 0xc5, 0x17, 0x7e, 0x07, 0x00, 0x00, 0xff, 0xff, 0xe6, 0xb9, 0x93,
-	0x70, 0xad, 0x02, 0x00, 0x00,
+		GoTypes:           file_helloworld_proto_goTypes,
+		DependencyIndexes: file_helloworld_proto_depIdxs,
+		MessageInfos:      file_helloworld_proto_msgTypes,
+		ExtensionInfos:    file_helloworld_proto_extTypes,
+	}.Build()
+	File_helloworld_proto = out.File
+	file_helloworld_proto_goTypes = nil
+	file_helloworld_proto_depIdxs = nil
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"gobook.examples/ch4-07-pbgo/pbgo"
+)
+
+func main() {
+	fd := File_helloworld_proto
+	md := fd.Messages().ByName("Message")
+	sd := fd.Services().ByName("HelloService")
+
+	for _, x := range []struct {
+		d  protoreflect.Descriptor
+		xt protoreflect.ExtensionType
+	}{
+		{fd, E_FileOption},
+		{md, E_MessageOption},
+		{md.Fields().ByName("name"), E_FiledOption},
+		{sd, E_ServiceOption},
+		{sd.Methods().ByName("Hello"), E_MethodOption},
+	} {
+		if v, ok := pbgo.GetOption(x.d, x.xt); ok {
+			fmt.Printf("%s: %q\n", x.d.FullName(), v.(*String).GetValue())
+		}
+	}
+
+	// all the methods whose method_option is "public"
+	idx := pbgo.NewOptionIndex(E_MethodOption, nil)
+	for _, opt := range idx.Lookup(&String{Value: proto.String("public")}) {
+		fmt.Println("public:", opt.Descriptor.FullName())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"gobook.examples/ch4-07-pbgo/pbgo"
+)
+
+func TestGetOption(t *testing.T) {
+	fd := File_helloworld_proto
+
+	v, ok := pbgo.GetOption(fd, E_FileOption)
+	if !ok || v.(*String).GetValue() != "this is a file option" {
+		t.Fatalf("unexpected file option: %v", v)
+	}
+
+	name := fd.Messages().ByName("Message").Fields().ByName("name")
+	if v, ok := pbgo.GetOption(name, E_FiledOption); !ok || v.(*String).GetValue() != "" {
+		t.Fatalf("unexpected field option: %v", v)
+	}
+	if _, ok := pbgo.GetOption(name, E_MethodOption); ok {
+		t.Fatal("expect no method option on a field")
+	}
+	if _, ok := pbgo.GetOption(fd.Messages().ByName("String"), E_MessageOption); ok {
+		t.Fatal("expect no message option on String")
+	}
+}
+
+func TestOptionIndex(t *testing.T) {
+	idx := pbgo.NewOptionIndex(E_MethodOption, nil)
+
+	var names []protoreflect.FullName
+	for _, md := range idx.Methods() {
+		names = append(names, md.FullName())
+	}
+	if len(names) != 2 || names[0] != "main.HelloService.Hello" || names[1] != "main.HelloService.Ping" {
+		t.Fatalf("unexpected methods: %v", names)
+	}
+
+	public := idx.Lookup(&String{Value: proto.String("public")})
+	if len(public) != 1 || public[0].Descriptor.FullName() != "main.HelloService.Ping" {
+		t.Fatalf("unexpected lookup: %v", public)
+	}
+
+	v, ok := idx.Get("main.HelloService.Ping")
+	if !ok || v.(*String).GetValue() != "public" {
+		t.Fatalf("unexpected option: %v", v)
+	}
+
+	fields := pbgo.NewOptionIndex(E_FiledOption, nil).Filter(func(d protoreflect.Descriptor, v interface{}) bool {
+		_, ok := d.(protoreflect.FieldDescriptor)
+		return ok
+	})
+	if len(fields) != 1 || fields[0].Descriptor.FullName() != "main.Message.name" {
+		t.Fatalf("unexpected fields: %v", fields)
+	}
+}
@@ -0,0 +1,87 @@
+// Code generated by protoc-gen-go-netrpc. DO NOT EDIT.
+// source: hello.proto
+
+package hello
+
+import (
+	proto "google.golang.org/protobuf/proto"
+	net "net"
+	rpc "net/rpc"
+	sync "sync"
+)
+
+// NewHelloServicePipe serves x on a new rpc.Server and returns a client
+// connected to it through net.Pipe, so tests go through the real net/rpc
+// path without opening ports. Closing the client stops the server.
+func NewHelloServicePipe(x HelloServiceInterface) (*rpc.Server, *HelloServiceClient, error) {
+	srv := rpc.NewServer()
+	if err := RegisterHelloService(srv, x); err != nil {
+		return nil, nil, err
+	}
+
+	c, s := net.Pipe()
+	go srv.ServeConn(s)
+	return srv, &HelloServiceClient{Client: rpc.NewClient(c)}, nil
+}
+
+// FakeHelloService is an in-memory HelloServiceInterface for tests.
+//
+// Every call is recorded. The replies are taken from the queue filled by
+// the Return methods, then from the Func hooks; a method with neither
+// returns an empty reply. The hooks must be set before the fake is used.
+type FakeHelloService struct {
+	HelloFunc func(in *String, out *String) error
+
+	mu           sync.Mutex
+	callsHello   []*String
+	repliesHello []_FakeHelloService_Hello_Reply
+}
+
+var _ HelloServiceInterface = (*FakeHelloService)(nil)
+
+type _FakeHelloService_Hello_Reply struct {
+	out *String
+	err error
+}
+
+// ReturnHello queues the reply of the next Hello call.
+func (f *FakeHelloService) ReturnHello(out *String, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repliesHello = append(f.repliesHello, _FakeHelloService_Hello_Reply{out: out, err: err})
+}
+
+// HelloCalls returns the requests of the Hello calls so far.
+func (f *FakeHelloService) HelloCalls() []*String {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*String(nil), f.callsHello...)
+}
+
+func (f *FakeHelloService) nextHelloReply() (*_FakeHelloService_Hello_Reply, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.repliesHello) == 0 {
+		return nil, false
+	}
+	r := f.repliesHello[0]
+	f.repliesHello = f.repliesHello[1:]
+	return &r, true
+}
+
+func (f *FakeHelloService) Hello(in *String, out *String) error {
+	f.mu.Lock()
+	f.callsHello = append(f.callsHello, in)
+	f.mu.Unlock()
+
+	if r, ok := f.nextHelloReply(); ok {
+		if r.out != nil {
+			proto.Merge(out, r.out)
+		}
+		return r.err
+	}
+	if f.HelloFunc != nil {
+		return f.HelloFunc(in, out)
+	}
+	return nil
+}
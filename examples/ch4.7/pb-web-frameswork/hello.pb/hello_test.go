@@ -0,0 +1,58 @@
+package hello
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHelloServiceHandler(t *testing.T) {
+	fake := &FakeHelloService{
+		HelloFunc: func(in *String, out *String) error {
+			out.Value = "hello:" + in.GetValue()
+			return nil
+		},
+	}
+	ts := httptest.NewServer(HelloServiceHandler(fake))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+		reply              string
+	}{
+		{"GET", "/hello/gopher", "", http.StatusOK, `{"value":"hello:gopher"}`},
+		{"GET", "/hello/gopher?value=ignored", "", http.StatusOK, `{"value":"hello:gopher"}`},
+		{"POST", "/hello", `{"value":"body"}`, http.StatusOK, `{"value":"hello:body"}`},
+		{"POST", "/hello?value=query", `{"value":"body"}`, http.StatusOK, `{"value":"hello:query"}`},
+		{"POST", "/hello", `{"value":`, http.StatusBadRequest, ""},
+		{"PUT", "/hello", "", http.StatusMethodNotAllowed, ""},
+		{"GET", "/hello", "", http.StatusMethodNotAllowed, ""},
+		{"GET", "/hello/a/b", "", http.StatusNotFound, ""},
+	} {
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.code {
+			t.Errorf("%s %s: expect = %d, got = %d (%s)", tt.method, tt.path, tt.code, resp.StatusCode, data)
+			continue
+		}
+		if tt.reply != "" && strings.Join(strings.Fields(string(data)), "") != tt.reply {
+			t.Errorf("%s %s: expect = %s, got = %s", tt.method, tt.path, tt.reply, data)
+		}
+	}
+
+	if n := len(fake.HelloCalls()); n != 4 {
+		t.Fatalf("expect = %d calls, got = %d", 4, n)
+	}
+}
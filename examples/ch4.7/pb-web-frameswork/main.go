@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	pb "gobook.examples/ch4-07-pbgo/pb-web-frameswork/hello.pb"
+)
+
+type HelloService struct{}
+
+func (p *HelloService) Hello(request *pb.String, reply *pb.String) error {
+	reply.Value = "hello:" + request.GetValue()
+	return nil
+}
+
+func main() {
+	router := pb.HelloServiceHandler(new(HelloService))
+	log.Fatal(http.ListenAndServe(":8080", router))
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbgo
+
+import (
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// GetOption returns the value of the custom option xt of d, a file,
+// message, field, service or method descriptor. The value has the Go type
+// of the extension, such as *pbgo.HttpRule for pbgo.E_RestApi.
+func GetOption(d protoreflect.Descriptor, xt protoreflect.ExtensionType) (interface{}, bool) {
+	opts := d.Options()
+	if opts == nil || !proto.HasExtension(opts, xt) {
+		return nil, false
+	}
+	return proto.GetExtension(opts, xt), true
+}
+
+// Option is a descriptor which has the option of an OptionIndex set.
+type Option struct {
+	Descriptor protoreflect.Descriptor
+	Value      interface{}
+}
+
+// OptionIndex holds the descriptors which have a custom option set, in
+// the order of the registry, so that routers, middlewares and doc
+// generators can be driven by the proto annotations:
+//
+//	idx := pbgo.NewOptionIndex(pbgo.E_RestApi, nil)
+//	for _, opt := range idx.All() {
+//		md := opt.Descriptor.(protoreflect.MethodDescriptor)
+//		rule := opt.Value.(*pbgo.HttpRule)
+//		...
+//	}
+type OptionIndex struct {
+	xt      protoreflect.ExtensionType
+	options []Option
+	byName  map[protoreflect.FullName]int
+}
+
+// NewOptionIndex indexes the option xt of the descriptors in files, or in
+// protoregistry.GlobalFiles if files is nil.
+func NewOptionIndex(xt protoreflect.ExtensionType, files *protoregistry.Files) *OptionIndex {
+	if files == nil {
+		files = protoregistry.GlobalFiles
+	}
+
+	p := &OptionIndex{xt: xt, byName: make(map[protoreflect.FullName]int)}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		p.add(fd)
+		p.addMessages(fd.Messages())
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			p.add(sd)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				p.add(sd.Methods().Get(j))
+			}
+		}
+		return true
+	})
+	return p
+}
+
+func (p *OptionIndex) addMessages(messages protoreflect.MessageDescriptors) {
+	for i := 0; i < messages.Len(); i++ {
+		md := messages.Get(i)
+		p.add(md)
+		for j := 0; j < md.Fields().Len(); j++ {
+			p.add(md.Fields().Get(j))
+		}
+		p.addMessages(md.Messages())
+	}
+}
+
+func (p *OptionIndex) add(d protoreflect.Descriptor) {
+	if v, ok := GetOption(d, p.xt); ok {
+		if _, isFile := d.(protoreflect.FileDescriptor); !isFile {
+			p.byName[d.FullName()] = len(p.options)
+		}
+		p.options = append(p.options, Option{Descriptor: d, Value: v})
+	}
+}
+
+// All returns the descriptors which have the option set.
+func (p *OptionIndex) All() []Option {
+	return append([]Option(nil), p.options...)
+}
+
+// Get returns the option of the message, field, service or method named
+// name, such as "pkg.HelloService.Hello".
+func (p *OptionIndex) Get(name protoreflect.FullName) (interface{}, bool) {
+	if i, ok := p.byName[name]; ok {
+		return p.options[i].Value, true
+	}
+	return nil, false
+}
+
+// Filter returns the descriptors whose option value satisfies fn.
+func (p *OptionIndex) Filter(fn func(d protoreflect.Descriptor, v interface{}) bool) []Option {
+	var list []Option
+	for _, opt := range p.options {
+		if fn(opt.Descriptor, opt.Value) {
+			list = append(list, opt)
+		}
+	}
+	return list
+}
+
+// Lookup returns the descriptors whose option value equals v, compared
+// with proto.Equal for messages and reflect.DeepEqual otherwise.
+func (p *OptionIndex) Lookup(v interface{}) []Option {
+	return p.Filter(func(_ protoreflect.Descriptor, x interface{}) bool {
+		if m, ok := v.(proto.Message); ok {
+			xm, ok := x.(proto.Message)
+			return ok && proto.Equal(m, xm)
+		}
+		return reflect.DeepEqual(x, v)
+	})
+}
+
+// Methods returns the methods which have the option set.
+func (p *OptionIndex) Methods() []protoreflect.MethodDescriptor {
+	var list []protoreflect.MethodDescriptor
+	for _, opt := range p.options {
+		if md, ok := opt.Descriptor.(protoreflect.MethodDescriptor); ok {
+			list = append(list, md)
+		}
+	}
+	return list
+}
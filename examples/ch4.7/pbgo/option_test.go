@@ -0,0 +1,36 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbgo_test
+
+import (
+	"testing"
+
+	hello "gobook.examples/ch4-07-pbgo/pb-web-frameswork/hello.pb"
+	"gobook.examples/ch4-07-pbgo/pbgo"
+)
+
+func TestRestApiIndex(t *testing.T) {
+	idx := pbgo.NewOptionIndex(pbgo.E_RestApi, nil)
+
+	v, ok := idx.Get("hello.HelloService.Hello")
+	if !ok {
+		t.Fatal("hello.HelloService.Hello not indexed")
+	}
+	if rule := v.(*pbgo.HttpRule); rule.GetGet() != "/hello/:value" || rule.GetPost() != "/hello" {
+		t.Fatalf("unexpected rule: %v", rule)
+	}
+
+	md := hello.File_hello_proto.Services().Get(0).Methods().Get(0)
+	if v, ok := pbgo.GetOption(md, pbgo.E_RestApi); !ok || v.(*pbgo.HttpRule).GetGet() != "/hello/:value" {
+		t.Fatalf("unexpected option: %v", v)
+	}
+
+	if got := idx.Lookup(&pbgo.HttpRule{Get: "/hello/:value", Post: "/hello"}); len(got) != 1 {
+		t.Fatalf("unexpected lookup: %v", got)
+	}
+	if got := idx.Lookup(&pbgo.HttpRule{Get: "/hello/:value"}); len(got) != 0 {
+		t.Fatalf("unexpected lookup: %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: pbgo/pbgo.proto
+
+package pbgo
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HttpRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Get           string                 `protobuf:"bytes,1,opt,name=get,proto3" json:"get,omitempty"`
+	Put           string                 `protobuf:"bytes,2,opt,name=put,proto3" json:"put,omitempty"`
+	Post          string                 `protobuf:"bytes,3,opt,name=post,proto3" json:"post,omitempty"`
+	Delete        string                 `protobuf:"bytes,4,opt,name=delete,proto3" json:"delete,omitempty"`
+	Patch         string                 `protobuf:"bytes,5,opt,name=patch,proto3" json:"patch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HttpRule) Reset() {
+	*x = HttpRule{}
+	mi := &file_pbgo_pbgo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HttpRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HttpRule) ProtoMessage() {}
+
+func (x *HttpRule) ProtoReflect() protoreflect.Message {
+	mi := &file_pbgo_pbgo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HttpRule.ProtoReflect.Descriptor instead.
+func (*HttpRule) Descriptor() ([]byte, []int) {
+	return file_pbgo_pbgo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *HttpRule) GetGet() string {
+	if x != nil {
+		return x.Get
+	}
+	return ""
+}
+
+func (x *HttpRule) GetPut() string {
+	if x != nil {
+		return x.Put
+	}
+	return ""
+}
+
+func (x *HttpRule) GetPost() string {
+	if x != nil {
+		return x.Post
+	}
+	return ""
+}
+
+func (x *HttpRule) GetDelete() string {
+	if x != nil {
+		return x.Delete
+	}
+	return ""
+}
+
+func (x *HttpRule) GetPatch() string {
+	if x != nil {
+		return x.Patch
+	}
+	return ""
+}
+
+var file_pbgo_pbgo_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*HttpRule)(nil),
+		Field:         20180715,
+		Name:          "pbgo.rest_api",
+		Tag:           "bytes,20180715,opt,name=rest_api",
+		Filename:      "pbgo/pbgo.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// optional pbgo.HttpRule rest_api = 20180715;
+	E_RestApi = &file_pbgo_pbgo_proto_extTypes[0]
+)
+
+var File_pbgo_pbgo_proto protoreflect.FileDescriptor
+
+const file_pbgo_pbgo_proto_rawDesc = "" +
+	"\n" +
+	"\x0fpbgo/pbgo.proto\x12\x04pbgo\x1a google/protobuf/descriptor.proto\"p\n" +
+	"\bHttpRule\x12\x10\n" +
+	"\x03get\x18\x01 \x01(\tR\x03get\x12\x10\n" +
+	"\x03put\x18\x02 \x01(\tR\x03put\x12\x12\n" +
+	"\x04post\x18\x03 \x01(\tR\x04post\x12\x16\n" +
+	"\x06delete\x18\x04 \x01(\tR\x06delete\x12\x14\n" +
+	"\x05patch\x18\x05 \x01(\tR\x05patch:L\n" +
+	"\brest_api\x12\x1e.google.protobuf.MethodOptions\x18\xeb\xdd\xcf\t \x01(\v2\x0e.pbgo.HttpRuleR\arestApiB'Z%gobook.examples/ch4-07-pbgo/pbgo;pbgob\x06proto3"
+
+var (
+	file_pbgo_pbgo_proto_rawDescOnce sync.Once
+	file_pbgo_pbgo_proto_rawDescData []byte
+)
+
+func file_pbgo_pbgo_proto_rawDescGZIP() []byte {
+	file_pbgo_pbgo_proto_rawDescOnce.Do(func() {
+		file_pbgo_pbgo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pbgo_pbgo_proto_rawDesc), len(file_pbgo_pbgo_proto_rawDesc)))
+	})
+	return file_pbgo_pbgo_proto_rawDescData
+}
+
+var file_pbgo_pbgo_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_pbgo_pbgo_proto_goTypes = []any{
+	(*HttpRule)(nil),                   // 0: pbgo.HttpRule
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_pbgo_pbgo_proto_depIdxs = []int32{
+	1, // 0: pbgo.rest_api:extendee -> google.protobuf.MethodOptions
+	0, // 1: pbgo.rest_api:type_name -> pbgo.HttpRule
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	1, // [1:2] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_pbgo_pbgo_proto_init() }
+func file_pbgo_pbgo_proto_init() {
+	if File_pbgo_pbgo_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pbgo_pbgo_proto_rawDesc), len(file_pbgo_pbgo_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_pbgo_pbgo_proto_goTypes,
+		DependencyIndexes: file_pbgo_pbgo_proto_depIdxs,
+		MessageInfos:      file_pbgo_pbgo_proto_msgTypes,
+		ExtensionInfos:    file_pbgo_pbgo_proto_extTypes,
+	}.Build()
+	File_pbgo_pbgo_proto = out.File
+	file_pbgo_pbgo_proto_goTypes = nil
+	file_pbgo_pbgo_proto_depIdxs = nil
+}
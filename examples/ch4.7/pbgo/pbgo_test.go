@@ -0,0 +1,113 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbgo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestPopulateFieldFromPath(t *testing.T) {
+	var msg descriptorpb.FieldDescriptorProto
+
+	for _, tt := range []struct {
+		path   string
+		values []string
+	}{
+		{"name", []string{"id"}},
+		{"number", []string{"7"}},
+		{"type", []string{"TYPE_INT64"}},
+		{"label", []string{"3"}},
+		{"jsonName", []string{"ID"}},
+		{"options.packed", []string{"true"}},
+		{"options.ctype", []string{"CORD"}},
+	} {
+		if err := PopulateFieldFromPath(&msg, tt.path, tt.values...); err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+	}
+
+	if msg.GetName() != "id" || msg.GetNumber() != 7 ||
+		msg.GetType() != descriptorpb.FieldDescriptorProto_TYPE_INT64 ||
+		msg.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED ||
+		msg.GetJsonName() != "ID" ||
+		!msg.GetOptions().GetPacked() ||
+		msg.GetOptions().GetCtype() != descriptorpb.FieldOptions_CORD {
+		t.Fatalf("unexpected message: %v", &msg)
+	}
+
+	for _, path := range []string{"unknown", "name.value", "options"} {
+		if err := PopulateFieldFromPath(&msg, path, "x"); err == nil {
+			t.Fatalf("%s: expect error", path)
+		}
+	}
+	if err := PopulateFieldFromPath(&msg, "number", "x"); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestPopulateQueryParameters(t *testing.T) {
+	var msg descriptorpb.FileDescriptorProto
+
+	query := url.Values{
+		"name":                 {"a.proto"},
+		"dependency":           {"b.proto", "c.proto"},
+		"public_dependency":    {"0", "1"},
+		"options.java_package": {"com.example"},
+		"unknown":              {"ignored"},
+	}
+	if err := PopulateQueryParameters(&msg, query); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.GetName() != "a.proto" ||
+		len(msg.GetDependency()) != 2 || msg.GetDependency()[1] != "c.proto" ||
+		len(msg.GetPublicDependency()) != 2 || msg.GetPublicDependency()[1] != 1 ||
+		msg.GetOptions().GetJavaPackage() != "com.example" {
+		t.Fatalf("unexpected message: %v", &msg)
+	}
+
+	if err := PopulateQueryParameters(&msg, url.Values{"public_dependency": {"x"}}); err == nil {
+		t.Fatal("expect error")
+	}
+}
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+	handle := func(name string) Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			w.Write([]byte(name + ":" + ps.ByName("id") + ":" + ps.ByName("path")))
+		}
+	}
+	router.Handle("GET", "/users/:id", handle("get"))
+	router.Handle("DELETE", "/users/:id", handle("delete"))
+	router.Handle("GET", "/files/*path", handle("files"))
+
+	for _, tt := range []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/users/42", http.StatusOK, "get:42:"},
+		{"DELETE", "/users/42/", http.StatusOK, "delete:42:"},
+		{"GET", "/files/a/b/c", http.StatusOK, "files::a/b/c"},
+		{"POST", "/users/42", http.StatusMethodNotAllowed, ""},
+		{"GET", "/users", http.StatusNotFound, ""},
+		{"GET", "/users/42/x", http.StatusNotFound, ""},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code {
+			t.Fatalf("%s %s: expect = %d, got = %d", tt.method, tt.path, tt.code, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Fatalf("%s %s: expect = %q, got = %q", tt.method, tt.path, tt.body, w.Body.String())
+		}
+	}
+}
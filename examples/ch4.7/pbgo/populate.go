@@ -0,0 +1,169 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pbgo
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PopulateFieldFromPath sets the field of msg named by the dotted
+// fieldPath, such as "value" or "user.name". Repeated fields get all the
+// values, other fields take the last one.
+func PopulateFieldFromPath(msg proto.Message, fieldPath string, values ...string) error {
+	m, fd, err := lookupField(msg.ProtoReflect(), fieldPath)
+	if err != nil {
+		return err
+	}
+	if fd == nil {
+		return fmt.Errorf("pbgo: %s has no field %q", msg.ProtoReflect().Descriptor().FullName(), fieldPath)
+	}
+	return setField(m, fd, fieldPath, values)
+}
+
+// PopulateQueryParameters sets the fields of msg named by the query keys.
+// Keys which don't name a field are ignored.
+func PopulateQueryParameters(msg proto.Message, query url.Values) error {
+	for key, values := range query {
+		m, fd, err := lookupField(msg.ProtoReflect(), key)
+		if err != nil {
+			return err
+		}
+		if fd == nil {
+			continue
+		}
+		if err := setField(m, fd, key, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PopulateFieldFromBody decodes the protobuf JSON request body into msg.
+// An empty body leaves msg unchanged.
+func PopulateFieldFromBody(msg proto.Message, r *http.Request) error {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	return protojson.Unmarshal(data, msg)
+}
+
+// WriteJSON writes msg as protobuf JSON.
+func WriteJSON(w http.ResponseWriter, msg proto.Message) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// lookupField walks the dotted path and returns the message holding the
+// last field. A nil field descriptor means that the field does not exist.
+func lookupField(m protoreflect.Message, fieldPath string) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		fields := m.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, nil, nil
+		}
+		if i == len(names)-1 {
+			return m, fd, nil
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil, nil, fmt.Errorf("pbgo: %s: %s is not a message field", fieldPath, name)
+		}
+		m = m.Mutable(fd).Message()
+	}
+	return nil, nil, nil
+}
+
+func setField(m protoreflect.Message, fd protoreflect.FieldDescriptor, fieldPath string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if fd.IsMap() || fd.Message() != nil {
+		return fmt.Errorf("pbgo: %s: unsupported field type %v", fieldPath, fd.Kind())
+	}
+
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseScalar(fd, s)
+			if err != nil {
+				return fmt.Errorf("pbgo: %s: %v", fieldPath, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	v, err := parseScalar(fd, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("pbgo: %s: %v", fieldPath, err)
+	}
+	m.Set(fd, v)
+	return nil
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field type %v", fd.Kind())
+}
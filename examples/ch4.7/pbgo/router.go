@@ -0,0 +1,103 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pbgo is the runtime support of the REST handlers generated by
+// protoc-gen-pbgo from the (pbgo.rest_api) method option.
+package pbgo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Param is a path parameter matched by a ":name" or "*name" segment.
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+// ByName returns the value of the first parameter named name.
+func (ps Params) ByName(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+type Handle func(w http.ResponseWriter, r *http.Request, ps Params)
+
+// Router is a minimal router using the httprouter path syntax: ":name"
+// matches one path segment and "*name" matches the rest of the path.
+type Router struct {
+	routes []route
+}
+
+type route struct {
+	method   string
+	segments []string
+	handle   Handle
+}
+
+func NewRouter() *Router {
+	return new(Router)
+}
+
+func (p *Router) Handle(method, path string, handle Handle) {
+	p.routes = append(p.routes, route{
+		method:   method,
+		segments: splitPath(path),
+		handle:   handle,
+	})
+}
+
+func (p *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var allow []string
+	for _, rt := range p.routes {
+		ps, ok := rt.match(splitPath(r.URL.Path))
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allow = append(allow, rt.method)
+			continue
+		}
+		rt.handle(w, r, ps)
+		return
+	}
+
+	if len(allow) > 0 {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (rt *route) match(segments []string) (Params, bool) {
+	var ps Params
+	for i, s := range rt.segments {
+		switch {
+		case strings.HasPrefix(s, "*"):
+			return append(ps, Param{Key: s[1:], Value: strings.Join(segments[i:], "/")}), true
+		case i >= len(segments):
+			return nil, false
+		case strings.HasPrefix(s, ":"):
+			if segments[i] == "" {
+				return nil, false
+			}
+			ps = append(ps, Param{Key: s[1:], Value: segments[i]})
+		case s != segments[i]:
+			return nil, false
+		}
+	}
+	return ps, len(segments) == len(rt.segments)
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
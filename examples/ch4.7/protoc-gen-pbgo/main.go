@@ -0,0 +1,50 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// protoc-gen-pbgo is a plugin for the Google protocol buffer compiler to
+// generate REST handlers from the (pbgo.rest_api) method option:
+//
+//	import "pbgo/pbgo.proto";
+//
+//	service HelloService {
+//		rpc Hello (String) returns (String) {
+//			option (pbgo.rest_api) = {
+//				get: "/hello/:value"
+//			};
+//		}
+//	}
+//
+// Run it next to protoc-gen-go and protoc-gen-go-netrpc:
+//
+//	protoc --go_out=. --go-netrpc_out=. --pbgo_out=. hello.proto
+//
+// HelloServiceHandler in hello_pbgo.pb.go wraps the HelloServiceInterface
+// generated by protoc-gen-go-netrpc in an http.Handler. Path parameters
+// and query strings are bound to the fields of the request message, the
+// reply is written as protobuf JSON.
+package main
+
+import (
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	protogen.Options{}.Run(generate)
+}
+
+func generate(gen *protogen.Plugin) error {
+	gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+	p := &pbgoPlugin{Plugin: gen}
+	for _, f := range gen.Files {
+		if !f.Generate {
+			continue
+		}
+		if err := p.Generate(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
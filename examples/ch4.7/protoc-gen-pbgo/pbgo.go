@@ -0,0 +1,267 @@
+// Copyright 2018 <chaishushan{AT}gmail.com>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+
+	"gobook.examples/ch4-07-pbgo/pbgo"
+)
+
+const (
+	httpPackage = protogen.GoImportPath("net/http")
+
+	// pbgoPackage is the runtime support of the generated code.
+	pbgoPackage = protogen.GoImportPath("gobook.examples/ch4-07-pbgo/pbgo")
+)
+
+type pbgoPlugin struct{ *protogen.Plugin }
+
+func (p *pbgoPlugin) Generate(file *protogen.File) error {
+	var specs []*ServiceSpec
+	for _, svc := range file.Services {
+		spec, err := p.buildServiceSpec(svc)
+		if err != nil {
+			return err
+		}
+		if spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+
+	g := p.NewGeneratedFile(file.GeneratedFilenamePrefix+"_pbgo.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-pbgo. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	g.QualifiedGoIdent(httpPackage.Ident("Handler"))
+	g.QualifiedGoIdent(pbgoPackage.Ident("Router"))
+	for _, spec := range specs {
+		for i, m := range spec.MethodList {
+			spec.MethodList[i].InputTypeName = g.QualifiedGoIdent(m.input)
+			spec.MethodList[i].OutputTypeName = g.QualifiedGoIdent(m.output)
+		}
+		if err := p.genServiceCode(g, spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ServiceSpec struct {
+	ServiceName string
+	MethodList  []ServiceMethodSpec
+}
+
+type ServiceMethodSpec struct {
+	MethodName     string
+	InputTypeName  string
+	OutputTypeName string
+	RuleList       []HttpRuleSpec
+
+	input, output protogen.GoIdent
+}
+
+type HttpRuleSpec struct {
+	Method string // GET, PUT, POST, DELETE or PATCH
+	Path   string
+	Params []string // the ":name" and "*name" segments of Path
+}
+
+// Verb is the lower case method, used in the handler names.
+func (p HttpRuleSpec) Verb() string {
+	return strings.ToLower(p.Method)
+}
+
+// HasBody reports whether the request body holds the request message.
+func (p HttpRuleSpec) HasBody() bool {
+	return p.Method == "PUT" || p.Method == "POST" || p.Method == "PATCH"
+}
+
+func (p *pbgoPlugin) genServiceCode(g *protogen.GeneratedFile, spec *ServiceSpec) error {
+	var buf bytes.Buffer
+	t := template.Must(template.New("").Parse(tmplService))
+	if err := t.Execute(&buf, spec); err != nil {
+		return err
+	}
+
+	g.P(buf.String())
+	return nil
+}
+
+// buildServiceSpec returns nil if no method of svc has a rest_api option.
+func (p *pbgoPlugin) buildServiceSpec(svc *protogen.Service) (*ServiceSpec, error) {
+	spec := &ServiceSpec{ServiceName: svc.GoName}
+
+	for _, m := range svc.Methods {
+		rule := p.getServiceMethodOption(m)
+		if rule == nil {
+			continue
+		}
+		if m.Desc.IsStreamingClient() || m.Desc.IsStreamingServer() {
+			return nil, fmt.Errorf(
+				"pbgo: %s: rest_api is not supported on streaming methods",
+				m.Desc.FullName(),
+			)
+		}
+
+		ms := ServiceMethodSpec{
+			MethodName: m.GoName,
+			input:      m.Input.GoIdent,
+			output:     m.Output.GoIdent,
+		}
+		for _, r := range []struct{ method, path string }{
+			{"GET", rule.GetGet()},
+			{"PUT", rule.GetPut()},
+			{"POST", rule.GetPost()},
+			{"DELETE", rule.GetDelete()},
+			{"PATCH", rule.GetPatch()},
+		} {
+			if r.path == "" {
+				continue
+			}
+			params, err := parsePath(m, r.path)
+			if err != nil {
+				return nil, err
+			}
+			ms.RuleList = append(ms.RuleList, HttpRuleSpec{
+				Method: r.method,
+				Path:   r.path,
+				Params: params,
+			})
+		}
+		if len(ms.RuleList) != 0 {
+			spec.MethodList = append(spec.MethodList, ms)
+		}
+	}
+
+	if len(spec.MethodList) == 0 {
+		return nil, nil
+	}
+	return spec, nil
+}
+
+func (p *pbgoPlugin) getServiceMethodOption(m *protogen.Method) *pbgo.HttpRule {
+	if m.Desc.Options() == nil || !proto.HasExtension(m.Desc.Options(), pbgo.E_RestApi) {
+		return nil
+	}
+	rule, _ := proto.GetExtension(m.Desc.Options(), pbgo.E_RestApi).(*pbgo.HttpRule)
+	return rule
+}
+
+// parsePath returns the parameters of path, checking that each of them
+// names a scalar field of the request message.
+func parsePath(m *protogen.Method, path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("pbgo: %s: path %q must start with /", m.Desc.FullName(), path)
+	}
+
+	var params []string
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if !strings.HasPrefix(s, ":") && !strings.HasPrefix(s, "*") {
+			continue
+		}
+		if s[0] == '*' && i != len(segments)-1 {
+			return nil, fmt.Errorf("pbgo: %s: %q must be the last segment of %q", m.Desc.FullName(), s, path)
+		}
+		if err := checkField(m.Input, s[1:]); err != nil {
+			return nil, fmt.Errorf("pbgo: %s: %q: %v", m.Desc.FullName(), path, err)
+		}
+		params = append(params, s[1:])
+	}
+	return params, nil
+}
+
+func checkField(msg *protogen.Message, fieldPath string) error {
+	names := strings.Split(fieldPath, ".")
+	for i, name := range names {
+		var field *protogen.Field
+		for _, f := range msg.Fields {
+			if string(f.Desc.Name()) == name {
+				field = f
+				break
+			}
+		}
+		switch {
+		case field == nil:
+			return fmt.Errorf("%s has no field %q", msg.Desc.FullName(), name)
+		case i == len(names)-1:
+			if field.Message != nil {
+				return fmt.Errorf("field %q is not a scalar", fieldPath)
+			}
+			return nil
+		case field.Message == nil || field.Desc.IsList() || field.Desc.IsMap():
+			return fmt.Errorf("field %q is not a message", name)
+		}
+		msg = field.Message
+	}
+	return nil
+}
+
+const tmplService = `
+{{$root := .}}
+
+// {{.ServiceName}}Handler returns the REST handler of the methods of svc
+// which have a (pbgo.rest_api) option.
+func {{.ServiceName}}Handler(svc {{.ServiceName}}Interface) http.Handler {
+	var router = pbgo.NewRouter()
+	{{- range $_, $m := .MethodList}}
+	{{- range $_, $r := $m.RuleList}}
+	_handle_{{$root.ServiceName}}_{{$m.MethodName}}_{{$r.Verb}}(router, svc)
+	{{- end}}
+	{{- end}}
+	return router
+}
+
+{{range $_, $m := .MethodList}}
+{{- range $_, $r := $m.RuleList}}
+func _handle_{{$root.ServiceName}}_{{$m.MethodName}}_{{$r.Verb}}(
+	router *pbgo.Router, svc {{$root.ServiceName}}Interface,
+) {
+	router.Handle("{{$r.Method}}", "{{$r.Path}}",
+		func(w http.ResponseWriter, r *http.Request, ps pbgo.Params) {
+			var protoReq {{$m.InputTypeName}}
+			var protoReply {{$m.OutputTypeName}}
+			{{if $r.HasBody}}
+			if err := pbgo.PopulateFieldFromBody(&protoReq, r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			{{- end}}
+			if err := pbgo.PopulateQueryParameters(&protoReq, r.URL.Query()); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			{{- range $_, $name := $r.Params}}
+			if err := pbgo.PopulateFieldFromPath(&protoReq, "{{$name}}", ps.ByName("{{$name}}")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			{{- end}}
+
+			if err := svc.{{$m.MethodName}}(&protoReq, &protoReply); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			pbgo.WriteJSON(w, &protoReply)
+		},
+	)
+}
+{{end}}
+{{- end}}
+`